@@ -0,0 +1,63 @@
+package connxray
+
+import (
+	"net"
+	"sync"
+	"time"
+)
+
+// reserve takes a single token from the bucket, possibly going into debt, and
+// returns how long it takes for the debt to be repaid, ie. how long to wait
+// before acting on the reservation.
+func (l *Limiter) reserve() time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens--
+	if l.tokens >= 0 {
+		return 0
+	}
+	return time.Duration(-l.tokens / l.rate * float64(time.Second))
+}
+
+// throttleAccept waits for AcceptLimiter to allow another Accept and reports
+// the delay to AfterAcceptRateLimit. It returns net.ErrClosed if the Listener
+// gets closed in the meantime.
+func (l *Listener) throttleAccept() error {
+	if l.AcceptLimiter == nil {
+		return nil
+	}
+	delay := l.AcceptLimiter.reserve()
+	if delay > 0 {
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-l.closing.done():
+			timer.Stop()
+			return net.ErrClosed
+		}
+	}
+	if l.AfterAcceptRateLimit != nil {
+		l.guard("AfterAcceptRateLimit", func() { l.AfterAcceptRateLimit(l, delay) })
+	}
+	return nil
+}
+
+// closeSignal is a channel, created on first use, which is closed once.
+type closeSignal struct {
+	once      sync.Once
+	ch        chan struct{}
+	closeOnce sync.Once
+}
+
+// done returns the channel.
+func (s *closeSignal) done() <-chan struct{} {
+	s.once.Do(func() { s.ch = make(chan struct{}) })
+	return s.ch
+}
+
+// close closes the channel, unless that already happened.
+func (s *closeSignal) close() {
+	s.done()
+	s.closeOnce.Do(func() { close(s.ch) })
+}
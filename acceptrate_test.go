@@ -0,0 +1,51 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestAcceptLimiter(t *testing.T) {
+	var delays []time.Duration
+	cl := NewListener(pipeListener(t), WithAcceptLimiter(NewLimiter(20, 1)))
+	cl.AfterAcceptRateLimit = func(_ *Listener, d time.Duration) {
+		delays = append(delays, d)
+	}
+	start := time.Now()
+	acceptN(t, cl, 3)
+	if elapsed := time.Since(start); elapsed < 90*time.Millisecond {
+		t.Errorf("Accepted 3 conns in %v, expected at least 90ms", elapsed)
+	}
+	if len(delays) != 3 {
+		t.Fatalf("Unexpected number of AfterAcceptRateLimit calls %d, expected 3", len(delays))
+	}
+	if delays[0] != 0 {
+		t.Errorf("Unexpected delay %v of the first accept, expected 0", delays[0])
+	}
+	for _, d := range delays[1:] {
+		if d < 40*time.Millisecond || d > time.Second {
+			t.Errorf("Unexpected delay %v, expected about 50ms", d)
+		}
+	}
+}
+
+func TestAcceptLimiterInterruptedByClose(t *testing.T) {
+	cl := &Listener{Base: pipeListener(t), AcceptLimiter: NewLimiter(1, 1)}
+	acceptN(t, cl, 1)
+	done := make(chan error)
+	go func() {
+		_, err := cl.Accept()
+		done <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	cl.Close()
+	select {
+	case err := <-done:
+		if err != net.ErrClosed {
+			t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Error("Accept still waiting for the limiter after Close")
+	}
+}
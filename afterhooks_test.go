@@ -0,0 +1,160 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestAfterHooksObserveReturnedValues(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "bac"), expectedErr
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return 2, expectedErr
+		},
+	}
+	var readN, writeN int
+	var readErr, writeErr error
+	cc := &Conn{
+		Base: mc,
+		AfterRead: func(_ *Conn, _ []byte, n int, err error) {
+			readN, readErr = n, err
+		},
+		AfterWrite: func(_ *Conn, _ []byte, n int, err error) {
+			writeN, writeErr = n, err
+		},
+	}
+	n, err := cc.Read(make([]byte, 8))
+	if readN != n || readErr != err || n != 3 || err != expectedErr {
+		t.Errorf("Unexpected hook values (%d, %v), expected (%d, %v)", readN, readErr, n, err)
+	}
+	n, err = cc.Write([]byte("bacon"))
+	if writeN != n || writeErr != err || n != 2 || err != expectedErr {
+		t.Errorf("Unexpected hook values (%d, %v), expected (%d, %v)", writeN, writeErr, n, err)
+	}
+}
+
+func TestAfterHooksRunInOrderBeforeReturn(t *testing.T) {
+	var calls []string
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			calls = append(calls, "base")
+			return 0, nil
+		},
+		closeHandler: func() error {
+			calls = append(calls, "base")
+			return nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		TransformRead: func(_ *Conn, _ []byte, n int, err error) (int, error) {
+			calls = append(calls, "TransformRead")
+			return n, err
+		},
+		AfterRead: func(*Conn, []byte, int, error) {
+			calls = append(calls, "AfterRead")
+		},
+		AfterReadCtx: func(context.Context, *Conn, []byte, int, error) {
+			calls = append(calls, "AfterReadCtx")
+		},
+		AfterClose: func(*Conn, error) {
+			calls = append(calls, "AfterClose")
+		},
+		AfterCloseCtx: func(context.Context, *Conn, error) {
+			calls = append(calls, "AfterCloseCtx")
+		},
+	}
+	cc.Read(nil)
+	calls = append(calls, "returned")
+	cc.Close()
+	calls = append(calls, "returned")
+	expected := []string{
+		"base", "TransformRead", "AfterRead", "AfterReadCtx", "returned",
+		"base", "AfterClose", "AfterCloseCtx", "returned",
+	}
+	if !reflect.DeepEqual(calls, expected) {
+		t.Errorf("Unexpected calls %v, expected %v", calls, expected)
+	}
+}
+
+func TestListenerAfterHooksBeforeReturn(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, expectedErr
+		},
+		closeHandler: func() error {
+			return expectedErr
+		},
+	}
+	var acceptErr, closeErr error
+	ll := &Listener{
+		Base: ml,
+		AfterAccept: func(_ *Listener, _ *Conn, err error) {
+			acceptErr = err
+		},
+		AfterClose: func(_ *Listener, err error) {
+			closeErr = err
+		},
+	}
+	if _, err := ll.Accept(); err != acceptErr {
+		t.Errorf("Unexpected error %v, expected %v", err, acceptErr)
+	}
+	if err := ll.Close(); err != closeErr {
+		t.Errorf("Unexpected error %v, expected %v", err, closeErr)
+	}
+}
+
+func TestAfterReadTimed(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			time.Sleep(20 * time.Millisecond)
+			return copy(b, "bacon"), nil
+		},
+	}
+	var elapsed time.Duration
+	var got string
+	cc := &Conn{
+		Base: mc,
+		AfterReadTimed: func(_ *Conn, b []byte, n int, d time.Duration, err error) {
+			got, elapsed = string(b[:n]), d
+		},
+	}
+	cc.Read(make([]byte, 8))
+	if got != "bacon" {
+		t.Errorf("Unexpected data %q, expected %q", got, "bacon")
+	}
+	if elapsed < 20*time.Millisecond || elapsed > time.Second {
+		t.Errorf("Unexpected elapsed time %v, expected between 20ms and 1s", elapsed)
+	}
+}
+
+func TestAfterWriteTimedExcludesHooks(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	var elapsed time.Duration
+	cc := &Conn{
+		Base: mc,
+		BeforeWrite: func(*Conn, []byte) error {
+			time.Sleep(20 * time.Millisecond)
+			return nil
+		},
+		AfterWriteTimed: func(_ *Conn, _ []byte, _ int, d time.Duration, _ error) {
+			elapsed = d
+		},
+	}
+	cc.Write([]byte("bacon"))
+	if elapsed >= 20*time.Millisecond {
+		t.Errorf("Unexpected elapsed time %v, expected the 'before' hook to be excluded", elapsed)
+	}
+}
@@ -0,0 +1,54 @@
+package connxray
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// Age returns the time elapsed since the Conn was accepted by a Listener,
+// dialed by a Dialer or constructed with NewConn. Once the Conn is closed it
+// stops growing and reports the lifetime of the Conn, up to its first Close.
+// It returns zero for a Conn created with a struct literal, whose creation time
+// is unknown.
+func (c *Conn) Age() time.Duration {
+	if c.created.IsZero() {
+		return 0
+	}
+	if closed := c.closedAt.Load(); closed != nil {
+		return closed.Sub(c.created)
+	}
+	return timeNow().Sub(c.created)
+}
+
+// recordClose notes the time at which the Conn was first closed.
+func (c *Conn) recordClose() {
+	now := timeNow()
+	c.closedAt.CompareAndSwap(nil, &now)
+}
+
+// firstTransfer records whether data was transferred in one direction yet.
+type firstTransfer struct {
+	done atomic.Bool
+}
+
+// once tells whether a transfer of n bytes is the first one moving any data
+// and, if so, returns the Age of c at that point.
+func (f *firstTransfer) once(n int, c *Conn) (bool, time.Duration) {
+	if n <= 0 || f.done.Load() || !f.done.CompareAndSwap(false, true) {
+		return false, 0
+	}
+	return true, c.Age()
+}
+
+// SetAbsoluteDeadline sets the deadline of the Conn (through SetDeadline, so
+// its hooks fire) to total after the Conn was created (see Age), rather than
+// after the current time. This caps the lifetime of a connection regardless of
+// its activity, unlike IdleTimeout. For a Conn whose creation time is unknown
+// the deadline is total from now.
+func (c *Conn) SetAbsoluteDeadline(total time.Duration) error {
+	created := c.created
+	if created.IsZero() {
+		created = time.Now()
+	}
+	return c.SetDeadline(created.Add(total))
+}
@@ -0,0 +1,123 @@
+package connxray
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestAge(t *testing.T) {
+	if age := (&Conn{}).Age(); age != 0 {
+		t.Errorf("Unexpected age %v, expected 0", age)
+	}
+	cc := NewConn(&mockConn{})
+	time.Sleep(10 * time.Millisecond)
+	if age := cc.Age(); age < 10*time.Millisecond {
+		t.Errorf("Unexpected age %v, expected at least 10ms", age)
+	}
+}
+
+func TestSetAbsoluteDeadline(t *testing.T) {
+	var deadlines []time.Time
+	mc := &mockConn{
+		setDeadlineHandler: func(time.Time) error {
+			return nil
+		},
+	}
+	cc := NewConn(mc)
+	cc.AfterSetDeadline = func(_ *Conn, t time.Time, _ error) {
+		deadlines = append(deadlines, t)
+	}
+	cc.SetAbsoluteDeadline(time.Minute)
+	time.Sleep(20 * time.Millisecond)
+	cc.SetAbsoluteDeadline(time.Minute)
+	if len(deadlines) != 2 {
+		t.Fatalf("Unexpected number of AfterSetDeadline calls %d, expected 2", len(deadlines))
+	}
+	if !deadlines[0].Equal(deadlines[1]) {
+		t.Errorf("Unexpected deadlines %v and %v, expected them to be equal", deadlines[0], deadlines[1])
+	}
+	if read, write := cc.Deadlines(); !read.Equal(deadlines[0]) || !write.Equal(deadlines[0]) {
+		t.Errorf("Unexpected deadlines (%v, %v), expected %v", read, write, deadlines[0])
+	}
+}
+
+func TestAfterFirstRead(t *testing.T) {
+	reads := 0
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			reads++
+			switch reads {
+			case 1:
+				return 0, nil
+			case 2:
+				time.Sleep(20 * time.Millisecond)
+			}
+			return len(b), nil
+		},
+	}
+	var calls []time.Duration
+	cc := NewConn(mc, WithTemplate(&Conn{
+		AfterFirstRead: func(_ *Conn, d time.Duration) {
+			calls = append(calls, d)
+		},
+	}))
+	buf := make([]byte, 4)
+	for i := 0; i < 4; i++ {
+		cc.Read(buf)
+	}
+	if len(calls) != 1 {
+		t.Fatalf("Unexpected number of AfterFirstRead calls %d, expected 1", len(calls))
+	}
+	if calls[0] < 20*time.Millisecond || calls[0] > time.Second {
+		t.Errorf("Unexpected time to first byte %v, expected between 20ms and 1s", calls[0])
+	}
+}
+
+func TestAfterFirstWrite(t *testing.T) {
+	calls := 0
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				return len(b), nil
+			},
+		},
+		AfterFirstWrite: func(_ *Conn, d time.Duration) {
+			calls++
+			if d != 0 {
+				t.Errorf("Unexpected duration %v for unknown creation time, expected 0", d)
+			}
+		},
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cc.Write([]byte("bacon"))
+		}()
+	}
+	wg.Wait()
+	if calls != 1 {
+		t.Errorf("Unexpected number of AfterFirstWrite calls %d, expected 1", calls)
+	}
+}
+
+func TestAgeStopsAtClose(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	advance := fakeClock(t, start)
+	cc := &Conn{
+		Base:       &mockConn{closeHandler: func() error { return nil }},
+		created:    start,
+		TrackStats: true,
+	}
+	advance(time.Second)
+	cc.Close()
+	advance(time.Minute)
+	first := cc.Stats().Age
+	advance(time.Minute)
+	cc.Close()
+	if second := cc.Age(); first != time.Second || second != first {
+		t.Errorf("Unexpected ages %v and %v after Close, expected 1s twice", first, second)
+	}
+}
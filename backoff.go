@@ -0,0 +1,74 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"time"
+)
+
+const (
+	// minAcceptRetryDelay and maxAcceptRetryDelay bound the backoff applied
+	// by Accept to temporary errors. They are the same as in net/http.
+	minAcceptRetryDelay = 5 * time.Millisecond
+	maxAcceptRetryDelay = time.Second
+)
+
+// acceptBackoff computes delays between Accept retries.
+type acceptBackoff struct {
+	delay time.Duration
+}
+
+// retry tells whether Accept should be retried after err and if so, waits
+// for the next delay, reporting it to AfterAcceptRetry first. Waiting is cut
+// short by Close, in which case Accept is not retried.
+func (b *acceptBackoff) retry(l *Listener, err error) bool {
+	if !l.RetryTemporary || l.conns.isShutdown() || !isTemporary(err) {
+		return false
+	}
+	delay := b.next()
+	if l.AfterAcceptRetry != nil {
+		l.guard("AfterAcceptRetry", func() { l.AfterAcceptRetry(l, err, delay) })
+	}
+	return sleepUnlessClosed(l, delay)
+}
+
+// wait waits for the next delay and tells whether it elapsed, rather than
+// being cut short by Close.
+func (b *acceptBackoff) wait(l *Listener) bool {
+	return sleepUnlessClosed(l, b.next())
+}
+
+// sleepUnlessClosed waits for delay or until l is closed, and tells whether the
+// delay elapsed.
+func sleepUnlessClosed(l *Listener, delay time.Duration) bool {
+	timer := time.NewTimer(delay)
+	select {
+	case <-timer.C:
+		return true
+	case <-l.closing.done():
+		timer.Stop()
+		return false
+	}
+}
+
+// next doubles the delay, within bounds, and returns it.
+func (b *acceptBackoff) next() time.Duration {
+	if b.delay == 0 {
+		b.delay = minAcceptRetryDelay
+	} else if b.delay *= 2; b.delay > maxAcceptRetryDelay {
+		b.delay = maxAcceptRetryDelay
+	}
+	return b.delay
+}
+
+// isTemporary tells whether err is (or wraps) a net.Error which is temporary.
+func isTemporary(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Temporary()
+}
+
+// isTimeout tells whether err is (or wraps) a net.Error which is a timeout.
+func isTimeout(err error) bool {
+	var ne net.Error
+	return errors.As(err, &ne) && ne.Timeout()
+}
@@ -0,0 +1,129 @@
+package connxray
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"testing"
+	"time"
+)
+
+// temporaryError is a net.Error which is temporary.
+type temporaryError struct{}
+
+func (temporaryError) Error() string   { return "too many open files" }
+func (temporaryError) Timeout() bool   { return false }
+func (temporaryError) Temporary() bool { return true }
+
+func TestAcceptRetryTemporary(t *testing.T) {
+	attempts := 0
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			attempts++
+			if attempts <= 3 {
+				return nil, temporaryError{}
+			}
+			return &mockConn{}, nil
+		},
+	}
+	var delays []time.Duration
+	ll := &Listener{
+		Base:           ml,
+		RetryTemporary: true,
+		AfterAcceptRetry: func(_ *Listener, err error, d time.Duration) {
+			if _, ok := err.(temporaryError); !ok {
+				t.Errorf("Unexpected error %v, expected %v", err, temporaryError{})
+			}
+			delays = append(delays, d)
+		},
+	}
+	conn, err := ll.Accept()
+	if conn == nil || err != nil {
+		t.Fatalf("Unexpected result (%v, %v), expected a conn", conn, err)
+	}
+	expected := []time.Duration{5 * time.Millisecond, 10 * time.Millisecond, 20 * time.Millisecond}
+	if len(delays) != len(expected) {
+		t.Fatalf("Unexpected delays %v, expected %v", delays, expected)
+	}
+	for i := range expected {
+		if delays[i] != expected[i] {
+			t.Errorf("Unexpected delays %v, expected %v", delays, expected)
+			break
+		}
+	}
+}
+
+func TestAcceptRetryTemporaryDisabled(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, temporaryError{}
+		},
+	}
+	ll := &Listener{Base: ml}
+	if _, err := ll.Accept(); err != (temporaryError{}) {
+		t.Errorf("Unexpected error %v, expected %v", err, temporaryError{})
+	}
+}
+
+func TestAcceptRetryPermanentError(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, expectedErr
+		},
+	}
+	ll := &Listener{
+		Base:           ml,
+		RetryTemporary: true,
+		AfterAcceptRetry: func(*Listener, error, time.Duration) {
+			t.Error("Unexpected retry of a permanent error")
+		},
+	}
+	if _, err := ll.Accept(); err != expectedErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expectedErr)
+	}
+}
+
+func TestAcceptBackoffCapped(t *testing.T) {
+	b := acceptBackoff{delay: 800 * time.Millisecond}
+	for i := 0; i < 2; i++ {
+		if d := b.next(); d != time.Second {
+			t.Errorf("Unexpected delay %v, expected %v", d, time.Second)
+		}
+	}
+}
+
+func TestAcceptRetryWrappedTemporary(t *testing.T) {
+	err := fmt.Errorf("accept: %w", temporaryError{})
+	if !isTemporary(err) {
+		t.Errorf("Wrapped error %v not considered temporary", err)
+	}
+}
+
+func TestAcceptRetryInterruptedByClose(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, temporaryError{}
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	ll := &Listener{Base: ml, RetryTemporary: true}
+	// Start with the longest delay, so that only Close can end the wait.
+	backoff := acceptBackoff{delay: maxAcceptRetryDelay}
+	done := make(chan bool)
+	go func() {
+		done <- backoff.retry(ll, temporaryError{})
+	}()
+	time.Sleep(10 * time.Millisecond)
+	ll.Close()
+	select {
+	case retried := <-done:
+		if retried {
+			t.Error("Unexpected retry after Close")
+		}
+	case <-time.After(500 * time.Millisecond):
+		t.Fatal("Backoff was not interrupted by Close")
+	}
+}
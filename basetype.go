@@ -0,0 +1,116 @@
+package connxray
+
+import (
+	"crypto/tls"
+	"net"
+	"reflect"
+)
+
+// walkBase calls visit on every layer underneath this Conn, starting with
+// Base and descending through nested Conns and through wrappers which expose
+// their own underlying connection via a NetConn method (eg. *tls.Conn). It
+// stops as soon as visit returns true and reports whether that happened.
+func (c *Conn) walkBase(visit func(net.Conn) bool) bool {
+	base := c.Base
+	for base != nil {
+		if visit(base) {
+			return true
+		}
+		switch b := base.(type) {
+		case *Conn:
+			base = b.Base
+		case interface{ NetConn() net.Conn }:
+			base = b.NetConn()
+		default:
+			return false
+		}
+	}
+	return false
+}
+
+// innerBase returns the first base underneath this Conn which is not itself a
+// Conn.
+func (c *Conn) innerBase() net.Conn {
+	base := c.Base
+	for {
+		cc, isConn := base.(*Conn)
+		if !isConn {
+			return base
+		}
+		base = cc.Base
+	}
+}
+
+// Unwrap returns the underlying net.Conn.
+func (c *Conn) Unwrap() net.Conn {
+	return c.Base
+}
+
+// Unwrap returns the underlying net.Listener.
+func (l *Listener) Unwrap() net.Listener {
+	return l.Base
+}
+
+// BaseConn looks for a connection of type T in c and underneath it, unwrapping
+// layers which have an Unwrap method returning net.Conn (such as Conn and
+// StreamConn), eg. to reach the *net.TCPConn under two nested Conns.
+func BaseConn[T net.Conn](c net.Conn) (T, bool) {
+	for c != nil {
+		if t, ok := c.(T); ok {
+			return t, true
+		}
+		u, ok := c.(interface{ Unwrap() net.Conn })
+		if !ok {
+			break
+		}
+		c = u.Unwrap()
+	}
+	var zero T
+	return zero, false
+}
+
+// BaseType returns the concrete type of the connection wrapped by this Conn,
+// looking through any nested Conns. It returns nil if there is no base.
+func (c *Conn) BaseType() reflect.Type {
+	base := c.innerBase()
+	if base == nil {
+		return nil
+	}
+	return reflect.TypeOf(base)
+}
+
+// IsTCP tells whether this Conn is backed by a *net.TCPConn, possibly
+// underneath other wrappers.
+func (c *Conn) IsTCP() bool {
+	return c.walkBase(func(base net.Conn) bool {
+		_, ok := base.(*net.TCPConn)
+		return ok
+	})
+}
+
+// IsUDP tells whether this Conn is backed by a *net.UDPConn, possibly
+// underneath other wrappers.
+func (c *Conn) IsUDP() bool {
+	return c.walkBase(func(base net.Conn) bool {
+		_, ok := base.(*net.UDPConn)
+		return ok
+	})
+}
+
+// IsUnix tells whether this Conn is backed by a *net.UnixConn, possibly
+// underneath other wrappers.
+func (c *Conn) IsUnix() bool {
+	return c.walkBase(func(base net.Conn) bool {
+		_, ok := base.(*net.UnixConn)
+		return ok
+	})
+}
+
+// IsTLS tells whether this Conn wraps a *tls.Conn, possibly underneath other
+// wrappers.
+func (c *Conn) IsTLS() bool {
+	return c.walkBase(func(base net.Conn) bool {
+		_, ok := base.(*tls.Conn)
+		return ok
+	})
+}
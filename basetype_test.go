@@ -0,0 +1,144 @@
+package connxray
+
+import (
+	"crypto/tls"
+	"net"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+// tcpConnPair returns both ends of a loopback TCP connection.
+func tcpConnPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	l, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Error creating a TCP listener: %v", err)
+	}
+	defer l.Close()
+	client, err := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	server, err := l.AcceptTCP()
+	if err != nil {
+		t.Fatalf("Error accepting: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server
+}
+
+type classification struct {
+	tcp, udp, unix, tls bool
+}
+
+func classify(c *Conn) classification {
+	return classification{c.IsTCP(), c.IsUDP(), c.IsUnix(), c.IsTLS()}
+}
+
+func TestBaseTypeTCP(t *testing.T) {
+	tcp, _ := tcpConnPair(t)
+	cc := &Conn{Base: tcp}
+	if typ := cc.BaseType(); typ != reflect.TypeOf(tcp) {
+		t.Errorf("Unexpected type %v, expected %v", typ, reflect.TypeOf(tcp))
+	}
+	if got, exp := classify(cc), (classification{tcp: true}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+}
+
+func TestBaseTypeUDP(t *testing.T) {
+	udp, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Error creating a UDP conn: %v", err)
+	}
+	defer udp.Close()
+	cc := &Conn{Base: udp}
+	if typ := cc.BaseType(); typ != reflect.TypeOf(udp) {
+		t.Errorf("Unexpected type %v, expected %v", typ, reflect.TypeOf(udp))
+	}
+	if got, exp := classify(cc), (classification{udp: true}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+}
+
+func TestBaseTypeUnix(t *testing.T) {
+	addr := &net.UnixAddr{
+		Name: filepath.Join(t.TempDir(), "sock"),
+		Net:  "unixgram",
+	}
+	unix, err := net.ListenUnixgram("unixgram", addr)
+	if err != nil {
+		t.Fatalf("Error creating a Unix conn: %v", err)
+	}
+	defer unix.Close()
+	cc := &Conn{Base: unix}
+	if got, exp := classify(cc), (classification{unix: true}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+}
+
+func TestBaseTypeTLS(t *testing.T) {
+	tcp, _ := tcpConnPair(t)
+	tlsConn := tls.Client(tcp, &tls.Config{InsecureSkipVerify: true})
+	cc := &Conn{Base: tlsConn}
+	if typ := cc.BaseType(); typ != reflect.TypeOf(tlsConn) {
+		t.Errorf("Unexpected type %v, expected %v", typ, reflect.TypeOf(tlsConn))
+	}
+	if got, exp := classify(cc), (classification{tcp: true, tls: true}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+}
+
+func TestBaseTypeNested(t *testing.T) {
+	tcp, _ := tcpConnPair(t)
+	cc := &Conn{Base: &Conn{Base: &Conn{Base: tcp}}}
+	if typ := cc.BaseType(); typ != reflect.TypeOf(tcp) {
+		t.Errorf("Unexpected type %v, expected %v", typ, reflect.TypeOf(tcp))
+	}
+	if got, exp := classify(cc), (classification{tcp: true}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+}
+
+func TestBaseTypeUnknown(t *testing.T) {
+	mc := &mockConn{}
+	cc := &Conn{Base: mc}
+	if typ := cc.BaseType(); typ != reflect.TypeOf(mc) {
+		t.Errorf("Unexpected type %v, expected %v", typ, reflect.TypeOf(mc))
+	}
+	if got, exp := classify(cc), (classification{}); got != exp {
+		t.Errorf("Unexpected classification %+v, expected %+v", got, exp)
+	}
+	if typ := (&Conn{}).BaseType(); typ != nil {
+		t.Errorf("Unexpected type %v, expected nil", typ)
+	}
+}
+
+func TestUnwrap(t *testing.T) {
+	mc := &mockConn{}
+	if got := (&Conn{Base: mc}).Unwrap(); got != mc {
+		t.Errorf("Unexpected base %v, expected %v", got, mc)
+	}
+	ml := &mockListener{}
+	if got := (&Listener{Base: ml}).Unwrap(); got != ml {
+		t.Errorf("Unexpected base %v, expected %v", got, ml)
+	}
+}
+
+func TestBaseConnThroughNestedConns(t *testing.T) {
+	client, _ := tcpConnPair(t)
+	wrapped := (&Conn{Base: &Conn{Base: client}}).Stream()
+	tcp, ok := BaseConn[*net.TCPConn](wrapped)
+	if !ok || tcp != client {
+		t.Errorf("Unexpected results (%v, %v), expected (%v, true)", tcp, ok, client)
+	}
+	if err := tcp.SetLinger(0); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if _, ok := BaseConn[*net.UDPConn](wrapped); ok {
+		t.Error("Unexpected *net.UDPConn found")
+	}
+}
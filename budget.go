@@ -0,0 +1,51 @@
+package connxray
+
+import (
+	"time"
+)
+
+// Budget is a deadline shared by multiple connections, modelling a
+// request-wide timeout across fan-out connections: once the deadline passes,
+// all I/O on all enrolled connections fails with a timeout error.
+type Budget struct {
+	deadline time.Time
+}
+
+// NewBudget creates a Budget expiring at deadline.
+func NewBudget(deadline time.Time) *Budget {
+	return &Budget{deadline: deadline}
+}
+
+// Deadline returns the shared deadline.
+func (b *Budget) Deadline() time.Time {
+	return b.deadline
+}
+
+// Expired tells whether the shared deadline has passed.
+func (b *Budget) Expired() bool {
+	return !time.Now().Before(b.deadline)
+}
+
+// Enroll makes c subject to the shared deadline by setting it as the deadline
+// on c (hooks fire as usual). From then on deadlines set on c are capped at
+// the shared deadline, so the budget can't be circumvented by extending or
+// clearing them. A Conn can be enrolled in one Budget at a time; enrolling it
+// in another one replaces the previous budget.
+func (b *Budget) Enroll(c *Conn) error {
+	c.budget.Store(b)
+	return c.SetDeadline(b.deadline)
+}
+
+// clampToBudget caps the deadline t at the deadline of the Budget this Conn is
+// enrolled in, if any. A zero t (no deadline) is replaced with the budget's
+// deadline.
+func (c *Conn) clampToBudget(t time.Time) time.Time {
+	b := c.budget.Load()
+	if b == nil {
+		return t
+	}
+	if t.IsZero() || t.After(b.deadline) {
+		return b.deadline
+	}
+	return t
+}
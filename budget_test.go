@@ -0,0 +1,87 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"testing"
+	"time"
+)
+
+// echoPipe returns a Conn wrapping one end of a net.Pipe whose other end
+// echoes everything back.
+func echoPipe(t *testing.T) *Conn {
+	client, server := net.Pipe()
+	go io.Copy(server, server)
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return &Conn{Base: client}
+}
+
+func roundTrip(c *Conn) error {
+	if _, err := c.Write([]byte("ping")); err != nil {
+		return err
+	}
+	_, err := io.ReadFull(c, make([]byte, 4))
+	return err
+}
+
+func TestBudgetSharedDeadline(t *testing.T) {
+	c1, c2 := echoPipe(t), echoPipe(t)
+	budget := NewBudget(time.Now().Add(100 * time.Millisecond))
+	hookCalled := false
+	c1.AfterSetDeadline = func(_ *Conn, d time.Time, _ error) {
+		if !d.Equal(budget.Deadline()) {
+			t.Errorf("Unexpected deadline %v, expected %v", d, budget.Deadline())
+		}
+		hookCalled = true
+	}
+	for _, c := range []*Conn{c1, c2} {
+		if err := budget.Enroll(c); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if !hookCalled {
+		t.Error("After callback not invoked")
+	}
+	c1.AfterSetDeadline = nil
+	for _, c := range []*Conn{c1, c2} {
+		if err := roundTrip(c); err != nil {
+			t.Errorf("Unexpected error %v before the deadline", err)
+		}
+	}
+	if budget.Expired() {
+		t.Error("Budget expired too early")
+	}
+	if err := c1.SetDeadline(time.Time{}); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := c2.SetReadDeadline(time.Now().Add(time.Hour)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	time.Sleep(time.Until(budget.Deadline()) + 10*time.Millisecond)
+	if !budget.Expired() {
+		t.Error("Budget not expired")
+	}
+	for _, c := range []*Conn{c1, c2} {
+		err := roundTrip(c)
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			t.Errorf("Unexpected error %v, expected a timeout", err)
+		}
+	}
+}
+
+func TestBudgetAllowsEarlierDeadline(t *testing.T) {
+	c := echoPipe(t)
+	budget := NewBudget(time.Now().Add(time.Hour))
+	if err := budget.Enroll(c); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := c.SetReadDeadline(time.Now().Add(-time.Second)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := c.Read(make([]byte, 1)); err == nil {
+		t.Error("Expected a timeout error")
+	}
+}
@@ -0,0 +1,45 @@
+package connxray
+
+import (
+	"net"
+)
+
+// WriteBuffers writes the contents of b to the underlying net.Conn and invokes
+// relevant hooks ('before' and 'after') that were set up. Unlike passing the
+// Conn to net.Buffers.WriteTo, which falls back to one Write per buffer, this
+// lets connections which support it (eg. *net.TCPConn) write all the buffers
+// with a single writev system call. As with net.Buffers.WriteTo, b is
+// consumed. For the purpose of Stats, ErrorCounts and the event log this
+// counts as a single Write.
+func (c *Conn) WriteBuffers(b *net.Buffers) (int64, error) {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeWriteBuffersHook(); hook != nil {
+		unpin := c.keys.pin(&c.keys.writeMu)
+		err := hook(c, b)
+		unpin()
+		if err != nil {
+			return 0, beforeHookError("WriteBuffers", err)
+		}
+	}
+	var n int64
+	err := ErrConnClosed
+	if !c.rejectsIO() {
+		start := c.now()
+		c.waitForInFlight()
+		c.syscalls.writes.Add(1)
+		if base, isConn := c.Base.(*Conn); isConn {
+			n, err = base.WriteBuffers(b)
+		} else {
+			n, err = b.WriteTo(c.Base)
+		}
+		c.spentInBase(start)
+		c.trackWrite(int(n), err)
+		c.errorCounts.record(err)
+		c.recordEvent(Event{Kind: EventWrite, N: int(n), Err: err})
+		c.touchIdle(err)
+	}
+	if hook := c.AfterWriteBuffersHook(); hook != nil {
+		hook(c, n, err)
+	}
+	return n, err
+}
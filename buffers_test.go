@@ -0,0 +1,64 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+)
+
+func TestWriteBuffersTCP(t *testing.T) {
+	client, server := tcpConnPair(t)
+	var afterN int64
+	cc := &Conn{
+		Base:       &Conn{Base: client},
+		TrackStats: true,
+		AfterWriteBuffers: func(_ *Conn, n int64, err error) {
+			afterN = n
+			if err != nil {
+				t.Errorf("Unexpected error %v, expected nil", err)
+			}
+		},
+	}
+	bufs := net.Buffers{[]byte("chunky "), []byte("bacon")}
+	n, err := cc.WriteBuffers(&bufs)
+	if n != 12 || err != nil {
+		t.Fatalf("Unexpected results (%d, %v), expected (12, nil)", n, err)
+	}
+	if len(bufs) != 0 {
+		t.Errorf("Unexpected %d buffers left, expected none", len(bufs))
+	}
+	got := make([]byte, 12)
+	if _, err := io.ReadFull(server, got); err != nil || string(got) != "chunky bacon" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"chunky bacon\", nil)", got, err)
+	}
+	if afterN != 12 {
+		t.Errorf("Unexpected after callback n %d, expected 12", afterN)
+	}
+	if stats := cc.Stats(); stats.Writes != 1 || stats.BytesWritten != 12 {
+		t.Errorf("Unexpected stats %+v, expected 1 write of 12 bytes", stats)
+	}
+}
+
+func TestWriteBuffersWithFailingBeforeCallback(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeWriteBuffers: func(*Conn, *net.Buffers) error {
+			return expErr
+		},
+		AfterWriteBuffers: func(*Conn, int64, error) {
+			t.Error("After callback invoked")
+		},
+	}
+	bufs := net.Buffers{[]byte("foo")}
+	if _, err := cc.WriteBuffers(&bufs); !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
@@ -0,0 +1,15 @@
+package connxray
+
+// trackLifetime arranges for OnShortLivedConn to be invoked if conn gets
+// closed within less than ShortLivedThreshold.
+func (l *Listener) trackLifetime(conn *Conn) {
+	if l.ShortLivedThreshold <= 0 || l.OnShortLivedConn == nil {
+		return
+	}
+	threshold, hook := l.ShortLivedThreshold, l.OnShortLivedConn
+	conn.onClose(func(c *Conn) {
+		if lifetime := c.Age(); lifetime < threshold {
+			l.guard("OnShortLivedConn", func() { hook(c, lifetime) })
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+	"time"
+)
+
+func TestShortLivedConn(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{
+				closeHandler: func() error {
+					return nil
+				},
+			}, nil
+		},
+	}
+	threshold := 50 * time.Millisecond
+	reported := map[*Conn]time.Duration{}
+	cl := &Listener{
+		Base:                ml,
+		ShortLivedThreshold: threshold,
+		OnShortLivedConn: func(c *Conn, lifetime time.Duration) {
+			reported[c] = lifetime
+		},
+	}
+	short, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	long, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	short.Close()
+	short.Close()
+	time.Sleep(threshold)
+	long.Close()
+	if len(reported) != 1 {
+		t.Fatalf("Unexpected number of short-lived conns %d, expected 1", len(reported))
+	}
+	lifetime, ok := reported[short.(*Conn)]
+	if !ok {
+		t.Fatal("Short-lived conn not reported")
+	}
+	if lifetime >= threshold {
+		t.Errorf("Unexpected lifetime %v, expected less than %v", lifetime, threshold)
+	}
+}
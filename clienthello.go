@@ -0,0 +1,213 @@
+package connxray
+
+import (
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+)
+
+// ErrBadClientHello is returned by ClientHelloInfo if the first bytes read
+// from the connection are not a well-formed TLS ClientHello.
+var ErrBadClientHello = errors.New("malformed TLS ClientHello")
+
+const (
+	tlsRecordHeaderLen     = 5
+	tlsMaxRecordLen        = 1 << 14
+	tlsRecordTypeHandshake = 22
+	tlsTypeClientHello     = 1
+	tlsExtServerName       = 0
+	tlsExtALPN             = 16
+	tlsExtSupportedVersion = 43
+)
+
+// ClientHelloInfo peeks at the first TLS record sent by the client and parses
+// the ClientHello it carries, so that decisions (eg. SNI-based routing) can be
+// made before the handshake without terminating TLS. The bytes are not
+// consumed, so they are still returned by subsequent Reads. Only ServerName,
+// SupportedProtos (ALPN), CipherSuites, SupportedVersions and Conn are set.
+//
+// ErrBadClientHello is returned if the data is not a TLS ClientHello or the
+// ClientHello does not fit in the first record. Errors from Peek (eg. io.EOF)
+// are returned as is.
+func (c *Conn) ClientHelloInfo() (*tls.ClientHelloInfo, error) {
+	header, err := c.Peek(tlsRecordHeaderLen)
+	if len(header) > 0 && header[0] != tlsRecordTypeHandshake {
+		return nil, ErrBadClientHello
+	}
+	if err != nil {
+		return nil, err
+	}
+	if header[1] != 3 {
+		return nil, ErrBadClientHello
+	}
+	length := int(binary.BigEndian.Uint16(header[3:]))
+	if length == 0 || length > tlsMaxRecordLen {
+		return nil, ErrBadClientHello
+	}
+	record, err := c.Peek(tlsRecordHeaderLen + length)
+	if err != nil {
+		return nil, err
+	}
+	info, ok := parseClientHello(record[tlsRecordHeaderLen:])
+	if !ok {
+		return nil, ErrBadClientHello
+	}
+	info.Conn = c
+	return info, nil
+}
+
+// parseClientHello parses a handshake message which must be a ClientHello.
+func parseClientHello(data []byte) (*tls.ClientHelloInfo, bool) {
+	msg := helloReader(data)
+	typ, ok := msg.uint8()
+	if !ok || typ != tlsTypeClientHello {
+		return nil, false
+	}
+	body, ok := msg.bytes(3)
+	if !ok {
+		return nil, false
+	}
+	var (
+		info               = new(tls.ClientHelloInfo)
+		version            uint16
+		suites, extensions helloReader
+	)
+	if version, ok = body.uint16(); !ok {
+		return nil, false
+	}
+	if _, ok = body.skip(32); !ok { // random
+		return nil, false
+	}
+	if _, ok = body.bytes(1); !ok { // session ID
+		return nil, false
+	}
+	if suites, ok = body.bytes(2); !ok || len(suites)%2 != 0 {
+		return nil, false
+	}
+	for len(suites) > 0 {
+		suite, _ := suites.uint16()
+		info.CipherSuites = append(info.CipherSuites, suite)
+	}
+	if _, ok = body.bytes(1); !ok { // compression methods
+		return nil, false
+	}
+	if len(body) > 0 {
+		if extensions, ok = body.bytes(2); !ok {
+			return nil, false
+		}
+	}
+	for len(extensions) > 0 {
+		ext, ok1 := extensions.uint16()
+		extData, ok2 := extensions.bytes(2)
+		if !ok1 || !ok2 {
+			return nil, false
+		}
+		switch ext {
+		case tlsExtServerName:
+			if info.ServerName, ok = parseServerName(extData); !ok {
+				return nil, false
+			}
+		case tlsExtALPN:
+			if info.SupportedProtos, ok = parseALPN(extData); !ok {
+				return nil, false
+			}
+		case tlsExtSupportedVersion:
+			if info.SupportedVersions, ok = parseSupportedVersions(extData); !ok {
+				return nil, false
+			}
+		}
+	}
+	if info.SupportedVersions == nil {
+		info.SupportedVersions = []uint16{version}
+	}
+	return info, true
+}
+
+func parseServerName(data helloReader) (string, bool) {
+	list, ok := data.bytes(2)
+	if !ok {
+		return "", false
+	}
+	for len(list) > 0 {
+		nameType, ok1 := list.uint8()
+		name, ok2 := list.bytes(2)
+		if !ok1 || !ok2 {
+			return "", false
+		}
+		if nameType == 0 { // host_name
+			return string(name), true
+		}
+	}
+	return "", true
+}
+
+func parseALPN(data helloReader) ([]string, bool) {
+	list, ok := data.bytes(2)
+	if !ok {
+		return nil, false
+	}
+	var protos []string
+	for len(list) > 0 {
+		proto, ok := list.bytes(1)
+		if !ok || len(proto) == 0 {
+			return nil, false
+		}
+		protos = append(protos, string(proto))
+	}
+	return protos, true
+}
+
+func parseSupportedVersions(data helloReader) ([]uint16, bool) {
+	list, ok := data.bytes(1)
+	if !ok || len(list)%2 != 0 {
+		return nil, false
+	}
+	var versions []uint16
+	for len(list) > 0 {
+		version, _ := list.uint16()
+		versions = append(versions, version)
+	}
+	return versions, true
+}
+
+// helloReader consumes big-endian integers and length-prefixed byte strings
+// from a TLS handshake message.
+type helloReader []byte
+
+func (r *helloReader) skip(n int) ([]byte, bool) {
+	if len(*r) < n {
+		return nil, false
+	}
+	out := (*r)[:n]
+	*r = (*r)[n:]
+	return out, true
+}
+
+func (r *helloReader) uint8() (uint8, bool) {
+	b, ok := r.skip(1)
+	if !ok {
+		return 0, false
+	}
+	return b[0], true
+}
+
+func (r *helloReader) uint16() (uint16, bool) {
+	b, ok := r.skip(2)
+	if !ok {
+		return 0, false
+	}
+	return binary.BigEndian.Uint16(b), true
+}
+
+// bytes reads a byte string prefixed by its length on lenSize bytes.
+func (r *helloReader) bytes(lenSize int) (helloReader, bool) {
+	prefix, ok := r.skip(lenSize)
+	if !ok {
+		return nil, false
+	}
+	n := 0
+	for _, b := range prefix {
+		n = n<<8 | int(b)
+	}
+	return r.skip(n)
+}
@@ -0,0 +1,112 @@
+package connxray
+
+import (
+	"bytes"
+	"crypto/tls"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// captureClientHello returns the first TLS record sent by a crypto/tls client.
+func captureClientHello(t *testing.T, config *tls.Config) []byte {
+	t.Helper()
+	cp, sp := net.Pipe()
+	defer sp.Close()
+	go tls.Client(cp, config).Handshake()
+	defer cp.Close()
+	header := make([]byte, tlsRecordHeaderLen)
+	if _, err := io.ReadFull(sp, header); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	record := make([]byte, tlsRecordHeaderLen+int(binary.BigEndian.Uint16(header[3:])))
+	copy(record, header)
+	if _, err := io.ReadFull(sp, record[tlsRecordHeaderLen:]); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	return record
+}
+
+// connWithData returns a Conn from which data is read, followed by io.EOF.
+func connWithData(data []byte) *Conn {
+	r := bytes.NewReader(data)
+	return &Conn{Base: &mockConn{readHandler: r.Read}}
+}
+
+func TestClientHelloInfo(t *testing.T) {
+	hello := captureClientHello(t, &tls.Config{
+		ServerName: "example.com",
+		NextProtos: []string{"h2", "http/1.1"},
+		MinVersion: tls.VersionTLS12,
+	})
+	cc := connWithData(hello)
+	info, err := cc.ClientHelloInfo()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if info.ServerName != "example.com" {
+		t.Errorf("Unexpected server name %q, expected \"example.com\"", info.ServerName)
+	}
+	if exp := []string{"h2", "http/1.1"}; !reflect.DeepEqual(info.SupportedProtos, exp) {
+		t.Errorf("Unexpected protocols %q, expected %q", info.SupportedProtos, exp)
+	}
+	if len(info.CipherSuites) == 0 {
+		t.Error("Unexpected empty list of cipher suites")
+	}
+	if len(info.SupportedVersions) == 0 || info.SupportedVersions[0] != tls.VersionTLS13 {
+		t.Errorf("Unexpected supported versions %v, expected TLS 1.3 first", info.SupportedVersions)
+	}
+	if info.Conn != cc {
+		t.Errorf("Unexpected conn %v, expected %v", info.Conn, cc)
+	}
+	data, err := io.ReadAll(cc)
+	if err != nil || !bytes.Equal(data, hello) {
+		t.Errorf("Unexpected results (%d bytes, %v), expected (%d bytes, nil)", len(data), err, len(hello))
+	}
+}
+
+func TestClientHelloInfoNoExtensions(t *testing.T) {
+	hello := captureClientHello(t, &tls.Config{InsecureSkipVerify: true})
+	cc := connWithData(hello)
+	info, err := cc.ClientHelloInfo()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if info.ServerName != "" || info.SupportedProtos != nil {
+		t.Errorf("Unexpected results (%q, %q), expected (\"\", [])", info.ServerName, info.SupportedProtos)
+	}
+}
+
+func TestClientHelloInfoMalformed(t *testing.T) {
+	hello := captureClientHello(t, &tls.Config{ServerName: "example.com"})
+	wrongType := append([]byte(nil), hello...)
+	wrongType[tlsRecordHeaderLen] = 2
+	truncatedBody := append([]byte(nil), hello...)
+	binary.BigEndian.PutUint16(truncatedBody[3:], 40)
+	cases := map[string]struct {
+		data []byte
+		err  error
+	}{
+		"plain text":     {[]byte("GET / HTTP/1.1\r\n\r\n"), ErrBadClientHello},
+		"short text":     {[]byte("GET"), ErrBadClientHello},
+		"not handshake":  {[]byte{21, 3, 3, 0, 2, 2, 40}, ErrBadClientHello},
+		"empty record":   {[]byte{22, 3, 1, 0, 0}, ErrBadClientHello},
+		"bad version":    {[]byte{22, 1, 0, 0, 4, 1, 0, 0, 0}, ErrBadClientHello},
+		"not hello":      {wrongType, ErrBadClientHello},
+		"truncated body": {truncatedBody[:tlsRecordHeaderLen+40], ErrBadClientHello},
+		"cut record":     {hello[:len(hello)-1], io.EOF},
+		"no data":        {nil, io.EOF},
+	}
+	for name, tc := range cases {
+		cc := connWithData(tc.data)
+		if _, err := cc.ClientHelloInfo(); !errors.Is(err, tc.err) {
+			t.Errorf("%s: unexpected error %v, expected %v", name, err, tc.err)
+		}
+		if data, _ := io.ReadAll(cc); !bytes.Equal(data, tc.data) {
+			t.Errorf("%s: unexpected data %q, expected %q", name, data, tc.data)
+		}
+	}
+}
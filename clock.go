@@ -0,0 +1,20 @@
+package connxray
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// clock, if set, replaces time.Now as the source of the current time for Age,
+// WarmupThroughput and the sampling of file descriptor usage. Tests set it to
+// control time; it is atomic since connections left behind by other tests may
+// be reading it.
+var clock atomic.Pointer[func() time.Time]
+
+// timeNow returns the current time according to clock.
+func timeNow() time.Time {
+	if now := clock.Load(); now != nil {
+		return (*now)()
+	}
+	return time.Now()
+}
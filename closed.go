@@ -0,0 +1,33 @@
+package connxray
+
+import (
+	"errors"
+)
+
+// ErrConnClosed is returned by I/O methods of a Conn with RejectAfterClose set
+// once it has been closed.
+var ErrConnClosed = errors.New("connxray: connection closed")
+
+// IsClosed tells whether Close has been called on the underlying net.Conn,
+// regardless of the error it returned. It is safe to call concurrently with
+// Close.
+func (c *Conn) IsClosed() bool {
+	c.closeMu.Lock()
+	defer c.closeMu.Unlock()
+	return c.closed
+}
+
+// rejectsIO tells whether I/O on the underlying net.Conn should fail with
+// ErrConnClosed.
+func (c *Conn) rejectsIO() bool {
+	return c.RejectAfterClose && c.IsClosed()
+}
+
+// closeVetoed reports err, returned by a 'before' hook of Close, to
+// OnCloseVetoed and returns it.
+func (c *Conn) closeVetoed(err error) error {
+	if c.OnCloseVetoed != nil {
+		c.OnCloseVetoed(c, err)
+	}
+	return err
+}
@@ -0,0 +1,230 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"sync"
+	"testing"
+)
+
+func TestIsClosed(t *testing.T) {
+	cc := &Conn{Base: &mockConn{closeHandler: func() error { return nil }}}
+	if cc.IsClosed() {
+		t.Error("Unexpectedly closed before Close")
+	}
+	cc.Close()
+	if !cc.IsClosed() {
+		t.Error("Unexpectedly not closed after Close")
+	}
+}
+
+func TestRejectAfterCloseWrite(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			t.Error("Base Write called after Close")
+			return len(b), nil
+		},
+		closeHandler: func() error { return nil },
+	}
+	var hookErr error
+	cc := &Conn{
+		Base:             mc,
+		RejectAfterClose: true,
+		AfterWrite: func(_ *Conn, _ []byte, _ int, err error) {
+			hookErr = err
+		},
+	}
+	cc.Close()
+	if _, err := cc.Write([]byte("bacon")); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	if hookErr != ErrConnClosed {
+		t.Errorf("Unexpected error %v in 'after' hook, expected %v", hookErr, ErrConnClosed)
+	}
+}
+
+func TestRejectAfterClosePacketConn(t *testing.T) {
+	mc := &mockConn{
+		readFromHandler: func([]byte) (int, net.Addr, error) {
+			t.Error("Base ReadFrom called after Close")
+			return 0, nil, nil
+		},
+		writeToHandler: func([]byte, net.Addr) (int, error) {
+			t.Error("Base WriteTo called after Close")
+			return 0, nil
+		},
+		closeHandler: func() error { return nil },
+	}
+	cc := &Conn{Base: mc, RejectAfterClose: true}
+	cc.Close()
+	if _, _, err := cc.ReadFrom(nil); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	if _, err := cc.WriteTo(nil, nil); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+}
+
+func TestRejectAfterCloseBuffersAndMsgUDP(t *testing.T) {
+	mc := &mockUDPConn{
+		mockConn: mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				t.Error("Base Write called after Close")
+				return len(b), nil
+			},
+			closeHandler: func() error { return nil },
+		},
+		readMsgUDPHandler: func([]byte, []byte) (int, int, int, *net.UDPAddr, error) {
+			t.Error("Base ReadMsgUDP called after Close")
+			return 0, 0, 0, nil, nil
+		},
+		writeMsgUDPHandler: func([]byte, []byte, *net.UDPAddr) (int, int, error) {
+			t.Error("Base WriteMsgUDP called after Close")
+			return 0, 0, nil
+		},
+	}
+	var hookErr error
+	cc := &Conn{
+		Base:             mc,
+		RejectAfterClose: true,
+		AfterWriteBuffers: func(_ *Conn, _ int64, err error) {
+			hookErr = err
+		},
+	}
+	cc.Close()
+	bufs := net.Buffers{[]byte("chunky "), []byte("bacon")}
+	if _, err := cc.WriteBuffers(&bufs); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	if hookErr != ErrConnClosed {
+		t.Errorf("Unexpected error %v in 'after' hook, expected %v", hookErr, ErrConnClosed)
+	}
+	if _, _, _, _, err := cc.ReadMsgUDP(nil, nil); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	if _, _, err := cc.WriteMsgUDP(nil, nil, nil); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+}
+
+func TestWriteAfterCloseWithoutReject(t *testing.T) {
+	calls := 0
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			calls++
+			return 0, net.ErrClosed
+		},
+		closeHandler: func() error { return nil },
+	}
+	cc := &Conn{Base: mc}
+	cc.Close()
+	if _, err := cc.Write([]byte("bacon")); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	if calls != 1 {
+		t.Errorf("Unexpected number of base Write calls %d, expected 1", calls)
+	}
+}
+
+func TestIsClosedConcurrent(t *testing.T) {
+	cc := &Conn{
+		Base: &mockConn{
+			readHandler:  func([]byte) (int, error) { return 0, nil },
+			closeHandler: func() error { return nil },
+		},
+		RejectAfterClose: true,
+	}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 100; i++ {
+			cc.Read(nil)
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		cc.Close()
+	}()
+	wg.Wait()
+	if _, err := cc.Read(nil); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+}
+
+func TestConnCloseVetoed(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	var vetoErr error
+	cc := &Conn{
+		Base: &mockConn{
+			closeHandler: func() error {
+				t.Error("Base Close called despite the veto")
+				return nil
+			},
+		},
+		BeforeClose: func(*Conn) error {
+			return expErr
+		},
+		AfterClose: func(*Conn, error) {
+			t.Error("AfterClose invoked despite the veto")
+		},
+		OnCloseVetoed: func(_ *Conn, err error) {
+			vetoErr = err
+		},
+	}
+	err := cc.Close()
+	if !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if vetoErr != err {
+		t.Errorf("Unexpected veto error %v, expected %v", vetoErr, err)
+	}
+	if cc.IsClosed() {
+		t.Error("Conn unexpectedly closed")
+	}
+}
+
+func TestConnCloseNotVetoed(t *testing.T) {
+	cc := &Conn{
+		Base: &mockConn{
+			closeHandler: func() error {
+				return nil
+			},
+		},
+		OnCloseVetoed: func(*Conn, error) {
+			t.Error("OnCloseVetoed invoked without a veto")
+		},
+	}
+	if err := cc.Close(); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+}
+
+func TestListenerCloseVetoed(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	var vetoErr error
+	cl := &Listener{
+		Base: &mockListener{
+			closeHandler: func() error {
+				t.Error("Base Close called despite the veto")
+				return nil
+			},
+		},
+		BeforeClose: func(*Listener) error {
+			return expErr
+		},
+		AfterClose: func(*Listener, error) {
+			t.Error("AfterClose invoked despite the veto")
+		},
+		OnCloseVetoed: func(_ *Listener, err error) {
+			vetoErr = err
+		},
+	}
+	err := cl.Close()
+	if !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if vetoErr != err {
+		t.Errorf("Unexpected veto error %v, expected %v", vetoErr, err)
+	}
+}
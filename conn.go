@@ -8,7 +8,13 @@
 // of connxray.Conn whose hooks can be set dynamically at any stage of the
 // connection lifetime. This means that only certain net.Conn objects can be
 // explicitly monitored (eg. sampling) or that monitoring behavior can be
-// adjusted on the fly.
+// adjusted on the fly. Hooks of a connection which is in use by other
+// goroutines should be changed using the setter methods (eg. SetAfterRead)
+// rather than by assigning the fields directly, which would be a data race.
+//
+// 'After' hooks are called synchronously by the goroutine calling the method,
+// right before it returns, so the caller regains control only once they have
+// completed. They receive the exact results which are then returned.
 //
 // Last but not least connxray.Conn implements net.PacketConn so can be used
 // with any code that expects one (eg. golang.org/x/net/ipv[46]). If the
@@ -17,9 +23,18 @@
 // as well as passed to their respective hooks, if any were specified.
 package connxray
 
+//go:generate go run gen_hooks.go
+
 import (
+	"bytes"
+	"context"
+	"crypto/tls"
 	"errors"
+	"io"
 	"net"
+	"sync"
+	"sync/atomic"
+	"syscall"
 	"time"
 )
 
@@ -27,6 +42,10 @@ var (
 	// ErrNotPacketConn signifies that the underlying net.Conn is does not
 	// implement the net.PacketConn interface.
 	ErrNotPacketConn = errors.New("this net.Conn is not a net.PacketConn")
+
+	// ErrNotSyscallConn signifies that the underlying net.Conn does not
+	// implement the syscall.Conn interface.
+	ErrNotSyscallConn = errors.New("this net.Conn is not a syscall.Conn")
 )
 
 // Conn wraps a net.Conn and presents the same interface while allowing
@@ -37,36 +56,174 @@ type Conn struct {
 	// Underlying net.Conn.
 	Base net.Conn
 
+	// Origin is inherited from the Listener which accepted this connection.
+	Origin Origin
+
+	// Context carries per-connection values (eg. tracing spans) and
+	// cancellation into the context-aware hooks (eg. BeforeReadCtx). A nil
+	// Context is treated as context.Background(). It should be set before
+	// the Conn is used by other goroutines, eg. via Listener's BaseContext.
+	Context context.Context
+
 	// BeforeRead is a 'before' hook for the Read method.
 	BeforeRead func(*Conn, []byte) error
 
 	// AfterRead is an 'after' hook for the Read method.
 	AfterRead func(*Conn, []byte, int, error)
 
+	// BeforeReadCtx is a context-aware 'before' hook for the Read method,
+	// invoked with the Conn's Context right after BeforeRead. Like any
+	// 'before' hook it can abort the Read by returning an error, eg.
+	// ctx.Err() once the context is done.
+	BeforeReadCtx func(context.Context, *Conn, []byte) error
+
+	// AfterReadCtx is a context-aware 'after' hook for the Read method,
+	// invoked right after AfterRead.
+	AfterReadCtx func(context.Context, *Conn, []byte, int, error)
+
+	// AfterReadTimed is a variant of AfterRead which also receives the time
+	// spent in the underlying Read (or in ReadInterceptor), excluding hooks.
+	// It is invoked right after AfterReadCtx. The time is only measured
+	// while the hook is set.
+	AfterReadTimed func(*Conn, []byte, int, time.Duration, error)
+
+	// TransformRead, unlike AfterRead, runs synchronously right after the
+	// underlying Read returns and its return values replace the (n, err)
+	// seen by the caller. This allows eg. error normalization or transparent
+	// decoding in place. It runs before AfterRead, which observes the
+	// transformed values.
+	TransformRead func(*Conn, []byte, int, error) (int, error)
+
+	// ReadInterceptor, if set, is invoked by Read after the 'before' hooks
+	// succeed. If it returns true as its first result the underlying net.Conn
+	// is not read from and Read returns the n and err it returned instead, so
+	// it must fill the buffer itself (eg. to serve a synthetic response).
+	// Transform and 'after' hooks still run, but the read is not accounted
+	// for in Stats, ErrorCounts or the event log.
+	ReadInterceptor func(*Conn, []byte) (bool, int, error)
+
+	// AfterEOF is invoked by Read right after its other 'after' hooks if
+	// it returns io.EOF (as per errors.Is), with the number of bytes returned
+	// alongside it. It tells a clean end of the stream apart from empty reads
+	// and from other errors.
+	AfterEOF func(*Conn, int)
+
+	// AfterReadTimeout is invoked by Read right after its other 'after'
+	// hooks if it fails with a timeout, ie. an error which is (or wraps) a
+	// net.Error whose Timeout method returns true.
+	AfterReadTimeout func(*Conn)
+
+	// AfterFirstRead is invoked once, by the first Read returning any data,
+	// right after its other 'after' hooks, with the time elapsed between the
+	// creation of the Conn (see Age) and the end of that Read, ie. the time
+	// to first byte. The duration is zero if the creation time is unknown.
+	AfterFirstRead func(*Conn, time.Duration)
+
+	// AfterReadFull is an 'after' hook for the ReadFull method, invoked
+	// once with the total number of bytes read and the error returned,
+	// after the hooks of every individual Read.
+	AfterReadFull func(*Conn, int, error)
+
 	// BeforeReadFrom is a 'before' hook for the ReadFrom method.
 	BeforeReadFrom func(*Conn, []byte) error
 
 	// AfterReadFrom is an 'after' hook for the ReadFrom method.
 	AfterReadFrom func(*Conn, []byte, int, net.Addr, error)
 
+	// BeforeReadMsgUDP is a 'before' hook for the ReadMsgUDP method. It
+	// receives the message and out-of-band data buffers.
+	BeforeReadMsgUDP func(*Conn, []byte, []byte) error
+
+	// AfterReadMsgUDP is an 'after' hook for the ReadMsgUDP method. It
+	// receives the buffers followed by the results: n, oobn, flags, addr and
+	// err.
+	AfterReadMsgUDP func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error)
+
 	// BeforeWrite is a 'before' hook for the Write method.
 	BeforeWrite func(*Conn, []byte) error
 
 	// AfterWrite is an 'after' hook for the Write method.
 	AfterWrite func(*Conn, []byte, int, error)
 
+	// BeforeWriteCtx is a context-aware 'before' hook for the Write method,
+	// invoked with the Conn's Context right after BeforeWrite.
+	BeforeWriteCtx func(context.Context, *Conn, []byte) error
+
+	// AfterWriteCtx is a context-aware 'after' hook for the Write method,
+	// invoked right after AfterWrite.
+	AfterWriteCtx func(context.Context, *Conn, []byte, int, error)
+
+	// AfterWriteTimed is the Write counterpart of AfterReadTimed.
+	AfterWriteTimed func(*Conn, []byte, int, time.Duration, error)
+
+	// TransformWrite is the Write counterpart of TransformRead: it runs
+	// synchronously right after the underlying Write returns, its return
+	// values replace the (n, err) seen by the caller and it runs before
+	// AfterWrite.
+	TransformWrite func(*Conn, []byte, int, error) (int, error)
+
+	// WriteInterceptor is the Write counterpart of ReadInterceptor. It can
+	// eg. pretend that the data was written without touching the underlying
+	// net.Conn.
+	WriteInterceptor func(*Conn, []byte) (bool, int, error)
+
+	// AfterWriteTimeout is invoked by Write right after its other 'after'
+	// hooks if it fails with a timeout.
+	AfterWriteTimeout func(*Conn)
+
+	// AfterFirstWrite is the Write counterpart of AfterFirstRead.
+	AfterFirstWrite func(*Conn, time.Duration)
+
+	// AfterWriteAll is an 'after' hook for the WriteAll method, invoked
+	// once with the total number of bytes written and the error returned.
+	AfterWriteAll func(*Conn, int, error)
+
+	// BeforeWriteBuffers is a 'before' hook for the WriteBuffers method.
+	BeforeWriteBuffers func(*Conn, *net.Buffers) error
+
+	// AfterWriteBuffers is an 'after' hook for the WriteBuffers method.
+	AfterWriteBuffers func(*Conn, int64, error)
+
 	// BeforeWriteTo is a 'before' hook for the WriteTo method.
 	BeforeWriteTo func(*Conn, []byte, net.Addr) error
 
 	// AfterWriteTo is an 'after' hook for the WriteTo method.
 	AfterWriteTo func(*Conn, []byte, net.Addr, int, error)
 
+	// BeforeWriteMsgUDP is a 'before' hook for the WriteMsgUDP method. It
+	// receives the message and out-of-band data buffers and the address.
+	BeforeWriteMsgUDP func(*Conn, []byte, []byte, *net.UDPAddr) error
+
+	// AfterWriteMsgUDP is an 'after' hook for the WriteMsgUDP method. It
+	// receives the arguments followed by the results: n, oobn and err.
+	AfterWriteMsgUDP func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error)
+
 	// BeforeClose is a 'before' hook for the Close method.
 	BeforeClose func(*Conn) error
 
 	// AfterClose is an 'after' hook for the Close method.
 	AfterClose func(*Conn, error)
 
+	// BeforeCloseWrite is a 'before' hook for the CloseWrite method.
+	BeforeCloseWrite func(*Conn) error
+
+	// AfterCloseWrite is an 'after' hook for the CloseWrite method.
+	AfterCloseWrite func(*Conn, error)
+
+	// BeforeCloseRead is a 'before' hook for the CloseRead method.
+	BeforeCloseRead func(*Conn) error
+
+	// AfterCloseRead is an 'after' hook for the CloseRead method.
+	AfterCloseRead func(*Conn, error)
+
+	// BeforeCloseCtx is a context-aware 'before' hook for the Close method,
+	// invoked with the Conn's Context right after BeforeClose.
+	BeforeCloseCtx func(context.Context, *Conn) error
+
+	// AfterCloseCtx is a context-aware 'after' hook for the Close method,
+	// invoked right after AfterClose.
+	AfterCloseCtx func(context.Context, *Conn, error)
+
 	// AfterLocalAddr is an 'after' hook for the LocalAddr method.
 	AfterLocalAddr func(*Conn, net.Addr)
 
@@ -94,19 +251,334 @@ type Conn struct {
 	// AfterSetWriteDeadline is an 'after' hook for the SetWriteDeadline
 	// method.
 	AfterSetWriteDeadline func(*Conn, time.Time, error)
+
+	// AfterSetSocketOption is an 'after' hook for the socket option methods
+	// (eg. SetReadBuffer or SetNoDelay). It receives the name of the method.
+	AfterSetSocketOption func(*Conn, string, error)
+
+	// BeforeCopyFrom is a 'before' hook for the StreamConn.ReadFrom method.
+	BeforeCopyFrom func(*Conn, io.Reader) error
+
+	// AfterCopyFrom is an 'after' hook for the StreamConn.ReadFrom method.
+	AfterCopyFrom func(*Conn, io.Reader, int64, error)
+
+	// BeforeCopyTo is a 'before' hook for the StreamConn.WriteTo method.
+	BeforeCopyTo func(*Conn, io.Writer) error
+
+	// AfterCopyTo is an 'after' hook for the StreamConn.WriteTo method.
+	AfterCopyTo func(*Conn, io.Writer, int64, error)
+
+	// BeforeSyscallConn is a 'before' hook for the SyscallConn method.
+	BeforeSyscallConn func(*Conn) error
+
+	// AfterSyscallConn is an 'after' hook for the SyscallConn method.
+	AfterSyscallConn func(*Conn, syscall.RawConn, error)
+
+	// BeforeHandshake is a 'before' hook for the Handshake method.
+	BeforeHandshake func(*Conn) error
+
+	// AfterHandshake is an 'after' hook for the Handshake method. It is also
+	// invoked once, with a nil error, when Read or Write completes on a TLS
+	// connection whose handshake they triggered implicitly.
+	AfterHandshake func(*Conn, tls.ConnectionState, error)
+
+	// AfterIdleTimeout is invoked when the Conn is about to be closed due to
+	// IdleTimeout.
+	AfterIdleTimeout func(*Conn)
+
+	// AfterStreamOpened is an 'after' hook for the StreamOpened method.
+	AfterStreamOpened func(*Conn, error)
+
+	// AfterStreamClosed is an 'after' hook for the StreamClosed method.
+	AfterStreamClosed func(*Conn)
+
+	// IdleTimeout, when positive, makes the Conn close itself (invoking the
+	// usual Close hooks) if no Read or Write succeeded for that long. The
+	// countdown starts with the first successful Read or Write.
+	IdleTimeout time.Duration
+
+	// StreamRate, when positive, limits the rate (per second) at which
+	// logical streams can be opened on this connection. See StreamOpened.
+	StreamRate float64
+
+	// StreamBurst is the number of streams which can be opened at once
+	// before StreamRate kicks in. Values below 1 are treated as 1. Neither
+	// must be changed once StreamOpened has been called.
+	StreamBurst int
+
+	// RecordEvents enables recording of every operation performed on the
+	// underlying net.Conn in an ordered event log. See EventLog.
+	RecordEvents bool
+
+	// MaxEvents caps the number of events kept in the event log. Zero means
+	// no limit.
+	MaxEvents int
+
+	// MaxInFlight, when positive, makes Write block while at least that many
+	// previously written bytes are still queued in the kernel, not yet
+	// delivered to the peer (see InFlight). This bounds how much data an
+	// application can pile up on a connection with a stalled peer. It is
+	// only enforced on Linux and for bases exposing their file descriptor.
+	MaxInFlight int
+
+	// Observer, if set, is invoked for every method which has hooks, in
+	// addition to the hooks themselves.
+	Observer Observer
+
+	// OnHookPanic, if set, is invoked with the name of the hook (eg.
+	// "AfterRead") and the recovered value whenever a hook panics, instead of
+	// letting the panic crash the program. See hooks.go for how methods
+	// proceed after a hook panicked.
+	OnHookPanic func(c *Conn, hook string, recovered interface{})
+
+	// OnCloseVetoed, if set, is invoked when a BeforeClose or BeforeCloseCtx
+	// hook makes Close fail, in which case the underlying net.Conn is not
+	// closed and AfterClose is not invoked. It receives the error returned
+	// by Close.
+	OnCloseVetoed func(*Conn, error)
+
+	// OnKeyRotation, if set, is invoked by RotateKey with the epoch of the
+	// new key once it replaced the previous one.
+	OnKeyRotation func(c *Conn, epoch uint64)
+
+	// MeasureHookLatency enables timing of hooks, so that those slower than
+	// SlowHookThreshold are reported to OnSlowHook. It has no effect unless
+	// OnSlowHook is set. Timing is off by default to keep hooks free of any
+	// overhead.
+	MeasureHookLatency bool
+
+	// SlowHookThreshold is the duration above which a hook is reported to
+	// OnSlowHook.
+	SlowHookThreshold time.Duration
+
+	// OnSlowHook is invoked with the name of the hook (eg. "AfterWrite") and
+	// the time it took, whenever a hook was slower than SlowHookThreshold
+	// while MeasureHookLatency is set.
+	OnSlowHook func(c *Conn, hook string, d time.Duration)
+
+	// CopyHookBuffers makes Read, ReadFrom, Write and WriteTo pass copies of
+	// their buffers to 'before' and 'after' hooks (but not to Transform
+	// hooks), so that hooks which modify or retain the buffers (eg. for
+	// asynchronous logging) can't corrupt the caller's data. The copy passed
+	// to 'after' hooks only holds the n bytes actually transferred. This
+	// costs an allocation per hook invocation.
+	CopyHookBuffers bool
+
+	// TrackStats enables built-in traffic counters updated by Read, ReadFrom,
+	// Write and WriteTo. See Stats.
+	TrackStats bool
+
+	// WarmupBytes and WarmupPeriod bound the warmup window of the Conn,
+	// which ends at whichever limit is reached first. Setting either enables
+	// WarmupThroughput.
+	WarmupBytes  int64
+	WarmupPeriod time.Duration
+
+	// RejectAfterClose makes Read, Write, ReadFrom, WriteTo, WriteBuffers,
+	// ReadMsgUDP, WriteMsgUDP and the copy methods of StreamConn fail with
+	// ErrConnClosed once the Conn is closed (see IsClosed), without calling
+	// the underlying net.Conn. Hooks still run and observe ErrConnClosed.
+	RejectAfterClose bool
+
+	// ProxyProtocolV1 makes the Conn handle the PROXY protocol v1 header
+	// prepended to connections by load balancers such as HAProxy. The first
+	// read from the underlying net.Conn reads the header, strips it and
+	// passes the source address it carries to SetRemoteAddr, so that
+	// RemoteAddr reports the real client rather than the proxy. Until then
+	// RemoteAddr reports the address of the underlying net.Conn. Connections
+	// which do not start with a PROXY header are left intact, and any bytes
+	// read past the header are returned by subsequent Reads. A malformed
+	// header makes all Reads fail with ErrBadProxyHeader. Other errors (eg.
+	// timeouts) hit while reading the header are returned by Read, and the
+	// next Read carries on reading the header.
+	//
+	// The header is handled below hooks, so it works with hooks disabled and
+	// is never seen by them, nor counted in Stats. Since Peek reads through
+	// Read, the header is stripped from peeked data too.
+	ProxyProtocolV1 bool
+
+	// MeasureOverhead enables accounting of time spent executing hooks
+	// versus time spent in the underlying net.Conn. See OverheadRatio.
+	MeasureOverhead bool
+
+	// hooksDisabled is set while hooks are turned off. See SetHooksEnabled.
+	hooksDisabled atomic.Bool
+
+	// hooksMu guards the hook fields and chains when they are accessed
+	// through the setters and getters in hooks.go.
+	hooksMu sync.RWMutex
+
+	// chains are hooks added with the Append methods in hooks.go.
+	chains hookChains
+
+	// methodTime and baseTime accumulate (in nanoseconds) the time spent in
+	// Conn methods and in the underlying net.Conn respectively.
+	methodTime, baseTime atomic.Int64
+
+	// streams track logical streams reported by the user.
+	streams streamCounters
+
+	// events is the event log kept while RecordEvents is set.
+	events eventLog
+
+	// stats are the traffic counters kept while TrackStats is set.
+	stats Stats
+
+	// warmup backs WarmupThroughput.
+	warmup warmupStats
+
+	// errorCounts is a histogram of errors returned by the base I/O methods.
+	errorCounts errorCounts
+
+	// deadlines are the deadlines most recently set on the underlying
+	// net.Conn. See Deadlines.
+	deadlines deadlineCache
+
+	// deadlineCallback is managed by SetDeadlineCallback.
+	deadlineCallback deadlineCallback
+
+	// deadlineSync is managed by SyncDeadline.
+	deadlineSync deadlineSync
+
+	// idle is the timer behind IdleTimeout.
+	idle idleTimer
+
+	// budget is the shared deadline budget this Conn is enrolled in, if any.
+	budget atomic.Pointer[Budget]
+
+	// keys holds the key managed by RotateKey.
+	keys keyState
+
+	// syscalls counts calls to the underlying net.Conn. See SyscallReads.
+	syscalls syscallCounts
+
+	// created is the time when the Conn was accepted, dialed or constructed
+	// with NewConn. See Age.
+	created time.Time
+
+	// firstRead and firstWrite are set once data is first read and written.
+	// See AfterFirstRead and AfterFirstWrite.
+	firstRead, firstWrite firstTransfer
+
+	// handshakeReported is set once AfterHandshake was invoked for a
+	// completed handshake.
+	handshakeReported atomic.Bool
+
+	// closedAt is the time of the first Close, which stops Age.
+	closedAt atomic.Pointer[time.Time]
+
+	// handshakeLimit is set on Conns accepted by a Listener with
+	// MaxHandshakes.
+	handshakeLimit *handshakeLimit
+
+	// peekMu guards peeked.
+	peekMu sync.Mutex
+
+	// peeked holds data read from the underlying net.Conn by Peek, not yet
+	// consumed by Read.
+	peeked []byte
+
+	// remoteAddr overrides the address reported by RemoteAddr. See
+	// SetRemoteAddr.
+	remoteAddr atomic.Pointer[net.Addr]
+
+	// proxyMu guards the state of ProxyProtocolV1: proxyBuf holds data read
+	// from the underlying net.Conn while looking for the header and not
+	// returned by Read yet, proxyDone is set once the header was handled and
+	// proxyErr is set if it was malformed.
+	proxyMu   sync.Mutex
+	proxyBuf  []byte
+	proxyDone bool
+	proxyErr  error
+
+	// networkType caches the result of NetworkType.
+	networkType atomic.Pointer[string]
+
+	// closeMu guards closed and closeCallbacks.
+	closeMu sync.Mutex
+
+	// closed is set once the underlying net.Conn has been closed.
+	closed bool
+
+	// closeCallbacks are internal callbacks run once the underlying net.Conn
+	// is closed for the first time. See onClose.
+	closeCallbacks []func(*Conn)
 }
 
 // Read reads from the underlying net.Conn and invokes relevant hooks
-// ('before' and 'after') that were set up.
+// ('before' and 'after') that were set up. Data buffered by Peek is returned
+// first, without invoking any hooks, since they already fired when it was
+// read from the underlying net.Conn.
 func (c *Conn) Read(b []byte) (int, error) {
-	if c.BeforeRead != nil {
-		if err := c.BeforeRead(c, b); err != nil {
-			return 0, err
+	if n := c.readPeeked(b); n > 0 {
+		return n, nil
+	}
+	if c.hooksDisabled.Load() {
+		return c.baseRead(b)
+	}
+	return c.read(b)
+}
+
+// read is Read bypassing the Peek buffer.
+func (c *Conn) read(b []byte) (int, error) {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeReadHook(); hook != nil {
+		if err := hook(c, c.hookBuffer(b)); err != nil {
+			return 0, beforeHookError("Read", err)
 		}
 	}
-	n, err := c.Base.Read(b)
-	if c.AfterRead != nil {
-		defer c.AfterRead(c, b, n, err)
+	if hook := c.BeforeReadCtxHook(); hook != nil {
+		if err := hook(c.context(), c, c.hookBuffer(b)); err != nil {
+			return 0, beforeHookError("Read", err)
+		}
+	}
+	timed := c.AfterReadTimedHook()
+	var start time.Time
+	if timed != nil {
+		start = time.Now()
+	}
+	var n int
+	var err error
+	handled := false
+	if hook := c.ReadInterceptorHook(); hook != nil {
+		handled, n, err = hook(c, b)
+	}
+	if !handled {
+		n, err = c.baseRead(b)
+	}
+	var elapsed time.Duration
+	if timed != nil {
+		elapsed = time.Since(start)
+	}
+	if hook := c.TransformReadHook(); hook != nil {
+		unpin := c.keys.pin(&c.keys.readMu)
+		n, err = hook(c, b, n, err)
+		unpin()
+	}
+	first, age := c.firstRead.once(n, c)
+	if hook := c.AfterReadHook(); hook != nil {
+		hook(c, c.afterHookBuffer(b, n), n, err)
+	}
+	if hook := c.AfterReadCtxHook(); hook != nil {
+		hook(c.context(), c, c.afterHookBuffer(b, n), n, err)
+	}
+	if timed != nil {
+		timed(c, c.afterHookBuffer(b, n), n, elapsed, err)
+	}
+	if first {
+		if hook := c.AfterFirstReadHook(); hook != nil {
+			hook(c, age)
+		}
+	}
+	if errors.Is(err, io.EOF) {
+		if hook := c.AfterEOFHook(); hook != nil {
+			hook(c, n)
+		}
+	}
+	if isTimeout(err) {
+		if hook := c.AfterReadTimeoutHook(); hook != nil {
+			hook(c)
+		}
 	}
 	return n, err
 }
@@ -114,20 +586,19 @@ func (c *Conn) Read(b []byte) (int, error) {
 // ReadFrom reads from the underlying net.PacketConn and invokes relevant hooks
 // ('before' and 'after') that were set up.
 func (c *Conn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
-	pconn, implements := c.Base.(net.PacketConn)
-	if !implements {
-		err = ErrNotPacketConn
-		return
+	if c.hooksDisabled.Load() {
+		return c.baseReadFrom(b)
 	}
-	if c.BeforeReadFrom != nil {
-		err = c.BeforeReadFrom(c, b)
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeReadFromHook(); hook != nil {
+		err = beforeHookError("ReadFrom", hook(c, c.hookBuffer(b)))
 	}
 	if err != nil {
 		return
 	}
-	n, addr, err = pconn.ReadFrom(b)
-	if c.AfterReadFrom != nil {
-		defer c.AfterReadFrom(c, b, n, addr, err)
+	n, addr, err = c.baseReadFrom(b)
+	if hook := c.AfterReadFromHook(); hook != nil {
+		hook(c, c.afterHookBuffer(b, n), n, addr, err)
 	}
 	return n, addr, err
 }
@@ -135,35 +606,175 @@ func (c *Conn) ReadFrom(b []byte) (n int, addr net.Addr, err error) {
 // Write writes to the underlying net.Conn and invokes relevant hooks ('before'
 // and 'after') that were set up.
 func (c *Conn) Write(b []byte) (int, error) {
-	if c.BeforeWrite != nil {
-		if err := c.BeforeWrite(c, b); err != nil {
-			return 0, err
+	if c.hooksDisabled.Load() {
+		return c.baseWrite(b)
+	}
+	defer c.spentInMethod(c.now())
+	if err := c.beforeWrite(b); err != nil {
+		return 0, err
+	}
+	timed := c.AfterWriteTimedHook()
+	var start time.Time
+	if timed != nil {
+		start = time.Now()
+	}
+	var n int
+	var err error
+	handled := false
+	if hook := c.WriteInterceptorHook(); hook != nil {
+		handled, n, err = hook(c, b)
+	}
+	if !handled {
+		n, err = c.baseWrite(b)
+	}
+	var elapsed time.Duration
+	if timed != nil {
+		elapsed = time.Since(start)
+	}
+	if hook := c.TransformWriteHook(); hook != nil {
+		unpin := c.keys.pin(&c.keys.writeMu)
+		n, err = hook(c, b, n, err)
+		unpin()
+	}
+	first, age := c.firstWrite.once(n, c)
+	if hook := c.AfterWriteHook(); hook != nil {
+		hook(c, c.afterHookBuffer(b, n), n, err)
+	}
+	if hook := c.AfterWriteCtxHook(); hook != nil {
+		hook(c.context(), c, c.afterHookBuffer(b, n), n, err)
+	}
+	if timed != nil {
+		timed(c, c.afterHookBuffer(b, n), n, elapsed, err)
+	}
+	if first {
+		if hook := c.AfterFirstWriteHook(); hook != nil {
+			hook(c, age)
+		}
+	}
+	if isTimeout(err) {
+		if hook := c.AfterWriteTimeoutHook(); hook != nil {
+			hook(c)
+		}
+	}
+	return n, err
+}
+
+// beforeWrite runs the 'before' hooks of Write with the key pinned (see
+// RotateKey).
+func (c *Conn) beforeWrite(b []byte) error {
+	unpin := c.keys.pin(&c.keys.writeMu)
+	defer unpin()
+	if hook := c.BeforeWriteHook(); hook != nil {
+		if err := hook(c, c.hookBuffer(b)); err != nil {
+			return beforeHookError("Write", err)
 		}
 	}
+	if hook := c.BeforeWriteCtxHook(); hook != nil {
+		if err := hook(c.context(), c, c.hookBuffer(b)); err != nil {
+			return beforeHookError("Write", err)
+		}
+	}
+	return nil
+}
+
+// baseRead reads from the underlying net.Conn, keeping track of statistics.
+func (c *Conn) baseRead(b []byte) (int, error) {
+	if c.rejectsIO() {
+		return 0, ErrConnClosed
+	}
+	if c.ProxyProtocolV1 {
+		if handled, n, err := c.readProxied(b); handled {
+			return n, err
+		}
+	}
+	start := c.now()
+	c.syscalls.reads.Add(1)
+	n, err := c.Base.Read(b)
+	c.spentInBase(start)
+	c.trackRead(n, err)
+	c.errorCounts.record(err)
+	c.recordEvent(Event{Kind: EventRead, N: n, Err: err})
+	c.touchIdle(err)
+	c.reportHandshake()
+	return n, err
+}
+
+// baseWrite writes to the underlying net.Conn, keeping track of statistics.
+func (c *Conn) baseWrite(b []byte) (int, error) {
+	if c.rejectsIO() {
+		return 0, ErrConnClosed
+	}
+	start := c.now()
+	c.waitForInFlight()
+	c.syscalls.writes.Add(1)
 	n, err := c.Base.Write(b)
-	if c.AfterWrite != nil {
-		defer c.AfterWrite(c, b, n, err)
+	c.spentInBase(start)
+	c.trackWrite(n, err)
+	c.errorCounts.record(err)
+	c.recordEvent(Event{Kind: EventWrite, N: n, Err: err})
+	c.touchIdle(err)
+	c.reportHandshake()
+	return n, err
+}
+
+// baseReadFrom reads from the underlying net.PacketConn, keeping track of
+// statistics.
+func (c *Conn) baseReadFrom(b []byte) (int, net.Addr, error) {
+	pconn, implements := c.Base.(net.PacketConn)
+	if !implements {
+		return 0, nil, ErrNotPacketConn
+	}
+	if c.rejectsIO() {
+		return 0, nil, ErrConnClosed
+	}
+	start := c.now()
+	c.syscalls.reads.Add(1)
+	n, addr, err := pconn.ReadFrom(b)
+	c.spentInBase(start)
+	c.trackRead(n, err)
+	c.errorCounts.record(err)
+	c.recordEvent(Event{Kind: EventReadFrom, N: n, Addr: addr, Err: err})
+	c.touchIdle(err)
+	return n, addr, err
+}
+
+// baseWriteTo writes to the underlying net.PacketConn, keeping track of
+// statistics.
+func (c *Conn) baseWriteTo(b []byte, addr net.Addr) (int, error) {
+	pconn, implements := c.Base.(net.PacketConn)
+	if !implements {
+		return 0, ErrNotPacketConn
 	}
+	if c.rejectsIO() {
+		return 0, ErrConnClosed
+	}
+	start := c.now()
+	c.syscalls.writes.Add(1)
+	n, err := pconn.WriteTo(b, addr)
+	c.spentInBase(start)
+	c.trackWrite(n, err)
+	c.errorCounts.record(err)
+	c.recordEvent(Event{Kind: EventWriteTo, N: n, Addr: addr, Err: err})
+	c.touchIdle(err)
 	return n, err
 }
 
 // WriteTo writes to the underlying net.PacketConn and invokes relevant hooks
 // ('before' and 'after') that were set up.
 func (c *Conn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
-	pconn, implements := c.Base.(net.PacketConn)
-	if !implements {
-		err = ErrNotPacketConn
-		return
+	if c.hooksDisabled.Load() {
+		return c.baseWriteTo(b, addr)
 	}
-	if c.BeforeWriteTo != nil {
-		err = c.BeforeWriteTo(c, b, addr)
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeWriteToHook(); hook != nil {
+		err = beforeHookError("WriteTo", hook(c, c.hookBuffer(b), addr))
 	}
 	if err != nil {
 		return
 	}
-	n, err = pconn.WriteTo(b, addr)
-	if c.AfterWriteTo != nil {
-		defer c.AfterWriteTo(c, b, addr, n, err)
+	n, err = c.baseWriteTo(b, addr)
+	if hook := c.AfterWriteToHook(); hook != nil {
+		hook(c, c.afterHookBuffer(b, n), addr, n, err)
 	}
 	return n, err
 }
@@ -171,49 +782,101 @@ func (c *Conn) WriteTo(b []byte, addr net.Addr) (n int, err error) {
 // Close closes the underlying net.Conn and invokes relevant hooks ('before'
 // and 'after') that were set up.
 func (c *Conn) Close() error {
-	if c.BeforeClose != nil {
-		if err := c.BeforeClose(c); err != nil {
-			return err
+	if c.hooksDisabled.Load() {
+		return c.baseClose()
+	}
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeCloseHook(); hook != nil {
+		if err := hook(c); err != nil {
+			return c.closeVetoed(beforeHookError("Close", err))
 		}
 	}
-	err := c.Base.Close()
-	if c.AfterClose != nil {
-		defer c.AfterClose(c, err)
+	if hook := c.BeforeCloseCtxHook(); hook != nil {
+		if err := hook(c.context(), c); err != nil {
+			return c.closeVetoed(beforeHookError("Close", err))
+		}
+	}
+	err := c.baseClose()
+	if hook := c.AfterCloseHook(); hook != nil {
+		hook(c, err)
 	}
+	if hook := c.AfterCloseCtxHook(); hook != nil {
+		hook(c.context(), c, err)
+	}
+	return err
+}
+
+// baseClose closes the underlying net.Conn and releases resources held for
+// the Conn.
+func (c *Conn) baseClose() error {
+	start := c.now()
+	err := c.Base.Close()
+	c.spentInBase(start)
+	c.recordClose()
+	c.recordEvent(Event{Kind: EventClose, Err: err})
+	c.deadlineCallback.stop()
+	c.deadlineSync.stop()
+	c.idle.stop()
+	c.runCloseCallbacks()
 	return err
 }
 
 // LocalAddr gets the local address from the underlying net.Conn and invokes
 // an 'after' hook if it was set up.
 func (c *Conn) LocalAddr() net.Addr {
+	if c.hooksDisabled.Load() {
+		return c.Base.LocalAddr()
+	}
+	defer c.spentInMethod(c.now())
+	start := c.now()
 	addr := c.Base.LocalAddr()
-	if c.AfterLocalAddr != nil {
-		defer c.AfterLocalAddr(c, addr)
+	c.spentInBase(start)
+	if hook := c.AfterLocalAddrHook(); hook != nil {
+		hook(c, addr)
 	}
 	return addr
 }
 
-// RemoteAddr gets the remote address from the underlying net.Conn and invokes
-// an 'after' hook if it was set up.
+// RemoteAddr gets the remote address from the underlying net.Conn, unless it
+// was overridden with SetRemoteAddr, and invokes an 'after' hook if it was set
+// up.
 func (c *Conn) RemoteAddr() net.Addr {
-	addr := c.Base.RemoteAddr()
-	if c.AfterRemoteAddr != nil {
-		defer c.AfterRemoteAddr(c, addr)
+	if c.hooksDisabled.Load() {
+		return c.remoteAddrDirect()
+	}
+	defer c.spentInMethod(c.now())
+	var addr net.Addr
+	if override := c.remoteAddr.Load(); override != nil {
+		addr = *override
+	} else {
+		start := c.now()
+		addr = c.Base.RemoteAddr()
+		c.spentInBase(start)
+	}
+	if hook := c.AfterRemoteAddrHook(); hook != nil {
+		hook(c, addr)
 	}
 	return addr
 }
 
 // SetDeadline sets a deadline on the underlying net.Conn and invokes relevant
-// hooks ('before' and 'after') that were set up.
+// hooks ('before' and 'after') that were set up. If the Conn is enrolled in a
+// Budget the deadline can't be set past the budget's deadline. The same
+// applies to SetReadDeadline and SetWriteDeadline.
 func (c *Conn) SetDeadline(t time.Time) error {
-	if c.BeforeSetDeadline != nil {
-		if err := c.BeforeSetDeadline(c, t); err != nil {
-			return err
+	if c.hooksDisabled.Load() {
+		return c.baseSetDeadline(c.clampToBudget(t), true, true)
+	}
+	defer c.spentInMethod(c.now())
+	t = c.clampToBudget(t)
+	if hook := c.BeforeSetDeadlineHook(); hook != nil {
+		if err := hook(c, t); err != nil {
+			return beforeHookError("SetDeadline", err)
 		}
 	}
-	err := c.Base.SetDeadline(t)
-	if c.AfterSetDeadline != nil {
-		defer c.AfterSetDeadline(c, t, err)
+	err := c.baseSetDeadline(t, true, true)
+	if hook := c.AfterSetDeadlineHook(); hook != nil {
+		hook(c, t, err)
 	}
 	return err
 }
@@ -221,14 +884,19 @@ func (c *Conn) SetDeadline(t time.Time) error {
 // SetReadDeadline sets a read deadline on the underlying net.Conn and invokes
 // relevant hooks ('before' and 'after') that were set up.
 func (c *Conn) SetReadDeadline(t time.Time) error {
-	if c.BeforeSetReadDeadline != nil {
-		if err := c.BeforeSetReadDeadline(c, t); err != nil {
-			return err
+	if c.hooksDisabled.Load() {
+		return c.baseSetDeadline(c.clampToBudget(t), true, false)
+	}
+	defer c.spentInMethod(c.now())
+	t = c.clampToBudget(t)
+	if hook := c.BeforeSetReadDeadlineHook(); hook != nil {
+		if err := hook(c, t); err != nil {
+			return beforeHookError("SetReadDeadline", err)
 		}
 	}
-	err := c.Base.SetReadDeadline(t)
-	if c.AfterSetReadDeadline != nil {
-		defer c.AfterSetReadDeadline(c, t, err)
+	err := c.baseSetDeadline(t, true, false)
+	if hook := c.AfterSetReadDeadlineHook(); hook != nil {
+		hook(c, t, err)
 	}
 	return err
 }
@@ -236,14 +904,128 @@ func (c *Conn) SetReadDeadline(t time.Time) error {
 // SetWriteDeadline sets a write deadline on the underlying net.Conn and invokes
 // relevant hooks ('before' and 'after') that were set up.
 func (c *Conn) SetWriteDeadline(t time.Time) error {
-	if c.BeforeSetWriteDeadline != nil {
-		if err := c.BeforeSetWriteDeadline(c, t); err != nil {
-			return err
+	if c.hooksDisabled.Load() {
+		return c.baseSetDeadline(c.clampToBudget(t), false, true)
+	}
+	defer c.spentInMethod(c.now())
+	t = c.clampToBudget(t)
+	if hook := c.BeforeSetWriteDeadlineHook(); hook != nil {
+		if err := hook(c, t); err != nil {
+			return beforeHookError("SetWriteDeadline", err)
 		}
 	}
-	err := c.Base.SetWriteDeadline(t)
-	if c.AfterSetWriteDeadline != nil {
-		defer c.AfterSetWriteDeadline(c, t, err)
+	err := c.baseSetDeadline(t, false, true)
+	if hook := c.AfterSetWriteDeadlineHook(); hook != nil {
+		hook(c, t, err)
+	}
+	return err
+}
+
+// baseSetDeadline sets the read and/or write deadline (depending on read and
+// write) on the underlying net.Conn and records it.
+func (c *Conn) baseSetDeadline(t time.Time, read, write bool) error {
+	start := c.now()
+	var err error
+	kind := EventSetDeadline
+	switch {
+	case read && write:
+		err = c.Base.SetDeadline(t)
+	case read:
+		err = c.Base.SetReadDeadline(t)
+		kind = EventSetReadDeadline
+	default:
+		err = c.Base.SetWriteDeadline(t)
+		kind = EventSetWriteDeadline
+	}
+	c.spentInBase(start)
+	if err == nil {
+		c.deadlines.set(t, read, write)
 	}
+	c.recordEvent(Event{Kind: kind, Deadline: t, Err: err})
 	return err
 }
+
+// SyscallConn returns a raw network connection from the underlying net.Conn
+// if it implements syscall.Conn (eg. *net.TCPConn), which lets code such as
+// golang.org/x/net/ipv4 or socket option tuning work through the wrapper.
+// Otherwise ErrNotSyscallConn is returned (and passed to the 'after' hook).
+func (c *Conn) SyscallConn() (syscall.RawConn, error) {
+	if c.hooksDisabled.Load() {
+		return c.syscallConnDirect()
+	}
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeSyscallConnHook(); hook != nil {
+		if err := hook(c); err != nil {
+			return nil, beforeHookError("SyscallConn", err)
+		}
+	}
+	var raw syscall.RawConn
+	err := ErrNotSyscallConn
+	if sc, implements := c.Base.(syscall.Conn); implements {
+		start := c.now()
+		raw, err = sc.SyscallConn()
+		c.spentInBase(start)
+	}
+	if hook := c.AfterSyscallConnHook(); hook != nil {
+		hook(c, raw, err)
+	}
+	return raw, err
+}
+
+// context returns the Conn's Context, defaulting to context.Background().
+func (c *Conn) context() context.Context {
+	if c.Context == nil {
+		return context.Background()
+	}
+	return c.Context
+}
+
+// onClose registers an internal callback to be run once, after the underlying
+// net.Conn is closed for the first time. Unlike AfterClose it can't be
+// overwritten by users. If the Conn is already closed fn is run straight away.
+func (c *Conn) onClose(fn func(*Conn)) {
+	c.closeMu.Lock()
+	if !c.closed {
+		c.closeCallbacks = append(c.closeCallbacks, fn)
+		c.closeMu.Unlock()
+		return
+	}
+	c.closeMu.Unlock()
+	fn(c)
+}
+
+// runCloseCallbacks marks the Conn as closed and runs the callbacks registered
+// with onClose, unless that already happened.
+func (c *Conn) runCloseCallbacks() {
+	c.closeMu.Lock()
+	if c.closed {
+		c.closeMu.Unlock()
+		return
+	}
+	c.closed = true
+	callbacks := c.closeCallbacks
+	c.closeCallbacks = nil
+	c.closeMu.Unlock()
+	for _, fn := range callbacks {
+		fn(c)
+	}
+}
+
+// hookBuffer returns the buffer b to be passed to a 'before' hook, which is a
+// copy of b if CopyHookBuffers is set.
+func (c *Conn) hookBuffer(b []byte) []byte {
+	if !c.CopyHookBuffers {
+		return b
+	}
+	return bytes.Clone(b)
+}
+
+// afterHookBuffer returns the buffer b, of which n bytes were transferred, to
+// be passed to an 'after' hook: a copy of these n bytes if CopyHookBuffers is
+// set, or b itself otherwise.
+func (c *Conn) afterHookBuffer(b []byte, n int) []byte {
+	if !c.CopyHookBuffers {
+		return b
+	}
+	return bytes.Clone(b[:max(0, min(n, len(b)))])
+}
@@ -0,0 +1,64 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+)
+
+func TestReadFromHooksFireWithoutPacketConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	beforeCalled, afterCalled := false, false
+	cc := &Conn{
+		Base: client,
+		BeforeReadFrom: func(*Conn, []byte) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterReadFrom: func(_ *Conn, _ []byte, n int, addr net.Addr, err error) {
+			afterCalled = true
+			if n != 0 || addr != nil || err != ErrNotPacketConn {
+				t.Errorf("Unexpected results (%d, %v, %v), expected (0, nil, %v)", n, addr, err, ErrNotPacketConn)
+			}
+		},
+	}
+	if _, _, err := cc.ReadFrom(nil); err != ErrNotPacketConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotPacketConn)
+	}
+	if !beforeCalled {
+		t.Error("Before callback not invoked")
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestWriteToHooksFireWithoutPacketConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	beforeCalled, afterCalled := false, false
+	cc := &Conn{
+		Base: client,
+		BeforeWriteTo: func(*Conn, []byte, net.Addr) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterWriteTo: func(_ *Conn, _ []byte, _ net.Addr, n int, err error) {
+			afterCalled = true
+			if n != 0 || err != ErrNotPacketConn {
+				t.Errorf("Unexpected results (%d, %v), expected (0, %v)", n, err, ErrNotPacketConn)
+			}
+		},
+	}
+	if _, err := cc.WriteTo(nil, nil); err != ErrNotPacketConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotPacketConn)
+	}
+	if !beforeCalled {
+		t.Error("Before callback not invoked")
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
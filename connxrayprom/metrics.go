@@ -0,0 +1,144 @@
+// Package connxrayprom exposes connection metrics gathered with connxray hooks
+// as Prometheus metrics. It lives in a separate package so that the
+// Prometheus client library is only pulled in by programs which use it.
+//
+// Typical usage:
+//
+//	m := connxrayprom.NewMetrics("myserver")
+//	prometheus.MustRegister(m)
+//	l := &connxray.Listener{
+//		Base:         base,
+//		ConnTemplate: m.ConnTemplate(),
+//		AfterAccept:  m.AfterAccept(),
+//	}
+package connxrayprom
+
+import (
+	"errors"
+	"io"
+	"sync"
+	"time"
+
+	xray "github.com/marcinwyszynski/connxray"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// Metrics holds Prometheus metrics describing connections accepted by a
+// connxray.Listener. None of the metrics have labels: in particular metrics
+// are deliberately not broken down by remote address, which would make their
+// cardinality unbounded. Metrics implements prometheus.Collector.
+type Metrics struct {
+	accepted     prometheus.Counter
+	acceptErrors prometheus.Counter
+	open         prometheus.Gauge
+	duration     prometheus.Histogram
+	bytesRead    prometheus.Counter
+	bytesWritten prometheus.Counter
+	readErrors   prometheus.Counter
+	writeErrors  prometheus.Counter
+}
+
+// NewMetrics creates metrics whose names are prefixed with namespace.
+func NewMetrics(namespace string) *Metrics {
+	counter := func(name, help string) prometheus.Counter {
+		return prometheus.NewCounter(prometheus.CounterOpts{
+			Namespace: namespace,
+			Subsystem: "connxray",
+			Name:      name,
+			Help:      help,
+		})
+	}
+	return &Metrics{
+		accepted:     counter("connections_accepted_total", "Number of accepted connections."),
+		acceptErrors: counter("accept_errors_total", "Number of failed Accept calls."),
+		open: prometheus.NewGauge(prometheus.GaugeOpts{
+			Namespace: namespace,
+			Subsystem: "connxray",
+			Name:      "connections_open",
+			Help:      "Number of accepted connections which are not closed yet.",
+		}),
+		duration: prometheus.NewHistogram(prometheus.HistogramOpts{
+			Namespace: namespace,
+			Subsystem: "connxray",
+			Name:      "connection_duration_seconds",
+			Help:      "Time between accepting and closing connections.",
+			// From 10ms to about 45 minutes, which covers both short
+			// request-response exchanges and long-lived connections.
+			Buckets: prometheus.ExponentialBuckets(0.01, 4, 10),
+		}),
+		bytesRead:    counter("read_bytes_total", "Number of bytes read from connections."),
+		bytesWritten: counter("written_bytes_total", "Number of bytes written to connections."),
+		readErrors:   counter("read_errors_total", "Number of failed reads, not counting EOF."),
+		writeErrors:  counter("write_errors_total", "Number of failed writes."),
+	}
+}
+
+// collectors returns all metrics held by m.
+func (m *Metrics) collectors() []prometheus.Collector {
+	return []prometheus.Collector{
+		m.accepted,
+		m.acceptErrors,
+		m.open,
+		m.duration,
+		m.bytesRead,
+		m.bytesWritten,
+		m.readErrors,
+		m.writeErrors,
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (m *Metrics) Describe(ch chan<- *prometheus.Desc) {
+	for _, c := range m.collectors() {
+		c.Describe(ch)
+	}
+}
+
+// Collect implements prometheus.Collector.
+func (m *Metrics) Collect(ch chan<- prometheus.Metric) {
+	for _, c := range m.collectors() {
+		c.Collect(ch)
+	}
+}
+
+// AfterAccept returns a hook for connxray.Listener.AfterAccept which counts
+// accepted connections and measures how long they stay open. The hook adds
+// to the connection's AfterClose hook chain, so it does not interfere with
+// other AfterClose hooks.
+func (m *Metrics) AfterAccept() func(*xray.Listener, *xray.Conn, error) {
+	return func(_ *xray.Listener, conn *xray.Conn, err error) {
+		if err != nil {
+			m.acceptErrors.Inc()
+			return
+		}
+		m.accepted.Inc()
+		m.open.Inc()
+		accepted := time.Now()
+		var once sync.Once
+		conn.AppendAfterClose(func(*xray.Conn, error) {
+			once.Do(func() {
+				m.open.Dec()
+				m.duration.Observe(time.Since(accepted).Seconds())
+			})
+		})
+	}
+}
+
+// ConnTemplate returns a template for connxray.Listener.ConnTemplate whose
+// hooks count bytes transferred and I/O errors.
+func (m *Metrics) ConnTemplate() *xray.Conn {
+	template := &xray.Conn{}
+	template.AppendAfterRead(func(_ *xray.Conn, _ []byte, n int, err error) {
+		m.bytesRead.Add(float64(n))
+		if err != nil && !errors.Is(err, io.EOF) {
+			m.readErrors.Inc()
+		}
+	})
+	template.AppendAfterWrite(func(_ *xray.Conn, _ []byte, n int, err error) {
+		m.bytesWritten.Add(float64(n))
+		if err != nil {
+			m.writeErrors.Inc()
+		}
+	})
+	return template
+}
@@ -0,0 +1,67 @@
+package connxrayprom
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	xray "github.com/marcinwyszynski/connxray"
+	"github.com/marcinwyszynski/connxray/connxraytest"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+)
+
+func TestMetrics(t *testing.T) {
+	m := NewMetrics("test")
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(m)
+	client, server := net.Pipe()
+	l := &xray.Listener{
+		Base:         connxraytest.ScriptedListener(server),
+		ConnTemplate: m.ConnTemplate(),
+		AfterAccept:  m.AfterAccept(),
+	}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	go func() {
+		client.Write([]byte("hello"))
+		io.ReadFull(client, make([]byte, 2))
+		client.Close()
+	}()
+	io.ReadFull(conn, make([]byte, 5))
+	conn.Write([]byte("hi"))
+	conn.Read(make([]byte, 1))
+	if got := testutil.ToFloat64(m.open); got != 1 {
+		t.Errorf("Unexpected number of open connections %v, expected 1", got)
+	}
+	conn.Close()
+	conn.Close()
+	if _, err := l.Accept(); !errors.Is(err, net.ErrClosed) {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	for name, tc := range map[string]struct {
+		metric prometheus.Collector
+		exp    float64
+	}{
+		"accepted":     {m.accepted, 1},
+		"acceptErrors": {m.acceptErrors, 1},
+		"open":         {m.open, 0},
+		"bytesRead":    {m.bytesRead, 5},
+		"bytesWritten": {m.bytesWritten, 2},
+		"readErrors":   {m.readErrors, 0},
+		"writeErrors":  {m.writeErrors, 0},
+	} {
+		if got := testutil.ToFloat64(tc.metric); got != tc.exp {
+			t.Errorf("Unexpected value of %s %v, expected %v", name, got, tc.exp)
+		}
+	}
+	if n := testutil.CollectAndCount(m, "test_connxray_connection_duration_seconds"); n != 1 {
+		t.Errorf("Unexpected number of duration metrics %d, expected 1", n)
+	}
+	if n := testutil.CollectAndCount(reg); n != 8 {
+		t.Errorf("Unexpected number of metrics %d, expected 8", n)
+	}
+}
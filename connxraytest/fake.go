@@ -0,0 +1,164 @@
+package connxraytest
+
+import (
+	"io"
+	"net"
+	"sync"
+	"time"
+)
+
+// FakeConn is a net.Conn and net.PacketConn whose behavior is driven by
+// handler functions, so that tests can control exactly what each method
+// returns. Methods whose handler is nil fall back to a default behavior:
+//
+//   - Read and ReadFrom return reads scripted with NewFakeConn, then io.EOF,
+//   - Write and WriteTo succeed and record the data (see Written),
+//   - Close and the SetDeadline methods succeed,
+//   - LocalAddr and RemoteAddr return a placeholder address.
+//
+// Handlers must be set before the FakeConn is used.
+type FakeConn struct {
+	ReadHandler             func([]byte) (int, error)
+	ReadFromHandler         func([]byte) (int, net.Addr, error)
+	WriteHandler            func([]byte) (int, error)
+	WriteToHandler          func([]byte, net.Addr) (int, error)
+	CloseHandler            func() error
+	LocalAddrHandler        func() net.Addr
+	RemoteAddrHandler       func() net.Addr
+	SetDeadlineHandler      func(time.Time) error
+	SetReadDeadlineHandler  func(time.Time) error
+	SetWriteDeadlineHandler func(time.Time) error
+
+	mu      sync.Mutex
+	reads   [][]byte
+	written []byte
+}
+
+// NewFakeConn returns a FakeConn whose successive Reads return reads in order,
+// followed by io.EOF. A read which does not fit in the buffer passed to Read
+// is returned over multiple calls. An empty read makes Read return (0, nil).
+func NewFakeConn(reads ...[]byte) *FakeConn {
+	return &FakeConn{reads: reads}
+}
+
+// Written returns a copy of all data written to the FakeConn by Write and
+// WriteTo calls which were not handled by WriteHandler and WriteToHandler.
+func (c *FakeConn) Written() []byte {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return append([]byte(nil), c.written...)
+}
+
+func (c *FakeConn) Read(b []byte) (int, error) {
+	if c.ReadHandler != nil {
+		return c.ReadHandler(b)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if len(c.reads) == 0 {
+		return 0, io.EOF
+	}
+	n := copy(b, c.reads[0])
+	if c.reads[0] = c.reads[0][n:]; len(c.reads[0]) == 0 {
+		c.reads = c.reads[1:]
+	}
+	return n, nil
+}
+
+func (c *FakeConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if c.ReadFromHandler != nil {
+		return c.ReadFromHandler(b)
+	}
+	n, err := c.Read(b)
+	return n, c.RemoteAddr(), err
+}
+
+func (c *FakeConn) Write(b []byte) (int, error) {
+	if c.WriteHandler != nil {
+		return c.WriteHandler(b)
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.written = append(c.written, b...)
+	return len(b), nil
+}
+
+func (c *FakeConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if c.WriteToHandler != nil {
+		return c.WriteToHandler(b, addr)
+	}
+	return c.Write(b)
+}
+
+func (c *FakeConn) Close() error {
+	if c.CloseHandler != nil {
+		return c.CloseHandler()
+	}
+	return nil
+}
+
+func (c *FakeConn) LocalAddr() net.Addr {
+	if c.LocalAddrHandler != nil {
+		return c.LocalAddrHandler()
+	}
+	return scriptedAddr{}
+}
+
+func (c *FakeConn) RemoteAddr() net.Addr {
+	if c.RemoteAddrHandler != nil {
+		return c.RemoteAddrHandler()
+	}
+	return scriptedAddr{}
+}
+
+func (c *FakeConn) SetDeadline(t time.Time) error {
+	if c.SetDeadlineHandler != nil {
+		return c.SetDeadlineHandler(t)
+	}
+	return nil
+}
+
+func (c *FakeConn) SetReadDeadline(t time.Time) error {
+	if c.SetReadDeadlineHandler != nil {
+		return c.SetReadDeadlineHandler(t)
+	}
+	return nil
+}
+
+func (c *FakeConn) SetWriteDeadline(t time.Time) error {
+	if c.SetWriteDeadlineHandler != nil {
+		return c.SetWriteDeadlineHandler(t)
+	}
+	return nil
+}
+
+// FakeListener is a net.Listener whose behavior is driven by handler
+// functions. Methods whose handler is nil fall back to a default behavior:
+// Accept returns net.ErrClosed, Close succeeds and Addr returns a placeholder
+// address. See also ScriptedListener.
+type FakeListener struct {
+	AcceptHandler func() (net.Conn, error)
+	CloseHandler  func() error
+	AddrHandler   func() net.Addr
+}
+
+func (l *FakeListener) Accept() (net.Conn, error) {
+	if l.AcceptHandler != nil {
+		return l.AcceptHandler()
+	}
+	return nil, net.ErrClosed
+}
+
+func (l *FakeListener) Close() error {
+	if l.CloseHandler != nil {
+		return l.CloseHandler()
+	}
+	return nil
+}
+
+func (l *FakeListener) Addr() net.Addr {
+	if l.AddrHandler != nil {
+		return l.AddrHandler()
+	}
+	return scriptedAddr{}
+}
@@ -0,0 +1,97 @@
+package connxraytest
+
+import (
+	"errors"
+	"io"
+	"net"
+	"testing"
+
+	"github.com/marcinwyszynski/connxray"
+)
+
+var (
+	_ net.Conn       = &FakeConn{}
+	_ net.PacketConn = &FakeConn{}
+	_ net.Listener   = &FakeListener{}
+)
+
+func TestFakeConnScriptedReads(t *testing.T) {
+	c := NewFakeConn([]byte("chunky"), []byte{}, []byte("bacon"))
+	buf := make([]byte, 4)
+	exp := []struct {
+		data string
+		err  error
+	}{{"chun", nil}, {"ky", nil}, {"", nil}, {"baco", nil}, {"n", nil}, {"", io.EOF}}
+	for _, e := range exp {
+		n, err := c.Read(buf)
+		if string(buf[:n]) != e.data || err != e.err {
+			t.Errorf("Unexpected results (%q, %v), expected (%q, %v)", buf[:n], err, e.data, e.err)
+		}
+	}
+}
+
+func TestFakeConnRecordsWrites(t *testing.T) {
+	c := NewFakeConn()
+	c.Write([]byte("chunky "))
+	c.WriteTo([]byte("bacon"), nil)
+	if written := string(c.Written()); written != "chunky bacon" {
+		t.Errorf("Unexpected data %q, expected \"chunky bacon\"", written)
+	}
+}
+
+func TestFakeConnHandlers(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	addr := &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 53}
+	c := &FakeConn{
+		ReadFromHandler: func(b []byte) (int, net.Addr, error) {
+			return copy(b, "dns"), addr, nil
+		},
+		WriteHandler: func([]byte) (int, error) {
+			return 0, expErr
+		},
+	}
+	buf := make([]byte, 8)
+	if n, from, err := c.ReadFrom(buf); string(buf[:n]) != "dns" || from != addr || err != nil {
+		t.Errorf("Unexpected results (%q, %v, %v), expected (\"dns\", %v, nil)", buf[:n], from, err, addr)
+	}
+	if _, err := c.Write(nil); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if len(c.Written()) != 0 {
+		t.Errorf("Unexpected data %q, expected none", c.Written())
+	}
+}
+
+func TestFakeConnWithHooks(t *testing.T) {
+	var reads int
+	c := connxray.NewConn(NewFakeConn([]byte("chunky"), []byte("bacon")), connxray.WithAfterRead(func(*connxray.Conn, []byte, int, error) {
+		reads++
+	}))
+	data, err := io.ReadAll(c)
+	if string(data) != "chunkybacon" || err != nil {
+		t.Errorf("Unexpected results (%q, %v), expected (\"chunkybacon\", nil)", data, err)
+	}
+	if reads != 3 {
+		t.Errorf("Unexpected number of reads %d, expected 3", reads)
+	}
+}
+
+func TestFakeListener(t *testing.T) {
+	conn := NewFakeConn()
+	l := &FakeListener{
+		AcceptHandler: func() (net.Conn, error) {
+			return conn, nil
+		},
+	}
+	cl := connxray.NewListener(l)
+	accepted, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if base := accepted.(*connxray.Conn).Base; base != conn {
+		t.Errorf("Unexpected conn %v, expected %v", base, conn)
+	}
+	if _, err := (&FakeListener{}).Accept(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+}
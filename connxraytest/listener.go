@@ -0,0 +1,56 @@
+// Package connxraytest provides test doubles for code which uses connxray,
+// most notably for exercising Listener and Conn hooks without touching the
+// network.
+package connxraytest
+
+import (
+	"net"
+	"sync"
+)
+
+// scriptedAddr is the net.Addr reported by a scripted listener and by fakes.
+type scriptedAddr struct{}
+
+func (scriptedAddr) Network() string { return "scripted" }
+func (scriptedAddr) String() string  { return "scripted" }
+
+// scriptedListener is a net.Listener which hands out a predefined sequence of
+// connections.
+type scriptedListener struct {
+	mu     sync.Mutex
+	conns  []net.Conn
+	closed bool
+}
+
+// ScriptedListener returns a net.Listener whose successive Accept calls return
+// the supplied conns in order. Once the sequence is exhausted, or after the
+// listener is closed, Accept returns net.ErrClosed.
+func ScriptedListener(conns ...net.Conn) net.Listener {
+	return &scriptedListener{conns: conns}
+}
+
+func (l *scriptedListener) Accept() (net.Conn, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed || len(l.conns) == 0 {
+		return nil, net.ErrClosed
+	}
+	conn := l.conns[0]
+	l.conns = l.conns[1:]
+	return conn, nil
+}
+
+func (l *scriptedListener) Close() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.closed {
+		return net.ErrClosed
+	}
+	l.closed = true
+	l.conns = nil
+	return nil
+}
+
+func (l *scriptedListener) Addr() net.Addr {
+	return scriptedAddr{}
+}
@@ -0,0 +1,66 @@
+package connxraytest
+
+import (
+	"net"
+	"testing"
+
+	"github.com/marcinwyszynski/connxray"
+)
+
+func TestScriptedListenerSequence(t *testing.T) {
+	c1, c2 := &net.TCPConn{}, &net.UDPConn{}
+	l := ScriptedListener(c1, c2)
+	for _, exp := range []net.Conn{c1, c2} {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if conn != exp {
+			t.Errorf("Unexpected conn %v, expected %v", conn, exp)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if _, err := l.Accept(); err != net.ErrClosed {
+			t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+		}
+	}
+}
+
+func TestScriptedListenerClose(t *testing.T) {
+	l := ScriptedListener(&net.TCPConn{})
+	if err := l.Close(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := l.Accept(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	if err := l.Close(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+}
+
+func TestScriptedListenerWithHooks(t *testing.T) {
+	base := &net.TCPConn{}
+	accepted := []net.Conn{}
+	var lastErr error
+	l := &connxray.Listener{
+		Base: ScriptedListener(base),
+		AfterAccept: func(_ *connxray.Listener, c *connxray.Conn, err error) {
+			if lastErr = err; err == nil {
+				accepted = append(accepted, c.Base)
+			}
+		},
+	}
+	if _, err := l.Accept(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if _, err := l.Accept(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	if len(accepted) != 1 || accepted[0] != base {
+		t.Errorf("Unexpected accepted conns %v", accepted)
+	}
+	if lastErr != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", lastErr, net.ErrClosed)
+	}
+}
@@ -0,0 +1,145 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+)
+
+type ctxKey struct{}
+
+func TestContextDefaultsToBackground(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	var seen context.Context
+	cc := &Conn{
+		Base: mc,
+		BeforeReadCtx: func(ctx context.Context, _ *Conn, _ []byte) error {
+			seen = ctx
+			return nil
+		},
+	}
+	if _, err := cc.Read(make([]byte, 1)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if seen != context.Background() {
+		t.Errorf("Unexpected context %v, expected background", seen)
+	}
+}
+
+func TestBeforeReadCtxCancelled(t *testing.T) {
+	baseCalled, afterCalled := false, false
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			baseCalled = true
+			return len(b), nil
+		},
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cc := &Conn{
+		Base:    mc,
+		Context: ctx,
+		BeforeReadCtx: func(ctx context.Context, _ *Conn, _ []byte) error {
+			return ctx.Err()
+		},
+		AfterReadCtx: func(_ context.Context, _ *Conn, _ []byte, _ int, _ error) {
+			afterCalled = true
+		},
+	}
+	if _, err := cc.Read(make([]byte, 1)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error %v, expected %v", err, context.Canceled)
+	}
+	if baseCalled {
+		t.Error("Base method invoked")
+	}
+	if afterCalled {
+		t.Error("After callback invoked")
+	}
+}
+
+func TestContextHookOrder(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	calls := []string{}
+	record := func(name string) {
+		calls = append(calls, name)
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeWrite: func(_ *Conn, _ []byte) error {
+			record("BeforeWrite")
+			return nil
+		},
+		BeforeWriteCtx: func(_ context.Context, _ *Conn, _ []byte) error {
+			record("BeforeWriteCtx")
+			return nil
+		},
+		AfterWrite: func(_ *Conn, _ []byte, _ int, _ error) {
+			record("AfterWrite")
+		},
+		AfterWriteCtx: func(_ context.Context, _ *Conn, _ []byte, _ int, _ error) {
+			record("AfterWriteCtx")
+		},
+		BeforeClose: func(_ *Conn) error {
+			record("BeforeClose")
+			return nil
+		},
+		BeforeCloseCtx: func(_ context.Context, _ *Conn) error {
+			record("BeforeCloseCtx")
+			return nil
+		},
+		AfterClose: func(_ *Conn, _ error) {
+			record("AfterClose")
+		},
+		AfterCloseCtx: func(_ context.Context, _ *Conn, _ error) {
+			record("AfterCloseCtx")
+		},
+	}
+	cc.Write(make([]byte, 1))
+	cc.Close()
+	exp := []string{
+		"BeforeWrite", "BeforeWriteCtx", "AfterWrite", "AfterWriteCtx",
+		"BeforeClose", "BeforeCloseCtx", "AfterClose", "AfterCloseCtx",
+	}
+	if len(calls) != len(exp) {
+		t.Fatalf("Unexpected calls %v, expected %v", calls, exp)
+	}
+	for i := range exp {
+		if calls[i] != exp[i] {
+			t.Errorf("Unexpected call %q, expected %q", calls[i], exp[i])
+		}
+	}
+}
+
+func TestListenerBaseContext(t *testing.T) {
+	mc := &mockConn{}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return mc, nil
+		},
+	}
+	cl := &Listener{
+		Base: ml,
+		BaseContext: func(c net.Conn) context.Context {
+			return context.WithValue(context.Background(), ctxKey{}, c)
+		},
+	}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if v := nc.(*Conn).Context.Value(ctxKey{}); v != mc {
+		t.Errorf("Unexpected context value %v, expected %v", v, mc)
+	}
+}
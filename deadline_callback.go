@@ -0,0 +1,55 @@
+package connxray
+
+import (
+	"sync"
+	"time"
+)
+
+// deadlineCallback holds the state behind Conn.SetDeadlineCallback.
+type deadlineCallback struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	gen     uint64
+	stopped bool
+}
+
+// SetDeadlineCallback arranges for fn to be invoked (in its own goroutine) at
+// time t, letting the application react before a hard I/O timeout, eg. by
+// sending a keepalive. Unlike SetDeadline it has no effect on the underlying
+// net.Conn. Each call replaces the previously set callback, a zero t cancels
+// it and closing the Conn stops it for good.
+func (c *Conn) SetDeadlineCallback(t time.Time, fn func(*Conn)) {
+	dc := &c.deadlineCallback
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.timer != nil {
+		dc.timer.Stop()
+		dc.timer = nil
+	}
+	dc.gen++
+	if dc.stopped || t.IsZero() || fn == nil {
+		return
+	}
+	gen := dc.gen
+	dc.timer = time.AfterFunc(time.Until(t), func() {
+		dc.mu.Lock()
+		current := dc.gen == gen && !dc.stopped
+		dc.mu.Unlock()
+		if current {
+			fn(c)
+		}
+	})
+}
+
+// stop cancels a pending deadline callback and prevents new ones from being
+// set. It is called when the Conn is closed.
+func (dc *deadlineCallback) stop() {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if dc.timer != nil {
+		dc.timer.Stop()
+		dc.timer = nil
+	}
+	dc.gen++
+	dc.stopped = true
+}
@@ -0,0 +1,73 @@
+package connxray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestSetDeadlineCallback(t *testing.T) {
+	fired := make(chan *Conn, 1)
+	cc := &Conn{Base: &mockConn{}}
+	start := time.Now()
+	cc.SetDeadlineCallback(start.Add(20*time.Millisecond), func(c *Conn) {
+		fired <- c
+	})
+	select {
+	case c := <-fired:
+		if c != cc {
+			t.Errorf("Unexpected conn %v, expected %v", c, cc)
+		}
+		if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+			t.Errorf("Callback fired after %v, expected at least 20ms", elapsed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Callback not invoked")
+	}
+}
+
+func TestSetDeadlineCallbackReplaceAndCancel(t *testing.T) {
+	fired := make(chan string, 2)
+	cc := &Conn{Base: &mockConn{}}
+	cc.SetDeadlineCallback(time.Now().Add(10*time.Millisecond), func(_ *Conn) {
+		fired <- "first"
+	})
+	cc.SetDeadlineCallback(time.Now().Add(20*time.Millisecond), func(_ *Conn) {
+		fired <- "second"
+	})
+	if name := <-fired; name != "second" {
+		t.Errorf("Unexpected callback %q, expected %q", name, "second")
+	}
+	cc.SetDeadlineCallback(time.Now().Add(10*time.Millisecond), func(_ *Conn) {
+		fired <- "cancelled"
+	})
+	cc.SetDeadlineCallback(time.Time{}, nil)
+	select {
+	case name := <-fired:
+		t.Errorf("Unexpected callback %q", name)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
+func TestSetDeadlineCallbackStopsOnClose(t *testing.T) {
+	fired := make(chan struct{}, 2)
+	mc := &mockConn{
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	cc.SetDeadlineCallback(time.Now().Add(20*time.Millisecond), func(_ *Conn) {
+		fired <- struct{}{}
+	})
+	if err := cc.Close(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cc.SetDeadlineCallback(time.Now().Add(10*time.Millisecond), func(_ *Conn) {
+		fired <- struct{}{}
+	})
+	select {
+	case <-fired:
+		t.Error("Callback invoked after close")
+	case <-time.After(50 * time.Millisecond):
+	}
+}
@@ -0,0 +1,77 @@
+package connxray
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// aLongTimeAgo is a deadline in the past, used to make pending and future I/O
+// fail immediately.
+var aLongTimeAgo = time.Unix(1, 0)
+
+// deadlineSync holds the state behind Conn.SyncDeadline.
+type deadlineSync struct {
+	mu      sync.Mutex
+	stopCh  chan struct{}
+	stopped bool
+}
+
+// SyncDeadline keeps the deadline of this Conn aligned with ctx: the deadline
+// is set to ctx's deadline (if it has one) straight away, and once ctx is done
+// the deadline is moved into the past so that all pending and future I/O
+// fails with a timeout error. Calling SyncDeadline again with another context
+// replaces the previous one. Watching stops when the Conn is closed. Errors
+// from setting the deadline are only visible to the SetDeadline hooks.
+func (c *Conn) SyncDeadline(ctx context.Context) {
+	ds := &c.deadlineSync
+	ds.mu.Lock()
+	ds.stopLocked()
+	if ds.stopped {
+		ds.mu.Unlock()
+		return
+	}
+	var stop chan struct{}
+	if ctx.Done() != nil {
+		stop = make(chan struct{})
+		ds.stopCh = stop
+	}
+	ds.mu.Unlock()
+	if deadline, ok := ctx.Deadline(); ok {
+		c.SetDeadline(deadline)
+	}
+	if stop == nil {
+		return
+	}
+	go func() {
+		select {
+		case <-ctx.Done():
+		case <-stop:
+			return
+		}
+		ds.mu.Lock()
+		current := ds.stopCh == stop
+		ds.mu.Unlock()
+		if current {
+			c.SetDeadline(aLongTimeAgo)
+		}
+	}()
+}
+
+// stopLocked stops watching the current context, if any. It must be called
+// with mu held.
+func (ds *deadlineSync) stopLocked() {
+	if ds.stopCh != nil {
+		close(ds.stopCh)
+		ds.stopCh = nil
+	}
+}
+
+// stop stops watching the current context and prevents new ones from being
+// watched. It is called when the Conn is closed.
+func (ds *deadlineSync) stop() {
+	ds.mu.Lock()
+	defer ds.mu.Unlock()
+	ds.stopLocked()
+	ds.stopped = true
+}
@@ -0,0 +1,75 @@
+package connxray
+
+import (
+	"context"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestSyncDeadlineTracksContextDeadline(t *testing.T) {
+	cc := echoPipe(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	expDeadline, _ := ctx.Deadline()
+	var lastDeadline atomic.Value
+	cc.AfterSetDeadline = func(_ *Conn, d time.Time, _ error) {
+		lastDeadline.Store(d)
+	}
+	cc.SyncDeadline(ctx)
+	if d := lastDeadline.Load().(time.Time); !d.Equal(expDeadline) {
+		t.Errorf("Unexpected deadline %v, expected %v", d, expDeadline)
+	}
+	if err := roundTrip(cc); err != nil {
+		t.Errorf("Unexpected error %v before the deadline", err)
+	}
+	<-ctx.Done()
+	time.Sleep(10 * time.Millisecond)
+	err := roundTrip(cc)
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Unexpected error %v, expected a timeout", err)
+	}
+}
+
+func TestSyncDeadlineCancel(t *testing.T) {
+	cc := echoPipe(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	cc.SyncDeadline(ctx)
+	if err := roundTrip(cc); err != nil {
+		t.Errorf("Unexpected error %v before cancellation", err)
+	}
+	errs := make(chan error, 1)
+	go func() {
+		_, err := cc.Read(make([]byte, 1))
+		errs <- err
+	}()
+	cancel()
+	select {
+	case err := <-errs:
+		if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+			t.Errorf("Unexpected error %v, expected a timeout", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Read not interrupted by cancellation")
+	}
+}
+
+func TestSyncDeadlineStopsOnClose(t *testing.T) {
+	cc := echoPipe(t)
+	ctx, cancel := context.WithCancel(context.Background())
+	var calls atomic.Int32
+	cc.AfterSetDeadline = func(_ *Conn, _ time.Time, _ error) {
+		calls.Add(1)
+	}
+	cc.SyncDeadline(ctx)
+	if err := cc.Close(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cancel()
+	cc.SyncDeadline(context.Background())
+	time.Sleep(20 * time.Millisecond)
+	if n := calls.Load(); n != 0 {
+		t.Errorf("Unexpected number of SetDeadline calls %d, expected 0", n)
+	}
+}
@@ -0,0 +1,35 @@
+package connxray
+
+import (
+	"sync"
+	"time"
+)
+
+// deadlineCache remembers the deadlines most recently applied to the
+// underlying net.Conn.
+type deadlineCache struct {
+	mu          sync.Mutex
+	read, write time.Time
+}
+
+// set records t as the read and/or write deadline.
+func (dc *deadlineCache) set(t time.Time, read, write bool) {
+	dc.mu.Lock()
+	defer dc.mu.Unlock()
+	if read {
+		dc.read = t
+	}
+	if write {
+		dc.write = t
+	}
+}
+
+// Deadlines returns the read and write deadlines most recently applied to the
+// underlying net.Conn by SetDeadline, SetReadDeadline and SetWriteDeadline.
+// Deadlines which the underlying net.Conn refused to set are not recorded. A
+// zero time means no deadline was set (or it was cleared).
+func (c *Conn) Deadlines() (read, write time.Time) {
+	c.deadlines.mu.Lock()
+	defer c.deadlines.mu.Unlock()
+	return c.deadlines.read, c.deadlines.write
+}
@@ -0,0 +1,36 @@
+package connxray
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestDeadlines(t *testing.T) {
+	mc := &mockConn{
+		setDeadlineHandler: func(time.Time) error {
+			return nil
+		},
+		setReadDeadlineHandler: func(time.Time) error {
+			return nil
+		},
+		setWriteDeadlineHandler: func(time.Time) error {
+			return errors.New("chunky bacon")
+		},
+	}
+	cc := &Conn{Base: mc}
+	readDeadline := time.Now().Add(time.Minute)
+	cc.SetReadDeadline(readDeadline)
+	if read, write := cc.Deadlines(); !read.Equal(readDeadline) || !write.IsZero() {
+		t.Errorf("Unexpected deadlines (%v, %v), expected (%v, zero)", read, write, readDeadline)
+	}
+	cc.SetWriteDeadline(time.Now().Add(time.Hour))
+	if _, write := cc.Deadlines(); !write.IsZero() {
+		t.Errorf("Unexpected write deadline %v recorded despite an error", write)
+	}
+	deadline := time.Now().Add(time.Second)
+	cc.SetDeadline(deadline)
+	if read, write := cc.Deadlines(); !read.Equal(deadline) || !write.Equal(deadline) {
+		t.Errorf("Unexpected deadlines (%v, %v), expected (%v, %v)", read, write, deadline, deadline)
+	}
+}
@@ -0,0 +1,77 @@
+package connxray
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// ContextDialer is the interface of the dialer wrapped by Dialer. It is
+// satisfied by *net.Dialer, among others.
+type ContextDialer interface {
+	DialContext(ctx context.Context, network, address string) (net.Conn, error)
+}
+
+// Dialer wraps a ContextDialer and returns dialed connections as *Conn with
+// hooks attached, providing the same introspection for client-side
+// connections as Listener provides for server-side ones. Its DialContext
+// method can be plugged directly into http.Transport.
+type Dialer struct {
+	// Underlying dialer. If nil, a zero net.Dialer is used.
+	Base ContextDialer
+
+	// BeforeDial is a 'before' hook for the Dial and DialContext methods. If
+	// it returns an error neither the base method nor the 'after' callback
+	// will be called.
+	BeforeDial func(d *Dialer, network, address string) error
+
+	// AfterDial is an 'after' hook for the Dial and DialContext methods. The
+	// Conn is nil if dialing failed.
+	AfterDial func(*Dialer, *Conn, error)
+
+	// ConnTemplate, if set, provides hooks for every dialed Conn: all of its
+	// hook function fields are copied onto the new Conn before it is passed
+	// to AfterDial (which can still override them) and returned.
+	ConnTemplate *Conn
+}
+
+// Dial connects to the address on the named network. See DialContext.
+func (d *Dialer) Dial(network, address string) (net.Conn, error) {
+	return d.DialContext(context.Background(), network, address)
+}
+
+// DialContext runs DialContext on the underlying dialer plus any relevant
+// hooks ('before' and 'after') that were set up, and returns the resulting
+// connection wrapped in a *Conn.
+func (d *Dialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	if d.BeforeDial != nil {
+		if err := d.BeforeDial(d, network, address); err != nil {
+			return nil, err
+		}
+	}
+	conn, err := d.dialConn(ctx, network, address)
+	if d.AfterDial != nil {
+		d.AfterDial(d, conn, err)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return conn, nil
+}
+
+// dialConn runs DialContext on the underlying dialer and wraps the result.
+func (d *Dialer) dialConn(ctx context.Context, network, address string) (*Conn, error) {
+	var base ContextDialer = &net.Dialer{}
+	if d.Base != nil {
+		base = d.Base
+	}
+	netconn, err := base.DialContext(ctx, network, address)
+	if err != nil {
+		return nil, err
+	}
+	conn := &Conn{Base: netconn, created: time.Now()}
+	if d.ConnTemplate != nil {
+		conn.copyHooks(d.ConnTemplate)
+	}
+	return conn, nil
+}
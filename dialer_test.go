@@ -0,0 +1,128 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestDialerWrapsConn(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	mc := &mockDialer{
+		dialHandler: func(_ context.Context, network, address string) (net.Conn, error) {
+			if network != "tcp" || address != "example.com:80" {
+				t.Errorf("Unexpected dial target %s/%s", network, address)
+			}
+			return client, nil
+		},
+	}
+	afterCalled := false
+	d := &Dialer{
+		Base:         mc,
+		ConnTemplate: &Conn{BeforeWrite: func(*Conn, []byte) error { return nil }},
+		AfterDial: func(_ *Dialer, c *Conn, err error) {
+			afterCalled = true
+			if err != nil {
+				t.Errorf("Unexpected error %v, expected nil", err)
+			}
+			if c.Base != client {
+				t.Errorf("Unexpected base %v, expected %v", c.Base, client)
+			}
+			if c.BeforeWriteHook() == nil {
+				t.Error("Template hooks not copied")
+			}
+		},
+	}
+	conn, err := d.Dial("tcp", "example.com:80")
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer conn.Close()
+	if _, ok := conn.(*Conn); !ok {
+		t.Errorf("Unexpected conn type %T, expected *Conn", conn)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestDialerWithFailingBase(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockDialer{
+		dialHandler: func(context.Context, string, string) (net.Conn, error) {
+			return nil, expErr
+		},
+	}
+	afterCalled := false
+	d := &Dialer{
+		Base: mc,
+		AfterDial: func(_ *Dialer, c *Conn, err error) {
+			afterCalled = true
+			if c != nil {
+				t.Errorf("Unexpected conn %v, expected nil", c)
+			}
+			if err != expErr {
+				t.Errorf("Unexpected error %v, expected %v", err, expErr)
+			}
+		},
+	}
+	conn, err := d.DialContext(context.Background(), "tcp", "example.com:80")
+	if conn != nil || err != expErr {
+		t.Errorf("Unexpected results (%v, %v), expected (nil, %v)", conn, err, expErr)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestDialerWithFailingBeforeCallback(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockDialer{
+		dialHandler: func(context.Context, string, string) (net.Conn, error) {
+			t.Error("Base method invoked")
+			return nil, nil
+		},
+	}
+	d := &Dialer{
+		Base: mc,
+		BeforeDial: func(*Dialer, string, string) error {
+			return expErr
+		},
+		AfterDial: func(*Dialer, *Conn, error) {
+			t.Error("After callback invoked")
+		},
+	}
+	if _, err := d.Dial("tcp", "example.com:80"); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestDialerWithHTTPTransport(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.Write([]byte("hello"))
+	}))
+	defer srv.Close()
+	var dialed *Conn
+	d := &Dialer{
+		AfterDial: func(_ *Dialer, c *Conn, _ error) {
+			dialed = c
+			c.TrackStats = true
+		},
+	}
+	client := &http.Client{Transport: &http.Transport{DialContext: d.DialContext}}
+	resp, err := client.Get(srv.URL)
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	resp.Body.Close()
+	if dialed == nil {
+		t.Fatal("After callback not invoked")
+	}
+	if got := dialed.Stats(); got.BytesWritten == 0 || got.BytesRead == 0 {
+		t.Errorf("Unexpected stats %+v, expected traffic in both directions", got)
+	}
+}
@@ -0,0 +1,41 @@
+package connxray
+
+import (
+	"net"
+	"syscall"
+)
+
+// SetHooksEnabled turns all hooks of the Conn on (the default) or off. While
+// hooks are off the methods of net.Conn and net.PacketConn, as well as
+// SyscallConn, skip all hooks (including interceptors) at the cost of a single
+// atomic load and go straight to the underlying net.Conn. Built-in
+// bookkeeping such as Stats, ErrorCounts, the event log, IdleTimeout and the
+// Peek buffer is kept up to date. Other methods (eg. CloseWrite) skip their
+// hooks too, since the getters in hooks.go return nil.
+// This allows flipping a live connection between full instrumentation and
+// next to no overhead, eg. when sampling adaptively, without reassigning hook
+// fields. It is safe to call concurrently with any Conn method.
+func (c *Conn) SetHooksEnabled(enabled bool) {
+	c.hooksDisabled.Store(!enabled)
+}
+
+// HooksEnabled tells whether hooks are enabled. See SetHooksEnabled.
+func (c *Conn) HooksEnabled() bool {
+	return !c.hooksDisabled.Load()
+}
+
+// remoteAddrDirect is RemoteAddr with hooks disabled.
+func (c *Conn) remoteAddrDirect() net.Addr {
+	if override := c.remoteAddr.Load(); override != nil {
+		return *override
+	}
+	return c.Base.RemoteAddr()
+}
+
+// syscallConnDirect is SyscallConn with hooks disabled.
+func (c *Conn) syscallConnDirect() (syscall.RawConn, error) {
+	if sc, implements := c.Base.(syscall.Conn); implements {
+		return sc.SyscallConn()
+	}
+	return nil, ErrNotSyscallConn
+}
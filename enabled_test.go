@@ -0,0 +1,178 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+func TestHooksEnabledByDefault(t *testing.T) {
+	cc := &Conn{}
+	if !cc.HooksEnabled() {
+		t.Error("Hooks unexpectedly disabled by default")
+	}
+	cc.SetHooksEnabled(false)
+	if cc.HooksEnabled() {
+		t.Error("Hooks unexpectedly enabled after SetHooksEnabled(false)")
+	}
+}
+
+func TestSetHooksEnabledMidConnection(t *testing.T) {
+	reads, writes, hooks := 0, 0, 0
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			reads++
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			writes++
+			return len(b), nil
+		},
+		closeHandler: func() error { return nil },
+	}
+	cc := &Conn{
+		Base:       mc,
+		TrackStats: true,
+		BeforeRead: func(*Conn, []byte) error {
+			hooks++
+			return nil
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			hooks++
+		},
+		AfterClose: func(*Conn, error) {
+			hooks++
+		},
+	}
+	buf := make([]byte, 4)
+	cc.Read(buf)
+	cc.Write(buf)
+	if hooks != 2 {
+		t.Errorf("Unexpected number of hook calls %d, expected 2", hooks)
+	}
+	cc.SetHooksEnabled(false)
+	cc.Read(buf)
+	cc.Write(buf)
+	if hooks != 2 {
+		t.Errorf("Unexpected number of hook calls %d while disabled, expected 2", hooks)
+	}
+	if stats := cc.Stats(); stats.Reads != 2 || stats.Writes != 2 {
+		t.Errorf("Unexpected stats %+v while disabled, expected 2 reads and 2 writes", stats)
+	}
+	cc.SetHooksEnabled(true)
+	cc.Read(buf)
+	cc.Write(buf)
+	cc.Close()
+	if hooks != 5 {
+		t.Errorf("Unexpected number of hook calls %d after re-enabling, expected 5", hooks)
+	}
+	if reads != 3 || writes != 3 {
+		t.Errorf("Unexpected number of base calls %d reads and %d writes, expected 3 each", reads, writes)
+	}
+}
+
+func TestHooksDisabledClose(t *testing.T) {
+	released := false
+	cc := &Conn{
+		Base: &mockConn{closeHandler: func() error { return nil }},
+		BeforeClose: func(*Conn) error {
+			t.Error("BeforeClose invoked while hooks are disabled")
+			return nil
+		},
+	}
+	cc.onClose(func(*Conn) { released = true })
+	cc.SetHooksEnabled(false)
+	if err := cc.Close(); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if !released {
+		t.Error("Close callbacks not run while hooks are disabled")
+	}
+	if !cc.IsClosed() {
+		t.Error("Conn unexpectedly not closed")
+	}
+}
+
+func TestHooksDisabledPacketConn(t *testing.T) {
+	cc := &Conn{
+		Base: &mockStreamConn{},
+		AfterWriteTo: func(*Conn, []byte, net.Addr, int, error) {
+			t.Error("AfterWriteTo invoked while hooks are disabled")
+		},
+	}
+	cc.SetHooksEnabled(false)
+	if _, err := cc.WriteTo(nil, nil); err != ErrNotPacketConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotPacketConn)
+	}
+}
+
+func TestHooksDisabledGetters(t *testing.T) {
+	cc := &Conn{AfterCloseWrite: func(*Conn, error) {}}
+	cc.SetHooksEnabled(false)
+	if cc.AfterCloseWriteHook() != nil {
+		t.Error("Unexpected AfterCloseWrite hook while hooks are disabled")
+	}
+}
+
+func TestHooksDisabledKeepsIdleTimeout(t *testing.T) {
+	var closes atomic.Int32
+	cc := &Conn{
+		Base:        idleMockConn(&closes),
+		IdleTimeout: 50 * time.Millisecond,
+	}
+	cc.SetHooksEnabled(false)
+	for i := 0; i < 10; i++ {
+		cc.Write([]byte("foo"))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closes.Load() != 0 {
+		t.Error("Conn closed as idle while writing with hooks disabled")
+	}
+	time.Sleep(150 * time.Millisecond)
+	if closes.Load() != 1 {
+		t.Errorf("Unexpected number of closes %d, expected 1", closes.Load())
+	}
+}
+
+// discardConn is a net.Conn reading zeros and discarding writes, with no
+// overhead of its own.
+type discardConn struct {
+	net.Conn
+}
+
+func (discardConn) Read(b []byte) (int, error) {
+	return len(b), nil
+}
+
+func benchmarkRead(b *testing.B, conn io.Reader) {
+	buf := make([]byte, 64)
+	b.ReportAllocs()
+	for i := 0; i < b.N; i++ {
+		conn.Read(buf)
+	}
+}
+
+func BenchmarkReadBase(b *testing.B) {
+	benchmarkRead(b, discardConn{})
+}
+
+func BenchmarkReadHooksDisabled(b *testing.B) {
+	cc := &Conn{
+		Base:       discardConn{},
+		BeforeRead: func(*Conn, []byte) error { return nil },
+		AfterRead:  func(*Conn, []byte, int, error) {},
+	}
+	cc.SetHooksEnabled(false)
+	benchmarkRead(b, cc)
+}
+
+func BenchmarkReadHooksEnabled(b *testing.B) {
+	cc := &Conn{
+		Base:       discardConn{},
+		BeforeRead: func(*Conn, []byte) error { return nil },
+		AfterRead:  func(*Conn, []byte, int, error) {},
+	}
+	benchmarkRead(b, cc)
+}
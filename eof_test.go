@@ -0,0 +1,56 @@
+package connxray
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestAfterEOF(t *testing.T) {
+	testCases := []struct {
+		desc     string
+		n        int
+		err      error
+		expCalls int
+	}{
+		{"empty read", 0, nil, 0},
+		{"clean close", 0, io.EOF, 1},
+		{"final data", 3, io.EOF, 1},
+		{"wrapped EOF", 0, fmt.Errorf("closing: %w", io.EOF), 1},
+		{"other error", 2, errors.New("chunky bacon"), 0},
+	}
+	for _, tc := range testCases {
+		mc := &mockConn{
+			readHandler: func(b []byte) (int, error) {
+				return tc.n, tc.err
+			},
+		}
+		var calls, eofN int
+		afterRead := false
+		cc := &Conn{
+			Base:       mc,
+			TrackStats: true,
+			AfterRead: func(*Conn, []byte, int, error) {
+				afterRead = true
+			},
+			AfterEOF: func(_ *Conn, n int) {
+				calls++
+				eofN = n
+			},
+		}
+		cc.Read(make([]byte, 8))
+		if calls != tc.expCalls {
+			t.Errorf("%s: Unexpected number of AfterEOF calls %d, expected %d", tc.desc, calls, tc.expCalls)
+		}
+		if calls > 0 && eofN != tc.n {
+			t.Errorf("%s: Unexpected n %d, expected %d", tc.desc, eofN, tc.n)
+		}
+		if !afterRead {
+			t.Errorf("%s: AfterRead not invoked", tc.desc)
+		}
+		if eofs := cc.Stats().EOFs; eofs != int64(tc.expCalls) {
+			t.Errorf("%s: Unexpected EOF count %d, expected %d", tc.desc, eofs, tc.expCalls)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+)
+
+// Error classes used as keys in the map returned by Conn.ErrorCounts.
+const (
+	// ErrorClassTimeout covers errors implementing net.Error with Timeout()
+	// returning true, eg. exceeded deadlines.
+	ErrorClassTimeout = "timeout"
+
+	// ErrorClassReset covers connections reset or aborted by the peer.
+	ErrorClassReset = "reset"
+
+	// ErrorClassEOF covers io.EOF.
+	ErrorClassEOF = "eof"
+
+	// ErrorClassOther covers all other errors.
+	ErrorClassOther = "other"
+)
+
+// errorCounts is a histogram of errors returned by the underlying net.Conn.
+type errorCounts struct {
+	timeout, reset, eof, other atomic.Uint64
+}
+
+// record classifies err and bumps the relevant counter. Nil errors are
+// ignored.
+func (ec *errorCounts) record(err error) {
+	if err == nil {
+		return
+	}
+	var ne net.Error
+	switch {
+	case errors.Is(err, io.EOF):
+		ec.eof.Add(1)
+	case errors.As(err, &ne) && ne.Timeout():
+		ec.timeout.Add(1)
+	case errors.Is(err, syscall.ECONNRESET), errors.Is(err, syscall.ECONNABORTED):
+		ec.reset.Add(1)
+	default:
+		ec.other.Add(1)
+	}
+}
+
+// ErrorCounts returns the number of errors returned by the underlying net.Conn
+// from Read, ReadFrom, Write and WriteTo, bucketed by class (see the
+// ErrorClass constants). Errors returned by hooks are not counted.
+func (c *Conn) ErrorCounts() map[string]uint64 {
+	return map[string]uint64{
+		ErrorClassTimeout: c.errorCounts.timeout.Load(),
+		ErrorClassReset:   c.errorCounts.reset.Load(),
+		ErrorClassEOF:     c.errorCounts.eof.Load(),
+		ErrorClassOther:   c.errorCounts.other.Load(),
+	}
+}
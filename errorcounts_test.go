@@ -0,0 +1,69 @@
+package connxray
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"reflect"
+	"syscall"
+	"testing"
+)
+
+func TestErrorCounts(t *testing.T) {
+	errs := []error{
+		nil,
+		io.EOF,
+		os.ErrDeadlineExceeded,
+		&net.OpError{Op: "read", Err: os.NewSyscallError("read", syscall.ECONNRESET)},
+		fmt.Errorf("wrapped: %w", io.EOF),
+		errors.New("chunky bacon"),
+		syscall.ECONNABORTED,
+	}
+	next := func() error {
+		err := errs[0]
+		errs = errs[1:]
+		return err
+	}
+	mc := &mockConn{
+		readHandler: func(_ []byte) (int, error) {
+			return 0, next()
+		},
+		writeHandler: func(_ []byte) (int, error) {
+			return 0, next()
+		},
+		readFromHandler: func(_ []byte) (int, net.Addr, error) {
+			return 0, nil, next()
+		},
+		writeToHandler: func(_ []byte, _ net.Addr) (int, error) {
+			return 0, next()
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeRead: func(_ *Conn, _ []byte) error {
+			if len(errs) == 0 {
+				return io.EOF
+			}
+			return nil
+		},
+	}
+	cc.Read(nil)
+	cc.Write(nil)
+	cc.ReadFrom(nil)
+	cc.WriteTo(nil, nil)
+	cc.Read(nil)
+	cc.Write(nil)
+	cc.Read(nil)
+	cc.Read(nil) // Fails in the before hook, must not be counted.
+	exp := map[string]uint64{
+		ErrorClassTimeout: 1,
+		ErrorClassReset:   2,
+		ErrorClassEOF:     2,
+		ErrorClassOther:   1,
+	}
+	if got := cc.ErrorCounts(); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Unexpected error counts %v, expected %v", got, exp)
+	}
+}
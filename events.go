@@ -0,0 +1,97 @@
+package connxray
+
+import (
+	"net"
+	"reflect"
+	"sync"
+	"time"
+)
+
+// EventKind identifies the operation recorded in an Event.
+type EventKind string
+
+// Kinds of events recorded in a Conn's event log.
+const (
+	EventAccept           EventKind = "accept"
+	EventRead             EventKind = "read"
+	EventReadFrom         EventKind = "readfrom"
+	EventWrite            EventKind = "write"
+	EventWriteTo          EventKind = "writeto"
+	EventSetDeadline      EventKind = "setdeadline"
+	EventSetReadDeadline  EventKind = "setreaddeadline"
+	EventSetWriteDeadline EventKind = "setwritedeadline"
+	EventClose            EventKind = "close"
+)
+
+// Event is a single entry in a Conn's event log.
+type Event struct {
+	// Kind of the operation.
+	Kind EventKind
+
+	// Time when the operation completed.
+	Time time.Time
+
+	// N is the number of bytes transferred by reads and writes.
+	N int
+
+	// Addr is the peer address for EventReadFrom and EventWriteTo. It is nil
+	// (rather than a nil pointer, eg. a nil *net.UDPAddr) if the underlying
+	// net.Conn reported none.
+	Addr net.Addr
+
+	// Deadline is the deadline set by the deadline events.
+	Deadline time.Time
+
+	// Err is the error returned by the underlying net.Conn, if any.
+	Err error
+}
+
+// eventLog is an append-only, optionally capped, log of events.
+type eventLog struct {
+	mu      sync.Mutex
+	events  []Event
+	dropped uint64
+}
+
+// recordEvent appends e to the event log if RecordEvents is set. Once
+// MaxEvents events are recorded further ones are dropped and counted.
+func (c *Conn) recordEvent(e Event) {
+	if !c.RecordEvents {
+		return
+	}
+	e.Time = time.Now()
+	e.Addr = eventAddr(e.Addr)
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+	if c.MaxEvents > 0 && len(c.events.events) >= c.MaxEvents {
+		c.events.dropped++
+		return
+	}
+	c.events.events = append(c.events.events, e)
+}
+
+// eventAddr returns addr, or nil if it holds a nil pointer.
+func eventAddr(addr net.Addr) net.Addr {
+	if v := reflect.ValueOf(addr); v.Kind() == reflect.Pointer && v.IsNil() {
+		return nil
+	}
+	return addr
+}
+
+// EventLog returns a copy of all events recorded so far, in the order in which
+// they happened. Only operations performed on the underlying net.Conn are
+// recorded, so calls rejected by a 'before' hook do not show up. Events are
+// only recorded while RecordEvents is set.
+func (c *Conn) EventLog() []Event {
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+	return append([]Event(nil), c.events.events...)
+}
+
+// DroppedEvents returns the number of events which were not recorded because
+// the event log reached MaxEvents.
+func (c *Conn) DroppedEvents() uint64 {
+	c.events.mu.Lock()
+	defer c.events.mu.Unlock()
+	return c.events.dropped
+}
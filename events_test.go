@@ -0,0 +1,163 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+// withoutTimes returns events with their timestamps zeroed, checking that the
+// timestamps are set and monotonic along the way.
+func withoutTimes(t *testing.T, events []Event) []Event {
+	var last time.Time
+	ret := make([]Event, len(events))
+	for i, e := range events {
+		if e.Time.IsZero() || e.Time.Before(last) {
+			t.Errorf("Unexpected time %v of event %d", e.Time, i)
+		}
+		last = e.Time
+		e.Time = time.Time{}
+		ret[i] = e
+	}
+	return ret
+}
+
+func TestEventLog(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	addr := &net.UDPAddr{Port: 53}
+	deadline := time.Now().Add(time.Minute)
+	mc := &mockConn{
+		readHandler: func(_ []byte) (int, error) {
+			return 3, nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		readFromHandler: func(_ []byte) (int, net.Addr, error) {
+			return 0, nil, io.EOF
+		},
+		writeToHandler: func(b []byte, _ net.Addr) (int, error) {
+			return 0, expErr
+		},
+		setDeadlineHandler: func(_ time.Time) error {
+			return nil
+		},
+		setReadDeadlineHandler: func(_ time.Time) error {
+			return nil
+		},
+		setWriteDeadlineHandler: func(_ time.Time) error {
+			return nil
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return mc, nil
+		},
+	}
+	cl := &Listener{Base: ml, RecordConnEvents: true}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cc := nc.(*Conn)
+	cc.BeforeWrite = func(_ *Conn, b []byte) error {
+		if len(b) == 0 {
+			return expErr
+		}
+		return nil
+	}
+	cc.Read(make([]byte, 8))
+	cc.Write([]byte("hello"))
+	cc.Write(nil) // Rejected by the before hook, must not be recorded.
+	cc.SetDeadline(deadline)
+	cc.SetReadDeadline(deadline)
+	cc.SetWriteDeadline(time.Time{})
+	cc.ReadFrom(make([]byte, 8))
+	cc.WriteTo([]byte("hi"), addr)
+	cc.Close()
+	exp := []Event{
+		{Kind: EventAccept},
+		{Kind: EventRead, N: 3},
+		{Kind: EventWrite, N: 5},
+		{Kind: EventSetDeadline, Deadline: deadline},
+		{Kind: EventSetReadDeadline, Deadline: deadline},
+		{Kind: EventSetWriteDeadline},
+		{Kind: EventReadFrom, Err: io.EOF},
+		{Kind: EventWriteTo, Addr: addr, Err: expErr},
+		{Kind: EventClose},
+	}
+	if got := withoutTimes(t, cc.EventLog()); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Unexpected event log %+v, expected %+v", got, exp)
+	}
+}
+
+func TestEventLogCap(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, RecordEvents: true, MaxEvents: 2}
+	for i := 1; i <= 5; i++ {
+		cc.Read(make([]byte, i))
+	}
+	exp := []Event{{Kind: EventRead, N: 1}, {Kind: EventRead, N: 2}}
+	if got := withoutTimes(t, cc.EventLog()); !reflect.DeepEqual(got, exp) {
+		t.Errorf("Unexpected event log %+v, expected %+v", got, exp)
+	}
+	if n := cc.DroppedEvents(); n != 3 {
+		t.Errorf("Unexpected dropped events %d, expected 3", n)
+	}
+}
+
+func TestEventLogDisabled(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	cc.Read(make([]byte, 1))
+	if events := cc.EventLog(); len(events) != 0 {
+		t.Errorf("Unexpected event log %+v, expected none", events)
+	}
+}
+
+func TestEventLogNilAddr(t *testing.T) {
+	mc := &mockUDPConn{
+		mockConn: mockConn{
+			readFromHandler: func(b []byte) (int, net.Addr, error) {
+				return 0, (*net.UDPAddr)(nil), io.EOF
+			},
+			writeToHandler: func(b []byte, _ net.Addr) (int, error) {
+				return len(b), nil
+			},
+		},
+		readMsgUDPHandler: func([]byte, []byte) (int, int, int, *net.UDPAddr, error) {
+			return 0, 0, 0, nil, io.EOF
+		},
+		writeMsgUDPHandler: func(b, _ []byte, _ *net.UDPAddr) (int, int, error) {
+			return len(b), 0, nil
+		},
+	}
+	cc := &Conn{Base: mc, RecordEvents: true}
+	cc.ReadFrom(nil)
+	cc.WriteTo(nil, (*net.UDPAddr)(nil))
+	cc.ReadMsgUDP(nil, nil)
+	cc.WriteMsgUDP(nil, nil, nil)
+	events := cc.EventLog()
+	if len(events) != 4 {
+		t.Fatalf("Unexpected event log %+v, expected 4 events", events)
+	}
+	for _, event := range events {
+		if event.Addr != nil {
+			t.Errorf("Unexpected address %#v in %s event, expected nil", event.Addr, event.Kind)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package connxray_test
+
+import (
+	"errors"
+	"fmt"
+	"net"
+
+	"github.com/marcinwyszynski/connxray"
+)
+
+// This example wraps a UDP socket, which implements net.PacketConn, and
+// inspects datagrams sent and received through it. The same hooks attached to
+// a TCP connection observe ErrNotPacketConn instead.
+func Example_packetConn() {
+	hooks := &connxray.Conn{
+		BeforeReadFrom: func(_ *connxray.Conn, b []byte) error {
+			fmt.Printf("reading up to %d bytes\n", len(b))
+			return nil
+		},
+		AfterReadFrom: func(_ *connxray.Conn, b []byte, n int, _ net.Addr, err error) {
+			fmt.Printf("read %q, error: %v\n", b[:n], err)
+		},
+		AfterWriteTo: func(_ *connxray.Conn, _ []byte, _ net.Addr, n int, err error) {
+			fmt.Printf("wrote %d bytes, error: %v\n", n, err)
+		},
+	}
+
+	udp, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	conn := connxray.NewConn(udp.(net.Conn), connxray.WithTemplate(hooks))
+	defer conn.Close()
+
+	// Send a datagram to ourselves and read it back.
+	if _, err := conn.WriteTo([]byte("ping"), conn.LocalAddr()); err != nil {
+		panic(err)
+	}
+	buf := make([]byte, 16)
+	if _, _, err := conn.ReadFrom(buf); err != nil {
+		panic(err)
+	}
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		panic(err)
+	}
+	defer ln.Close()
+	tcp, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		panic(err)
+	}
+	stream := connxray.NewConn(tcp, connxray.WithTemplate(hooks))
+	defer stream.Close()
+	_, err = stream.WriteTo([]byte("ping"), ln.Addr())
+	fmt.Println(errors.Is(err, connxray.ErrNotPacketConn))
+
+	// Output:
+	// wrote 4 bytes, error: <nil>
+	// reading up to 16 bytes
+	// read "ping", error: <nil>
+	// wrote 0 bytes, error: this net.Conn is not a net.PacketConn
+	// true
+}
@@ -1,6 +1,7 @@
 // In this example HTTP traffic is inspected by introspecting on an underlying
-// TCP acceptor. By injecting callbacks on Accept, Read, Write and Close we can
-// track stats for each individual connection as it changes state.
+// TCP acceptor. By injecting callbacks on Accept and Close and enabling
+// built-in traffic counters we can track stats for each individual connection
+// as it changes state.
 //
 // This is only one possible use case of the connxray library.
 package main
@@ -10,7 +11,6 @@ import (
 	"fmt"
 	"net"
 	"net/http"
-	"time"
 
 	"github.com/golang/glog"
 	xray "github.com/marcinwyszynski/connxray"
@@ -20,51 +20,26 @@ var (
 	port = flag.Int("port", 1983, "HTTP port")
 )
 
-type ReadCallback func(*xray.Conn, []byte, int, error)
-type WriteCallback func(*xray.Conn, []byte, int, error)
-type CloseCallback func(*xray.Conn, error)
-
-type stats struct {
-	bytesRead    int
-	bytesWritten int
-	startTime    time.Time
-}
-
 func onAccept(_ *xray.Listener, conn *xray.Conn, err error) {
-	s := &stats{startTime: time.Now()}
-	conn.AfterRead = onRead(s)
-	conn.AfterWrite = onWrite(s)
-	conn.AfterClose = onClose(s)
 	if err != nil {
 		glog.Errorf("Error establishing connection: %v", err)
 		return
 	}
+	conn.TrackStats = true
+	conn.AfterClose = onClose
 	glog.Infof("%s <-> %s started", conn.LocalAddr(), conn.RemoteAddr())
 }
 
-func onRead(s *stats) ReadCallback {
-	return func(_ *xray.Conn, _ []byte, n int, _ error) {
-		s.bytesRead += n
-	}
-}
-
-func onWrite(s *stats) WriteCallback {
-	return func(_ *xray.Conn, _ []byte, n int, _ error) {
-		s.bytesWritten += n
-	}
-}
-
-func onClose(s *stats) CloseCallback {
-	return func(conn *xray.Conn, _ error) {
-		msg := "%s closed: %d bytes read, %d bytes written in %d ms"
-		glog.Infof(
-			msg,
-			conn.RemoteAddr(),
-			s.bytesRead,
-			s.bytesWritten,
-			time.Since(s.startTime)/1e6,
-		)
-	}
+func onClose(conn *xray.Conn, _ error) {
+	stats := conn.Stats()
+	msg := "%s closed: %d bytes read, %d bytes written in %d ms"
+	glog.Infof(
+		msg,
+		conn.RemoteAddr(),
+		stats.BytesRead,
+		stats.BytesWritten,
+		stats.Age.Milliseconds(),
+	)
 }
 
 func main() {
@@ -0,0 +1,42 @@
+package connxray
+
+import (
+	"sync/atomic"
+	"time"
+)
+
+// The functions below build hooks which inject faults, for chaos testing code
+// using a Conn. Each call returns a hook with its own state, which is shared by
+// all Conns the hook is set on (eg. through Listener.ConnTemplate).
+
+// FailReadAfter returns a BeforeRead hook which lets the first n reads through
+// and makes all subsequent ones fail with err. It is safe for concurrent use.
+func FailReadAfter(n int, err error) func(*Conn, []byte) error {
+	var reads atomic.Int64
+	return func(*Conn, []byte) error {
+		if reads.Add(1) > int64(n) {
+			return err
+		}
+		return nil
+	}
+}
+
+// DelayWrite returns a BeforeWrite hook which delays every write by d.
+func DelayWrite(d time.Duration) func(*Conn, []byte) error {
+	return func(*Conn, []byte) error {
+		time.Sleep(d)
+		return nil
+	}
+}
+
+// TruncateRead returns a TransformRead hook which makes reads return at most
+// max bytes. Note that any bytes read from the underlying net.Conn past max are
+// discarded, so on top of short reads it simulates data loss.
+func TruncateRead(max int) func(*Conn, []byte, int, error) (int, error) {
+	return func(_ *Conn, _ []byte, n int, err error) (int, error) {
+		if n > max {
+			n = max
+		}
+		return n, err
+	}
+}
@@ -0,0 +1,62 @@
+package connxray
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestFailReadAfter(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, BeforeRead: FailReadAfter(3, expErr)}
+	buf := make([]byte, 4)
+	for i := 0; i < 3; i++ {
+		if n, err := cc.Read(buf); n != 4 || err != nil {
+			t.Errorf("Unexpected results (%d, %v) of read %d, expected (4, nil)", n, err, i+1)
+		}
+	}
+	for i := 0; i < 2; i++ {
+		if n, err := cc.Read(buf); n != 0 || !errors.Is(err, expErr) {
+			t.Errorf("Unexpected results (%d, %v), expected (0, %v)", n, err, expErr)
+		}
+	}
+}
+
+func TestDelayWrite(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, BeforeWrite: DelayWrite(20 * time.Millisecond)}
+	start := time.Now()
+	if _, err := cc.Write([]byte("foo")); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if elapsed := time.Since(start); elapsed < 20*time.Millisecond {
+		t.Errorf("Unexpected write duration %v, expected at least 20ms", elapsed)
+	}
+}
+
+func TestTruncateRead(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "chunky bacon"), nil
+		},
+	}
+	cc := &Conn{Base: mc, TransformRead: TruncateRead(6)}
+	buf := make([]byte, 12)
+	n, err := cc.Read(buf)
+	if n != 6 || err != nil || string(buf[:n]) != "chunky" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"chunky\", nil)", buf[:n], err)
+	}
+	cc.TransformRead = TruncateRead(100)
+	if n, _ := cc.Read(buf); n != 12 {
+		t.Errorf("Unexpected number of bytes read %d, expected 12", n)
+	}
+}
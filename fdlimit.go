@@ -0,0 +1,72 @@
+package connxray
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// errFDUsageUnsupported is returned by fdUsage on platforms where the number of
+// open file descriptors cannot be determined.
+var errFDUsageUnsupported = errors.New("file descriptor usage is not available on this platform")
+
+// fdSampleInterval is how long a sample of the file descriptor usage is reused
+// by Accept before taking a new one, since counting them is not cheap.
+const fdSampleInterval = 100 * time.Millisecond
+
+// fdCount and fdLimit return the number of file descriptors currently open in
+// this process and the soft limit on their number. Tests replace them.
+var (
+	fdCount = openFDs
+	fdLimit = softFDLimit
+)
+
+// fdUsage returns the number of file descriptors currently open in this
+// process and the soft limit on their number.
+func fdUsage() (used, limit uint64, err error) {
+	if limit, err = fdLimit(); err != nil {
+		return 0, 0, err
+	}
+	if used, err = fdCount(); err != nil {
+		return 0, 0, err
+	}
+	return used, limit, nil
+}
+
+// fdSampler caches the result of fdUsage for fdSampleInterval.
+type fdSampler struct {
+	mu          sync.Mutex
+	at          time.Time
+	used, limit uint64
+	err         error
+}
+
+// sample returns the file descriptor usage, taking a new sample if the last
+// one is older than fdSampleInterval.
+func (s *fdSampler) sample() (used, limit uint64, err error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if now := timeNow(); s.at.IsZero() || now.Sub(s.at) >= fdSampleInterval {
+		s.used, s.limit, s.err = fdUsage()
+		s.at = now
+	}
+	return s.used, s.limit, s.err
+}
+
+// underFDPressure tells whether the process has fewer than FDHeadroom file
+// descriptors left before hitting its limit, and fires the OnFDPressure hook
+// if so. It always returns false if FDHeadroom is not set or if fd usage can't
+// be determined on this platform.
+func (l *Listener) underFDPressure() bool {
+	if l.FDHeadroom == 0 {
+		return false
+	}
+	used, limit, err := l.fdSampler.sample()
+	if err != nil || used+l.FDHeadroom <= limit {
+		return false
+	}
+	if l.OnFDPressure != nil {
+		l.guard("OnFDPressure", func() { l.OnFDPressure(l, used, limit) })
+	}
+	return true
+}
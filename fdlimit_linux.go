@@ -0,0 +1,25 @@
+package connxray
+
+import (
+	"os"
+	"syscall"
+)
+
+// openFDs returns the number of file descriptors currently open in this
+// process.
+func openFDs() (uint64, error) {
+	entries, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		return 0, err
+	}
+	return uint64(len(entries)), nil
+}
+
+// softFDLimit returns the soft limit on the number of open file descriptors.
+func softFDLimit() (uint64, error) {
+	var rlimit syscall.Rlimit
+	if err := syscall.Getrlimit(syscall.RLIMIT_NOFILE, &rlimit); err != nil {
+		return 0, err
+	}
+	return rlimit.Cur, nil
+}
@@ -0,0 +1,15 @@
+package connxray
+
+import (
+	"testing"
+)
+
+func TestFDUsage(t *testing.T) {
+	used, limit, err := fdUsage()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if used == 0 || used > limit {
+		t.Errorf("Unexpected fd usage %d/%d", used, limit)
+	}
+}
@@ -0,0 +1,13 @@
+//go:build !linux
+
+package connxray
+
+// openFDs is not supported on this platform.
+func openFDs() (uint64, error) {
+	return 0, errFDUsageUnsupported
+}
+
+// softFDLimit is not supported on this platform.
+func softFDLimit() (uint64, error) {
+	return 0, errFDUsageUnsupported
+}
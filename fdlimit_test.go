@@ -0,0 +1,131 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// withFDUsage makes fdUsage report used open file descriptors out of limit for
+// the duration of a test. It returns the number of times the descriptors were
+// counted.
+func withFDUsage(t *testing.T, used, limit uint64) *atomic.Int64 {
+	var counted atomic.Int64
+	origCount, origLimit := fdCount, fdLimit
+	fdCount = func() (uint64, error) {
+		counted.Add(1)
+		return used, nil
+	}
+	fdLimit = func() (uint64, error) {
+		return limit, nil
+	}
+	t.Cleanup(func() { fdCount, fdLimit = origCount, origLimit })
+	return &counted
+}
+
+func TestAcceptShedsLoadUnderFDPressure(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	shedConn, closed, calls := &mockConn{}, false, 0
+	shedConn.closeHandler = func() error {
+		closed = true
+		return nil
+	}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			calls++
+			if calls == 1 {
+				return shedConn, nil
+			}
+			return nil, expErr
+		},
+	}
+	pressure := false
+	cl := &Listener{
+		Base:       ml,
+		FDHeadroom: 10,
+		OnFDPressure: func(_ *Listener, used, limit uint64) {
+			if used != 95 || limit != 100 {
+				t.Errorf("Unexpected fd usage %d/%d, expected 95/100", used, limit)
+			}
+			pressure = true
+		},
+	}
+	withFDUsage(t, 95, 100)
+	if _, err := cl.Accept(); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if !closed {
+		t.Error("Shed connection not closed")
+	}
+	if !pressure {
+		t.Error("Pressure callback not invoked")
+	}
+	if calls != 2 {
+		t.Errorf("Unexpected number of base Accept calls %d, expected 2", calls)
+	}
+}
+
+func TestAcceptWithFDHeadroom(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{}, nil
+		},
+	}
+	cl := &Listener{
+		Base:       ml,
+		FDHeadroom: 10,
+		OnFDPressure: func(_ *Listener, _, _ uint64) {
+			t.Error("Pressure callback invoked")
+		},
+	}
+	withFDUsage(t, 50, 100)
+	if _, err := cl.Accept(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
+
+func TestFDUsageSampled(t *testing.T) {
+	advance := fakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	counted := withFDUsage(t, 50, 100)
+	cl := &Listener{FDHeadroom: 10}
+	for i := 0; i < 3; i++ {
+		cl.underFDPressure()
+	}
+	if n := counted.Load(); n != 1 {
+		t.Errorf("Unexpected number of fd counts %d, expected 1", n)
+	}
+	advance(fdSampleInterval)
+	cl.underFDPressure()
+	if n := counted.Load(); n != 2 {
+		t.Errorf("Unexpected number of fd counts %d, expected 2", n)
+	}
+}
+
+func TestAcceptBacksOffUnderFDPressure(t *testing.T) {
+	var calls atomic.Int64
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			calls.Add(1)
+			return &mockConn{closeHandler: func() error { return nil }}, nil
+		},
+		closeHandler: func() error { return nil },
+	}
+	cl := &Listener{Base: ml, FDHeadroom: 10}
+	withFDUsage(t, 95, 100)
+	done := make(chan error)
+	go func() {
+		_, err := cl.Accept()
+		done <- err
+	}()
+	// Sheds happen after 0, 5, 15, 35, 75 and 155ms.
+	time.Sleep(100 * time.Millisecond)
+	cl.Close()
+	if err := <-done; err != ErrListenerClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrListenerClosed)
+	}
+	if n := calls.Load(); n < 2 || n > 8 {
+		t.Errorf("Unexpected number of base Accept calls %d, expected about 5", n)
+	}
+}
@@ -0,0 +1,59 @@
+package connxray
+
+import (
+	"errors"
+	"net/netip"
+)
+
+var (
+	// ErrPeerNotAllowed is returned by validators created with AllowPrefixes
+	// and DenyPrefixes for connections from disallowed remote addresses.
+	ErrPeerNotAllowed = errors.New("connxray: remote address not allowed")
+)
+
+// AllowPrefixes returns a function suitable for Listener.ValidateConn which
+// rejects connections whose remote IP address is not within any of prefixes
+// (eg. netip.MustParsePrefix("10.0.0.0/8")), including connections whose
+// remote IP address is unknown. Rejected connections are closed before they
+// are returned from Accept and reported to OnInvalidConn with
+// ErrPeerNotAllowed.
+func AllowPrefixes(prefixes ...netip.Prefix) func(*Conn) error {
+	return func(c *Conn) error {
+		if addr, ok := remoteNetipAddr(c); ok && containsAddr(prefixes, addr) {
+			return nil
+		}
+		return ErrPeerNotAllowed
+	}
+}
+
+// DenyPrefixes returns a function suitable for Listener.ValidateConn which
+// rejects connections whose remote IP address is within any of prefixes.
+// Connections whose remote IP address is unknown are let through.
+func DenyPrefixes(prefixes ...netip.Prefix) func(*Conn) error {
+	return func(c *Conn) error {
+		if addr, ok := remoteNetipAddr(c); ok && containsAddr(prefixes, addr) {
+			return ErrPeerNotAllowed
+		}
+		return nil
+	}
+}
+
+// remoteNetipAddr returns the remote IP address of c, with IPv4-mapped IPv6
+// addresses unmapped.
+func remoteNetipAddr(c *Conn) (netip.Addr, bool) {
+	addr, err := netip.ParseAddr(remoteIP(c.RemoteAddr()))
+	if err != nil {
+		return netip.Addr{}, false
+	}
+	return addr.Unmap(), true
+}
+
+// containsAddr tells whether addr is within any of prefixes.
+func containsAddr(prefixes []netip.Prefix, addr netip.Addr) bool {
+	for _, prefix := range prefixes {
+		if prefix.Contains(addr) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,71 @@
+package connxray
+
+import (
+	"net"
+	"net/netip"
+	"testing"
+)
+
+// connFrom returns a Conn whose remote address is addr.
+func connFrom(addr net.Addr) *Conn {
+	return &Conn{Base: &mockConn{remoteAddrHandler: func() net.Addr { return addr }}}
+}
+
+func TestAllowPrefixes(t *testing.T) {
+	allow := AllowPrefixes(netip.MustParsePrefix("10.0.0.0/8"), netip.MustParsePrefix("fd00::/8"))
+	for _, tc := range []struct {
+		addr net.Addr
+		exp  error
+	}{
+		{&net.TCPAddr{IP: net.IPv4(10, 1, 2, 3)}, nil},
+		{&net.TCPAddr{IP: net.ParseIP("::ffff:10.1.2.3")}, nil},
+		{&net.TCPAddr{IP: net.ParseIP("fd00::1")}, nil},
+		{&net.TCPAddr{IP: net.IPv4(192, 168, 0, 1)}, ErrPeerNotAllowed},
+		{nil, ErrPeerNotAllowed},
+	} {
+		if err := allow(connFrom(tc.addr)); err != tc.exp {
+			t.Errorf("Unexpected error %v for %v, expected %v", err, tc.addr, tc.exp)
+		}
+	}
+}
+
+func TestDenyPrefixes(t *testing.T) {
+	deny := DenyPrefixes(netip.MustParsePrefix("192.168.0.0/16"))
+	for _, tc := range []struct {
+		addr net.Addr
+		exp  error
+	}{
+		{&net.TCPAddr{IP: net.IPv4(192, 168, 1, 1)}, ErrPeerNotAllowed},
+		{&net.TCPAddr{IP: net.IPv4(10, 0, 0, 1)}, nil},
+		{nil, nil},
+	} {
+		if err := deny(connFrom(tc.addr)); err != tc.exp {
+			t.Errorf("Unexpected error %v for %v, expected %v", err, tc.addr, tc.exp)
+		}
+	}
+}
+
+func TestAcceptWithDenyPrefixes(t *testing.T) {
+	ml := addrListener("192.168.0.1:1", "10.0.0.1:1")
+	var rejected []net.Addr
+	cl := &Listener{
+		Base:         ml,
+		ValidateConn: DenyPrefixes(netip.MustParsePrefix("192.168.0.0/16")),
+		OnInvalidConn: func(_ *Listener, conn *Conn, err error) {
+			if err != ErrPeerNotAllowed {
+				t.Errorf("Unexpected error %v, expected %v", err, ErrPeerNotAllowed)
+			}
+			rejected = append(rejected, conn.RemoteAddr())
+		},
+	}
+	conn, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if addr := conn.RemoteAddr().String(); addr != "10.0.0.1:1" {
+		t.Errorf("Unexpected remote address %s, expected 10.0.0.1:1", addr)
+	}
+	if len(rejected) != 1 || rejected[0].String() != "192.168.0.1:1" {
+		t.Errorf("Unexpected rejected addresses %v, expected [192.168.0.1:1]", rejected)
+	}
+}
@@ -0,0 +1,79 @@
+package connxray
+
+import (
+	"encoding/binary"
+	"errors"
+	"io"
+)
+
+var (
+	// ErrFrameTooLarge signifies that a length prefix announced a frame larger
+	// than the configured maximum.
+	ErrFrameTooLarge = errors.New("frame exceeds the maximum frame size")
+
+	// ErrInvalidPrefixSize signifies that a Framer was configured with a
+	// length prefix size other than 1, 2, 4 or 8 bytes.
+	ErrInvalidPrefixSize = errors.New("length prefix must be 1, 2, 4 or 8 bytes")
+)
+
+// Framer reads length-prefixed frames from a Conn. All reads go through
+// Conn.Read so Read hooks fire for every underlying read, not once per frame.
+type Framer struct {
+	// Conn to read frames from.
+	Conn *Conn
+
+	// PrefixSize is the size of the length prefix in bytes: 1, 2, 4 or 8.
+	// Zero means 4.
+	PrefixSize int
+
+	// ByteOrder of the length prefix. Nil means binary.BigEndian.
+	ByteOrder binary.ByteOrder
+
+	// MaxFrameSize is the largest frame (excluding the prefix) that will be
+	// accepted. Zero means no limit.
+	MaxFrameSize uint64
+}
+
+// ReadFrame reads exactly one frame and returns its payload. If the prefix
+// announces a frame larger than MaxFrameSize ErrFrameTooLarge is returned
+// without reading the payload. If the connection ends in the middle of a frame
+// io.ErrUnexpectedEOF is returned.
+func (f *Framer) ReadFrame() ([]byte, error) {
+	size := f.PrefixSize
+	if size == 0 {
+		size = 4
+	}
+	order := f.ByteOrder
+	if order == nil {
+		order = binary.BigEndian
+	}
+	if size != 1 && size != 2 && size != 4 && size != 8 {
+		return nil, ErrInvalidPrefixSize
+	}
+	var prefix [8]byte
+	if _, err := io.ReadFull(f.Conn, prefix[:size]); err != nil {
+		return nil, err
+	}
+	var length uint64
+	switch size {
+	case 1:
+		length = uint64(prefix[0])
+	case 2:
+		length = uint64(order.Uint16(prefix[:2]))
+	case 4:
+		length = uint64(order.Uint32(prefix[:4]))
+	case 8:
+		length = order.Uint64(prefix[:8])
+	}
+	if f.MaxFrameSize > 0 && length > f.MaxFrameSize {
+		return nil, ErrFrameTooLarge
+	}
+	frame := make([]byte, length)
+	if _, err := io.ReadFull(f.Conn, frame); err != nil {
+		if err == io.EOF {
+			err = io.ErrUnexpectedEOF
+		}
+		return nil, err
+	}
+	return frame, nil
+}
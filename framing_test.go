@@ -0,0 +1,95 @@
+package connxray
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"testing"
+)
+
+// chunkedConn returns a mockConn which serves data in reads of at most chunk
+// bytes.
+func chunkedConn(data []byte, chunk int) *mockConn {
+	r := bytes.NewReader(data)
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			if len(b) > chunk {
+				b = b[:chunk]
+			}
+			return r.Read(b)
+		},
+	}
+}
+
+func TestReadFrame(t *testing.T) {
+	data := []byte{0, 5, 'h', 'e', 'l', 'l', 'o', 0, 2, 'h', 'i'}
+	reads := 0
+	f := &Framer{
+		Conn: &Conn{
+			Base: chunkedConn(data, 3),
+			AfterRead: func(_ *Conn, _ []byte, _ int, _ error) {
+				reads++
+			},
+		},
+		PrefixSize: 2,
+	}
+	for _, exp := range []string{"hello", "hi"} {
+		frame, err := f.ReadFrame()
+		if err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+		if string(frame) != exp {
+			t.Errorf("Unexpected frame %q, expected %q", frame, exp)
+		}
+	}
+	if reads < 4 {
+		t.Errorf("Unexpected number of reads %d, expected at least 4", reads)
+	}
+	if _, err := f.ReadFrame(); err != io.EOF {
+		t.Errorf("Unexpected error %v, expected %v", err, io.EOF)
+	}
+}
+
+func TestReadFrameLittleEndian(t *testing.T) {
+	data := []byte{3, 0, 0, 0, 'a', 'b', 'c'}
+	f := &Framer{
+		Conn:      &Conn{Base: chunkedConn(data, len(data))},
+		ByteOrder: binary.LittleEndian,
+	}
+	frame, err := f.ReadFrame()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if string(frame) != "abc" {
+		t.Errorf("Unexpected frame %q, expected %q", frame, "abc")
+	}
+}
+
+func TestReadFrameTooLarge(t *testing.T) {
+	data := []byte{0, 0, 0, 10, 'a', 'b', 'c'}
+	f := &Framer{
+		Conn:         &Conn{Base: chunkedConn(data, len(data))},
+		MaxFrameSize: 5,
+	}
+	if _, err := f.ReadFrame(); err != ErrFrameTooLarge {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrFrameTooLarge)
+	}
+}
+
+func TestReadFrameTruncated(t *testing.T) {
+	data := []byte{0, 0, 0, 5, 'a', 'b'}
+	f := &Framer{Conn: &Conn{Base: chunkedConn(data, len(data))}}
+	if _, err := f.ReadFrame(); err != io.ErrUnexpectedEOF {
+		t.Errorf("Unexpected error %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+}
+
+func TestReadFrameInvalidPrefixSize(t *testing.T) {
+	f := &Framer{
+		Conn:       &Conn{Base: chunkedConn(nil, 1)},
+		PrefixSize: 3,
+	}
+	if _, err := f.ReadFrame(); err != ErrInvalidPrefixSize {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrInvalidPrefixSize)
+	}
+}
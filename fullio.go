@@ -0,0 +1,75 @@
+package connxray
+
+import (
+	"io"
+	"os"
+	"time"
+)
+
+// maxEmptyReads is the number of consecutive reads returning neither data nor
+// an error after which ReadFull gives up with io.ErrNoProgress, like
+// bufio.Reader does.
+const maxEmptyReads = 100
+
+// ReadFull reads exactly len(b) bytes from the connection, like io.ReadFull,
+// calling Read as many times as needed. Hooks of Read fire for every
+// individual call, while AfterReadFull fires once with the total. The error
+// is io.EOF only if no bytes were read, and io.ErrUnexpectedEOF if the
+// connection ended after some but not all of the bytes were read. Once the
+// read deadline (see Deadlines) has passed ReadFull fails with a timeout
+// rather than calling Read again.
+func (c *Conn) ReadFull(b []byte) (n int, err error) {
+	for empty := 0; n < len(b) && err == nil; {
+		if deadline, _ := c.Deadlines(); deadlinePassed(deadline) {
+			err = os.ErrDeadlineExceeded
+			break
+		}
+		var nn int
+		nn, err = c.Read(b[n:])
+		n += nn
+		if nn > 0 || err != nil {
+			empty = 0
+		} else if empty++; empty >= maxEmptyReads {
+			err = io.ErrNoProgress
+		}
+	}
+	if n == len(b) {
+		err = nil
+	} else if n > 0 && err == io.EOF {
+		err = io.ErrUnexpectedEOF
+	}
+	if hook := c.AfterReadFullHook(); hook != nil {
+		hook(c, n, err)
+	}
+	return n, err
+}
+
+// WriteAll writes all of b to the connection, calling Write until it is
+// drained or fails. Hooks of Write fire for every individual call, while
+// AfterWriteAll fires once with the total. A Write making no progress without
+// an error (eg. due to a TransformWrite hook) makes WriteAll fail with
+// io.ErrShortWrite. Once the write deadline has passed WriteAll fails with a
+// timeout rather than calling Write again.
+func (c *Conn) WriteAll(b []byte) (n int, err error) {
+	for n < len(b) && err == nil {
+		if _, deadline := c.Deadlines(); deadlinePassed(deadline) {
+			err = os.ErrDeadlineExceeded
+			break
+		}
+		var nn int
+		nn, err = c.Write(b[n:])
+		n += nn
+		if nn == 0 && err == nil {
+			err = io.ErrShortWrite
+		}
+	}
+	if hook := c.AfterWriteAllHook(); hook != nil {
+		hook(c, n, err)
+	}
+	return n, err
+}
+
+// deadlinePassed tells whether the deadline t is set and in the past.
+func deadlinePassed(t time.Time) bool {
+	return !t.IsZero() && !time.Now().Before(t)
+}
@@ -0,0 +1,153 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"os"
+	"testing"
+	"time"
+)
+
+// chunkReader returns a read handler serving chunks one per call, followed by
+// err.
+func chunkReader(err error, chunks ...string) func([]byte) (int, error) {
+	return func(b []byte) (int, error) {
+		if len(chunks) == 0 {
+			return 0, err
+		}
+		n := copy(b, chunks[0])
+		chunks[0] = chunks[0][n:]
+		if len(chunks[0]) == 0 {
+			chunks = chunks[1:]
+		}
+		return n, nil
+	}
+}
+
+func TestReadFull(t *testing.T) {
+	reads := 0
+	var fullN int
+	var fullErr error
+	cc := &Conn{
+		Base: &mockConn{readHandler: chunkReader(io.EOF, "chu", "nky", " bacon")},
+		AfterRead: func(*Conn, []byte, int, error) {
+			reads++
+		},
+		AfterReadFull: func(_ *Conn, n int, err error) {
+			fullN, fullErr = n, err
+		},
+	}
+	b := make([]byte, 12)
+	n, err := cc.ReadFull(b)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if n != 12 || string(b) != "chunky bacon" {
+		t.Errorf("Unexpected read %d %q, expected 12 %q", n, b, "chunky bacon")
+	}
+	if reads != 3 {
+		t.Errorf("Unexpected number of AfterRead calls %d, expected 3", reads)
+	}
+	if fullN != 12 || fullErr != nil {
+		t.Errorf("Unexpected AfterReadFull results %d %v, expected 12 nil", fullN, fullErr)
+	}
+}
+
+func TestReadFullUnexpectedEOF(t *testing.T) {
+	cc := &Conn{Base: &mockConn{readHandler: chunkReader(io.EOF, "chunky")}}
+	n, err := cc.ReadFull(make([]byte, 12))
+	if err != io.ErrUnexpectedEOF {
+		t.Errorf("Unexpected error %v, expected %v", err, io.ErrUnexpectedEOF)
+	}
+	if n != 6 {
+		t.Errorf("Unexpected number of bytes %d, expected 6", n)
+	}
+}
+
+func TestReadFullEOF(t *testing.T) {
+	cc := &Conn{Base: &mockConn{readHandler: chunkReader(io.EOF)}}
+	if _, err := cc.ReadFull(make([]byte, 12)); err != io.EOF {
+		t.Errorf("Unexpected error %v, expected %v", err, io.EOF)
+	}
+}
+
+func TestReadFullDeadline(t *testing.T) {
+	reads := 0
+	cc := &Conn{
+		Base: &mockConn{
+			readHandler: func(b []byte) (int, error) {
+				reads++
+				time.Sleep(5 * time.Millisecond)
+				return 1, nil
+			},
+			setReadDeadlineHandler: func(time.Time) error { return nil },
+		},
+	}
+	cc.SetReadDeadline(time.Now().Add(20 * time.Millisecond))
+	n, err := cc.ReadFull(make([]byte, 1000))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Unexpected error %v, expected %v", err, os.ErrDeadlineExceeded)
+	}
+	if n != reads || n >= 1000 {
+		t.Errorf("Unexpected number of bytes %d after %d reads", n, reads)
+	}
+}
+
+func TestReadFullNoProgress(t *testing.T) {
+	cc := &Conn{Base: &mockConn{readHandler: func([]byte) (int, error) { return 0, nil }}}
+	if _, err := cc.ReadFull(make([]byte, 1)); err != io.ErrNoProgress {
+		t.Errorf("Unexpected error %v, expected %v", err, io.ErrNoProgress)
+	}
+}
+
+func TestWriteAll(t *testing.T) {
+	var written []byte
+	writes := 0
+	var allN int
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				n := min(len(b), 5)
+				written = append(written, b[:n]...)
+				return n, nil
+			},
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			writes++
+		},
+		AfterWriteAll: func(_ *Conn, n int, _ error) {
+			allN = n
+		},
+	}
+	n, err := cc.WriteAll([]byte("chunky bacon"))
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if n != 12 || string(written) != "chunky bacon" {
+		t.Errorf("Unexpected write %d %q, expected 12 %q", n, written, "chunky bacon")
+	}
+	if writes != 3 {
+		t.Errorf("Unexpected number of AfterWrite calls %d, expected 3", writes)
+	}
+	if allN != 12 {
+		t.Errorf("Unexpected AfterWriteAll total %d, expected 12", allN)
+	}
+}
+
+func TestWriteAllError(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				return 2, expErr
+			},
+		},
+	}
+	n, err := cc.WriteAll([]byte("bacon"))
+	if err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if n != 2 {
+		t.Errorf("Unexpected number of bytes %d, expected 2", n)
+	}
+}
@@ -0,0 +1,324 @@
+//go:build ignore
+
+// This program generates hooks.go from the hook fields of Conn in conn.go.
+// Run it with go generate after adding, removing or changing a hook field.
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"go/ast"
+	"go/format"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"log"
+	"os"
+	"regexp"
+	"strings"
+)
+
+// hookName matches the names of Conn fields which are hooks.
+var hookName = regexp.MustCompile(`^((Before|After|Transform)\w+|\w+Interceptor)$`)
+
+// paramNames maps parameter types to the names used for them in the generated
+// code.
+var paramNames = map[string]string{
+	"context.Context":     "ctx",
+	"*Conn":               "conn",
+	"[]byte":              "b",
+	"int":                 "n",
+	"int64":               "n",
+	"error":               "err",
+	"net.Addr":            "addr",
+	"time.Time":           "t",
+	"io.Reader":           "r",
+	"io.Writer":           "w",
+	"syscall.RawConn":     "raw",
+	"tls.ConnectionState": "state",
+	"*net.Buffers":        "bufs",
+	"*net.UDPAddr":        "addr",
+	"string":              "name",
+	"time.Duration":       "d",
+}
+
+// paramOverrides holds parameter names for hooks whose parameter types are not
+// unique.
+var paramOverrides = map[string][]string{
+	"BeforeReadMsgUDP":  {"conn", "b", "oob"},
+	"AfterReadMsgUDP":   {"conn", "b", "oob", "n", "oobn", "flags", "addr", "err"},
+	"BeforeWriteMsgUDP": {"conn", "b", "oob", "addr"},
+	"AfterWriteMsgUDP":  {"conn", "b", "oob", "addr", "n", "oobn", "err"},
+}
+
+const header = `// Code generated by gen_hooks.go; DO NOT EDIT.
+
+package connxray
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// The methods below provide synchronized access to the hook fields of Conn.
+// Assigning a hook field directly is fine while the Conn is not in use by
+// other goroutines (eg. in Listener's AfterAccept hook), but changing hooks of
+// a live connection must go through the setters, which are safe to call
+// concurrently with any Conn method.
+//
+// Besides the single hook kept in each field, any number of additional hooks
+// can be registered with the Append methods (eg. AppendBeforeRead). The hook
+// in the field always runs first, followed by appended hooks in the order in
+// which they were added. A chain of 'before' hooks stops at the first hook
+// returning an error, which is then returned from the Conn method. All
+// 'after' hooks in a chain run, in order. Transform hooks are chained by
+// passing the (n, err) returned by one hook to the next one. In a chain of
+// interceptors the first one which handles the call wins.
+//
+// If OnHookPanic is set, hooks returned by the getters (eg. BeforeReadHook)
+// recover from panics and report them to OnHookPanic. A 'before' hook which
+// panicked makes the method fail with ErrHookPanic, while a Transform hook
+// which panicked leaves the (n, err) it was given unchanged and an
+// interceptor which panicked handles the call, failing it with ErrHookPanic.
+//
+// If MeasureHookLatency is set, hooks returned by the getters are timed and
+// reported to OnSlowHook when they take longer than SlowHookThreshold. The
+// time reported covers the whole chain, including recovery from panics.
+//
+// Getters return nil while hooks are disabled with SetHooksEnabled.
+//
+// If Observer is set, it is invoked after all other 'before' and 'after' hooks,
+// as if it were appended last to every chain except those of the context-aware,
+// timed and Transform hooks and interceptors.
+`
+
+// hook describes a hook field of Conn.
+type hook struct {
+	name   string
+	typ    string   // eg. "func(*Conn, []byte) error"
+	params []string // parameter types
+	ret    string   // result list, eg. "(int, error)", or ""
+}
+
+func main() {
+	hooks, err := parseHooks("conn.go")
+	if err != nil {
+		log.Fatal(err)
+	}
+	var buf bytes.Buffer
+	buf.WriteString(header)
+	var chains strings.Builder
+	for _, h := range hooks {
+		writeHook(&buf, h)
+		fmt.Fprintf(&chains, "\t%s []%s\n", h.name, h.typ)
+	}
+	fmt.Fprintf(&buf, `
+// hookChains holds hooks added with the Append methods of Conn. Slices are
+// never appended to in place, so they can be safely shared between Conns.
+type hookChains struct {
+%s}
+`, chains.String())
+	src, err := format.Source(buf.Bytes())
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := os.WriteFile("hooks.go", src, 0o644); err != nil {
+		log.Fatal(err)
+	}
+}
+
+// parseHooks returns the hook fields of the Conn struct declared in path, in
+// the order of declaration.
+func parseHooks(path string) ([]hook, error) {
+	file, err := parser.ParseFile(token.NewFileSet(), path, nil, 0)
+	if err != nil {
+		return nil, err
+	}
+	var hooks []hook
+	ast.Inspect(file, func(n ast.Node) bool {
+		spec, ok := n.(*ast.TypeSpec)
+		if !ok || spec.Name.Name != "Conn" {
+			return true
+		}
+		for _, field := range spec.Type.(*ast.StructType).Fields.List {
+			fn, ok := field.Type.(*ast.FuncType)
+			if !ok || len(field.Names) != 1 || !hookName.MatchString(field.Names[0].Name) {
+				continue
+			}
+			h := hook{name: field.Names[0].Name, typ: types.ExprString(fn)}
+			for _, param := range fn.Params.List {
+				h.params = append(h.params, types.ExprString(param.Type))
+			}
+			if fn.Results != nil {
+				var results []string
+				for _, result := range fn.Results.List {
+					results = append(results, types.ExprString(result.Type))
+				}
+				h.ret = strings.Join(results, ", ")
+				if len(results) > 1 {
+					h.ret = "(" + h.ret + ")"
+				}
+			}
+			hooks = append(hooks, h)
+		}
+		return false
+	})
+	if len(hooks) == 0 {
+		return nil, fmt.Errorf("no hooks found in %s", path)
+	}
+	return hooks, nil
+}
+
+// writeHook writes the setter, the Append method and the getter of h.
+func writeHook(buf *bytes.Buffer, h hook) {
+	args := paramOverrides[h.name]
+	if args == nil {
+		for _, param := range h.params {
+			name, ok := paramNames[param]
+			if !ok {
+				log.Fatalf("%s: no name for parameter type %s", h.name, param)
+			}
+			args = append(args, name)
+		}
+	}
+	var sigParts []string
+	seen := map[string]bool{}
+	for i, arg := range args {
+		if seen[arg] {
+			log.Fatalf("%s: duplicate parameter name %s", h.name, arg)
+		}
+		seen[arg] = true
+		sigParts = append(sigParts, arg+" "+h.params[i])
+	}
+	sig := strings.Join(sigParts, ", ")
+	call := strings.Join(args, ", ")
+	retSp, retKw := "", ""
+	if h.ret != "" {
+		retSp, retKw = h.ret+" ", "return "
+	}
+
+	var body, guardRet, onPanic string
+	switch {
+	case strings.HasPrefix(h.name, "Before"):
+		body = fmt.Sprintf(`			for _, hook := range chain {
+				if err := hook(%s); err != nil {
+					return err
+				}
+			}
+			return nil`, call)
+		guardRet, onPanic = "(err error)", "err = ErrHookPanic"
+	case strings.HasSuffix(h.name, "Interceptor"):
+		body = fmt.Sprintf(`			for _, hook := range chain {
+				if handled, n, err := hook(%s); handled {
+					return true, n, err
+				}
+			}
+			return false, 0, nil`, call)
+		guardRet, onPanic = "(handled bool, n int, err error)", "handled, n, err = true, 0, ErrHookPanic"
+	case strings.HasPrefix(h.name, "Transform"):
+		body = fmt.Sprintf(`			for _, hook := range chain {
+				n, err = hook(%s)
+			}
+			return n, err`, call)
+		guardRet, onPanic = "(rn int, rerr error)", "rn, rerr = n, err"
+	default:
+		body = fmt.Sprintf(`			for _, hook := range chain {
+				hook(%s)
+			}`, call)
+	}
+
+	observe := ""
+	observed := !strings.HasPrefix(h.name, "Transform") &&
+		!strings.HasSuffix(h.name, "Ctx") &&
+		!strings.HasSuffix(h.name, "Interceptor") &&
+		!strings.HasSuffix(h.name, "Timed")
+	if observed {
+		method := strings.TrimPrefix(strings.TrimPrefix(h.name, "Before"), "After")
+		var obsArgs []string
+		for _, arg := range args {
+			if arg != "conn" {
+				obsArgs = append(obsArgs, ", "+arg)
+			}
+		}
+		obsCall := fmt.Sprintf(`obs.AfterCall(conn, "%s"%s)`, method, strings.Join(obsArgs, ""))
+		if strings.HasPrefix(h.name, "Before") {
+			obsCall = fmt.Sprintf(`return obs.BeforeCall(conn, "%s"%s)`, method, strings.Join(obsArgs, ""))
+		}
+		observe = fmt.Sprintf(`
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(%s) %s{
+			%s
+		})
+	}`, sig, retSp, obsCall)
+	}
+
+	guardSp, recovered := "", ""
+	if guardRet != "" {
+		guardSp = guardRet + " "
+		recovered = "\n\t\t\t\t\t" + onPanic
+	}
+	guard := fmt.Sprintf(`	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(%[1]s) %[2]s{
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "%[3]s", r)%[4]s
+				}
+			}()
+			%[5]sguarded(%[6]s)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(%[1]s) %[7]s{
+			defer reportSlowHook(onSlow, threshold, conn, "%[3]s", time.Now())
+			%[5]stimed(%[6]s)
+		}
+	}
+	return hook`, sig, guardSp, h.name, recovered, retKw, call, retSp)
+
+	fmt.Fprintf(buf, `
+// Set%[1]s sets the %[1]s hook.
+func (c *Conn) Set%[1]s(fn %[2]s) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.%[1]s = fn
+}
+
+// Append%[1]s adds fn to the chain of %[1]s hooks.
+func (c *Conn) Append%[1]s(fn %[2]s) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.%[1]s = append(slices.Clip(c.chains.%[1]s), fn)
+}
+
+// %[1]sHook returns the %[1]s hook followed by
+// any hooks added with Append%[1]s.
+func (c *Conn) %[1]sHook() %[2]s {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.%[1]s
+	chain := c.chains.%[1]s%[3]s
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]%[2]s{hook}, chain...)
+		}
+		hook = func(%[4]s) %[5]s{
+%[6]s
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+%[7]s
+}
+`, h.name, h.typ, observe, sig, retSp, body, guard)
+}
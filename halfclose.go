@@ -0,0 +1,59 @@
+package connxray
+
+import (
+	"errors"
+)
+
+var (
+	// ErrHalfCloseUnsupported signifies that the underlying net.Conn does not
+	// support closing one direction of the connection (ie. it does not have
+	// a CloseRead or CloseWrite method).
+	ErrHalfCloseUnsupported = errors.New("this net.Conn does not support half-close")
+)
+
+// CloseWrite shuts down the writing side of the underlying net.Conn (eg.
+// *net.TCPConn or *net.UnixConn), signalling EOF to the peer while still
+// allowing reads, and invokes relevant hooks ('before' and 'after') that were
+// set up. If the base does not support it ErrHalfCloseUnsupported is
+// returned (and passed to the 'after' hook).
+func (c *Conn) CloseWrite() error {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeCloseWriteHook(); hook != nil {
+		if err := hook(c); err != nil {
+			return beforeHookError("CloseWrite", err)
+		}
+	}
+	err := ErrHalfCloseUnsupported
+	if hc, implements := c.Base.(interface{ CloseWrite() error }); implements {
+		start := c.now()
+		err = hc.CloseWrite()
+		c.spentInBase(start)
+	}
+	if hook := c.AfterCloseWriteHook(); hook != nil {
+		hook(c, err)
+	}
+	return err
+}
+
+// CloseRead shuts down the reading side of the underlying net.Conn and
+// invokes relevant hooks ('before' and 'after') that were set up. If the base
+// does not support it ErrHalfCloseUnsupported is returned (and passed to the
+// 'after' hook).
+func (c *Conn) CloseRead() error {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeCloseReadHook(); hook != nil {
+		if err := hook(c); err != nil {
+			return beforeHookError("CloseRead", err)
+		}
+	}
+	err := ErrHalfCloseUnsupported
+	if hc, implements := c.Base.(interface{ CloseRead() error }); implements {
+		start := c.now()
+		err = hc.CloseRead()
+		c.spentInBase(start)
+	}
+	if hook := c.AfterCloseReadHook(); hook != nil {
+		hook(c, err)
+	}
+	return err
+}
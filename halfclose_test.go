@@ -0,0 +1,102 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"testing"
+)
+
+func TestCloseWrite(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	baseCalled, afterCalled := false, false
+	mc := &mockHalfCloseConn{
+		closeWriteHandler: func() error {
+			baseCalled = true
+			return expErr
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		AfterCloseWrite: func(_ *Conn, err error) {
+			if err != expErr {
+				t.Errorf("Unexpected error %v, expected %v", err, expErr)
+			}
+			afterCalled = true
+		},
+	}
+	if err := cc.CloseWrite(); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if !baseCalled {
+		t.Error("Base method not invoked")
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestCloseReadWithFailingBeforeCallback(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockHalfCloseConn{
+		closeReadHandler: func() error {
+			t.Error("Base method invoked")
+			return nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeCloseRead: func(_ *Conn) error {
+			return expErr
+		},
+		AfterCloseRead: func(_ *Conn, _ error) {
+			t.Error("After callback invoked")
+		},
+	}
+	if err := cc.CloseRead(); !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestHalfCloseUnsupported(t *testing.T) {
+	afterErrs := []error{}
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterCloseWrite: func(_ *Conn, err error) {
+			afterErrs = append(afterErrs, err)
+		},
+		AfterCloseRead: func(_ *Conn, err error) {
+			afterErrs = append(afterErrs, err)
+		},
+	}
+	if err := cc.CloseWrite(); err != ErrHalfCloseUnsupported {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrHalfCloseUnsupported)
+	}
+	if err := cc.CloseRead(); err != ErrHalfCloseUnsupported {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrHalfCloseUnsupported)
+	}
+	if len(afterErrs) != 2 {
+		t.Errorf("Unexpected after callback errors %v", afterErrs)
+	}
+}
+
+func TestCloseWriteTCP(t *testing.T) {
+	client, server := tcpConnPair(t)
+	cc := &Conn{Base: client}
+	if _, err := cc.Write([]byte("bye")); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := cc.CloseWrite(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	data, err := io.ReadAll(server)
+	if err != nil || string(data) != "bye" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"bye\", nil)", data, err)
+	}
+	if _, err := server.Write([]byte("ok")); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	buf := make([]byte, 2)
+	if _, err := io.ReadFull(cc, buf); err != nil || string(buf) != "ok" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"ok\", nil)", buf, err)
+	}
+}
@@ -0,0 +1,52 @@
+package connxray
+
+// ConnState is the accounting state of a Conn, as returned by ExportState. It
+// lets a new Conn wrapping the same underlying connection carry on where the
+// previous one left off, eg. when a connection is handed from an accept loop
+// to a worker pool.
+type ConnState struct {
+	// Stats are the traffic counters, along with the creation time.
+	Stats StatsSnapshot
+
+	// ErrorCounts is the histogram returned by Conn.ErrorCounts.
+	ErrorCounts map[string]uint64
+}
+
+// ExportState returns the accounting state of the Conn: its traffic counters,
+// error counts and creation time.
+func (c *Conn) ExportState() ConnState {
+	return ConnState{
+		Stats:       c.Stats(),
+		ErrorCounts: c.ErrorCounts(),
+	}
+}
+
+// ImportState replaces the accounting state of the Conn with state, so that
+// Stats, ErrorCounts and Age continue from where the exporting Conn stopped.
+// AfterFirstRead and AfterFirstWrite hooks no longer fire for directions which
+// already transferred data. It must be called before the Conn is used.
+func (c *Conn) ImportState(state ConnState) {
+	s := state.Stats
+	c.stats.BytesRead.Store(s.BytesRead)
+	c.stats.BytesWritten.Store(s.BytesWritten)
+	c.stats.Reads.Store(s.Reads)
+	c.stats.Writes.Store(s.Writes)
+	c.stats.ReadErrors.Store(s.ReadErrors)
+	c.stats.WriteErrors.Store(s.WriteErrors)
+	c.stats.EOFs.Store(s.EOFs)
+	c.stats.ReadTimeouts.Store(s.ReadTimeouts)
+	c.stats.WriteTimeouts.Store(s.WriteTimeouts)
+	c.errorCounts.timeout.Store(state.ErrorCounts[ErrorClassTimeout])
+	c.errorCounts.reset.Store(state.ErrorCounts[ErrorClassReset])
+	c.errorCounts.eof.Store(state.ErrorCounts[ErrorClassEOF])
+	c.errorCounts.other.Store(state.ErrorCounts[ErrorClassOther])
+	if !s.Created.IsZero() {
+		c.created = s.Created
+	}
+	if s.BytesRead > 0 {
+		c.firstRead.done.Store(true)
+	}
+	if s.BytesWritten > 0 {
+		c.firstWrite.done.Store(true)
+	}
+}
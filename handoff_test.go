@@ -0,0 +1,60 @@
+package connxray
+
+import (
+	"io"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestExportImportState(t *testing.T) {
+	reads := 0
+	base := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			reads++
+			if reads == 3 {
+				return 0, io.EOF
+			}
+			return copy(b, "bacon"), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	created := time.Now().Add(-time.Minute)
+	first := NewConn(base, WithStats())
+	first.created = created
+	buf := make([]byte, 8)
+	first.Read(buf)
+	first.Write([]byte("chunky"))
+	state := first.ExportState()
+	if state.Stats.BytesRead != 5 || state.Stats.BytesWritten != 6 {
+		t.Errorf("Unexpected exported stats %+v, expected 5 bytes read and 6 written", state.Stats)
+	}
+
+	firstReads := 0
+	second := NewConn(base, WithStats())
+	second.AfterFirstRead = func(*Conn, time.Duration) {
+		firstReads++
+	}
+	second.ImportState(state)
+	second.Read(buf)
+	second.Read(buf)
+	second.Write([]byte("chunky"))
+	got := second.Stats()
+	exp := StatsSnapshot{BytesRead: 10, BytesWritten: 12, Reads: 3, Writes: 2, ReadErrors: 1, EOFs: 1}
+	if got.Age < time.Minute || !got.Created.Equal(created) {
+		t.Errorf("Unexpected creation time %v (age %v), expected %v", got.Created, got.Age, created)
+	}
+	got.Created, got.Age = time.Time{}, 0
+	if got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	expCounts := map[string]uint64{ErrorClassTimeout: 0, ErrorClassReset: 0, ErrorClassEOF: 1, ErrorClassOther: 0}
+	if counts := second.ErrorCounts(); !reflect.DeepEqual(counts, expCounts) {
+		t.Errorf("Unexpected error counts %v, expected %v", counts, expCounts)
+	}
+	if firstReads != 0 {
+		t.Errorf("Unexpected number of AfterFirstRead calls %d, expected 0", firstReads)
+	}
+}
@@ -0,0 +1,70 @@
+package connxray
+
+import (
+	"context"
+	"sync"
+	"sync/atomic"
+)
+
+// handshakeSlots is the semaphore behind Listener.MaxHandshakes.
+type handshakeSlots struct {
+	once     sync.Once
+	slots    chan struct{}
+	inFlight atomic.Int64
+}
+
+// handshakeLimit ties a Conn to the handshakeSlots of its Listener.
+type handshakeLimit struct {
+	slots  *handshakeSlots
+	closed chan struct{} // closed along with the Conn
+}
+
+// limitHandshakes makes conn take a slot of MaxHandshakes for the duration of
+// each call to its Handshake method.
+func (l *Listener) limitHandshakes(conn *Conn) {
+	if l.MaxHandshakes <= 0 {
+		return
+	}
+	hs := &l.handshakeSlots
+	hs.once.Do(func() { hs.slots = make(chan struct{}, l.MaxHandshakes) })
+	limit := &handshakeLimit{slots: hs, closed: make(chan struct{})}
+	conn.handshakeLimit = limit
+	conn.onClose(func(*Conn) {
+		close(limit.closed)
+	})
+}
+
+// acquire waits for a free slot on behalf of c and returns the function which
+// frees it. It fails if c is closed, or ctx or the Context of c is done before
+// a slot frees up. A nil limit always succeeds immediately.
+func (limit *handshakeLimit) acquire(ctx context.Context, c *Conn) (func(), error) {
+	if limit == nil {
+		return func() {}, nil
+	}
+	var connDone <-chan struct{}
+	if c.Context != nil {
+		connDone = c.Context.Done()
+	}
+	hs := limit.slots
+	select {
+	case hs.slots <- struct{}{}:
+	case <-limit.closed:
+		return nil, ErrConnClosed
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	case <-connDone:
+		return nil, c.Context.Err()
+	}
+	hs.inFlight.Add(1)
+	return func() {
+		hs.inFlight.Add(-1)
+		<-hs.slots
+	}, nil
+}
+
+// Handshakes returns the number of connections accepted by the Listener which
+// are currently performing a handshake under MaxHandshakes. It is always zero
+// if MaxHandshakes is not set.
+func (l *Listener) Handshakes() int {
+	return int(l.handshakeSlots.inFlight.Load())
+}
@@ -0,0 +1,173 @@
+package connxray
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// tlsListener returns a Listener accepting the server ends of n TLS pairs,
+// along with their client ends.
+func tlsListener(t *testing.T, n int) (*Listener, []*tls.Conn) {
+	t.Helper()
+	var clients []*tls.Conn
+	var servers []net.Conn
+	for i := 0; i < n; i++ {
+		client, server := tlsPair(t)
+		clients = append(clients, client)
+		servers = append(servers, server)
+	}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			server := servers[0]
+			servers = servers[1:]
+			return server, nil
+		},
+	}
+	return &Listener{Base: ml}, clients
+}
+
+func TestMaxHandshakes(t *testing.T) {
+	l, clients := tlsListener(t, 3)
+	l.MaxHandshakes = 1
+	var active, maxActive, started atomic.Int32
+	conns := acceptN(t, l, 3)
+	for _, nc := range conns {
+		conn := nc.(*Conn)
+		// Hooks run once a slot is taken.
+		conn.AppendBeforeHandshake(func(*Conn) error {
+			started.Add(1)
+			if n := active.Add(1); n > maxActive.Load() {
+				maxActive.Store(n)
+			}
+			return nil
+		})
+		// The slot is freed once AfterHandshake has run.
+		conn.AfterHandshake = func(*Conn, tls.ConnectionState, error) {
+			active.Add(-1)
+		}
+	}
+	var wg sync.WaitGroup
+	for _, nc := range conns {
+		wg.Add(1)
+		go func(conn *Conn) {
+			defer wg.Done()
+			if err := conn.Handshake(context.Background()); err != nil {
+				t.Errorf("Unexpected error %v, expected nil", err)
+			}
+		}(nc.(*Conn))
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := started.Load(); n != 1 {
+		t.Errorf("Unexpected number of started handshakes %d, expected 1", n)
+	}
+	if n := l.Handshakes(); n != 1 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 1", n)
+	}
+	for _, client := range clients {
+		go client.Handshake()
+	}
+	wg.Wait()
+	if n := maxActive.Load(); n != 1 {
+		t.Errorf("Unexpected maximum of concurrent handshakes %d, expected 1", n)
+	}
+	if n := l.Handshakes(); n != 0 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 0", n)
+	}
+}
+
+func TestMaxHandshakesReleasedOnClose(t *testing.T) {
+	l, clients := tlsListener(t, 3)
+	l.MaxHandshakes = 1
+	conns := acceptN(t, l, 3)
+	first, waiting, last := conns[0].(*Conn), conns[1].(*Conn), conns[2].(*Conn)
+	firstDone := make(chan error)
+	go func() {
+		firstDone <- first.Handshake(context.Background())
+	}()
+	for l.Handshakes() == 0 {
+		time.Sleep(time.Millisecond)
+	}
+	waitingDone := make(chan error)
+	go func() {
+		waitingDone <- waiting.Handshake(context.Background())
+	}()
+	time.Sleep(20 * time.Millisecond)
+	waiting.Close()
+	if err := <-waitingDone; !errors.Is(err, ErrConnClosed) {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	// Closing a Conn in the middle of its handshake frees its slot too.
+	first.Close()
+	if err := <-firstDone; err == nil {
+		t.Error("Unexpected nil error from an interrupted handshake")
+	}
+	if n := l.Handshakes(); n != 0 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 0", n)
+	}
+	go clients[2].Handshake()
+	if err := last.Handshake(context.Background()); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+}
+
+func TestMaxHandshakesWithoutHooks(t *testing.T) {
+	l, clients := tlsListener(t, 2)
+	l.MaxHandshakes = 1
+	conns := acceptN(t, l, 2)
+	done := make(chan error, 2)
+	for _, nc := range conns {
+		conn := nc.(*Conn)
+		conn.SetHooksEnabled(false)
+		go func() {
+			done <- conn.Handshake(context.Background())
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	if n := l.Handshakes(); n != 1 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 1", n)
+	}
+	for _, client := range clients {
+		go client.Handshake()
+	}
+	for range conns {
+		if err := <-done; err != nil {
+			t.Errorf("Unexpected error %v, expected nil", err)
+		}
+	}
+	if n := l.Handshakes(); n != 0 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 0", n)
+	}
+}
+
+func TestMaxHandshakesBeforeHookError(t *testing.T) {
+	l, clients := tlsListener(t, 1)
+	l.MaxHandshakes = 1
+	conn := acceptN(t, l, 1)[0].(*Conn)
+	expected := errors.New("not now")
+	conn.AppendBeforeHandshake(func(*Conn) error {
+		return expected
+	})
+	if err := conn.Handshake(context.Background()); !errors.Is(err, expected) {
+		t.Errorf("Unexpected error %v, expected %v", err, expected)
+	}
+	if n := l.Handshakes(); n != 0 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 0", n)
+	}
+	// Neither a failed attempt nor toggling hooks keeps the slot.
+	conn.SetHooksEnabled(false)
+	conn.SetHooksEnabled(true)
+	conn.SetHooksEnabled(false)
+	go clients[0].Handshake()
+	if err := conn.Handshake(context.Background()); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if n := l.Handshakes(); n != 0 {
+		t.Errorf("Unexpected number of in-flight handshakes %d, expected 0", n)
+	}
+}
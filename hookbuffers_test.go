@@ -0,0 +1,75 @@
+package connxray
+
+import (
+	"testing"
+)
+
+func TestCopyHookBuffersRead(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "chunky"), nil
+		},
+	}
+	var afterLen int
+	cc := &Conn{
+		Base:            mc,
+		CopyHookBuffers: true,
+		AfterRead: func(_ *Conn, b []byte, _ int, _ error) {
+			afterLen = len(b)
+			copy(b, "XXXXXX")
+		},
+	}
+	buf := make([]byte, 16)
+	n, _ := cc.Read(buf)
+	if string(buf[:n]) != "chunky" {
+		t.Errorf("Unexpected data %q, expected \"chunky\"", buf[:n])
+	}
+	if afterLen != 6 {
+		t.Errorf("Unexpected hook buffer length %d, expected 6", afterLen)
+	}
+}
+
+func TestCopyHookBuffersWrite(t *testing.T) {
+	var written string
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			written = string(b)
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base:            mc,
+		CopyHookBuffers: true,
+		BeforeWrite: func(_ *Conn, b []byte) error {
+			copy(b, "XXXXX")
+			return nil
+		},
+		AfterWrite: func(_ *Conn, b []byte, _ int, _ error) {
+			copy(b, "YYYYY")
+		},
+	}
+	buf := []byte("bacon")
+	cc.Write(buf)
+	if written != "bacon" || string(buf) != "bacon" {
+		t.Errorf("Unexpected data (%q, %q), expected (\"bacon\", \"bacon\")", written, buf)
+	}
+}
+
+func TestHookBuffersSharedByDefault(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "chunky"), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		AfterRead: func(_ *Conn, b []byte, _ int, _ error) {
+			b[0] = 'C'
+		},
+	}
+	buf := make([]byte, 16)
+	n, _ := cc.Read(buf)
+	if string(buf[:n]) != "Chunky" {
+		t.Errorf("Unexpected data %q, expected \"Chunky\"", buf[:n])
+	}
+}
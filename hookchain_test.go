@@ -0,0 +1,133 @@
+package connxray
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestHookChainEmpty(t *testing.T) {
+	cc := &Conn{}
+	if cc.BeforeReadHook() != nil || cc.AfterReadHook() != nil {
+		t.Error("Unexpected non-nil hook with empty chain")
+	}
+	called := false
+	cc.BeforeRead = func(*Conn, []byte) error {
+		called = true
+		return nil
+	}
+	if err := cc.BeforeReadHook()(cc, nil); err != nil || !called {
+		t.Errorf("Unexpected results (%v, %v), expected (nil, true)", err, called)
+	}
+}
+
+func TestHookChainBeforeStopsAtFirstError(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	calls := []string{}
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	cc.AppendBeforeRead(func(*Conn, []byte) error {
+		calls = append(calls, "first")
+		return nil
+	})
+	cc.AppendBeforeRead(func(*Conn, []byte) error {
+		calls = append(calls, "middle")
+		return expErr
+	})
+	cc.AppendBeforeRead(func(*Conn, []byte) error {
+		calls = append(calls, "last")
+		return nil
+	})
+	cc.AppendAfterRead(func(*Conn, []byte, int, error) {
+		t.Error("After callback invoked")
+	})
+	if _, err := cc.Read(nil); !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if exp := []string{"first", "middle"}; !reflect.DeepEqual(calls, exp) {
+		t.Errorf("Unexpected calls %v, expected %v", calls, exp)
+	}
+}
+
+func TestHookChainWithLegacyField(t *testing.T) {
+	calls := []string{}
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			calls = append(calls, "base")
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeWrite: func(*Conn, []byte) error {
+			calls = append(calls, "before field")
+			return nil
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			calls = append(calls, "after field")
+		},
+	}
+	cc.AppendBeforeWrite(func(*Conn, []byte) error {
+		calls = append(calls, "before appended")
+		return nil
+	})
+	cc.AppendAfterWrite(func(*Conn, []byte, int, error) {
+		calls = append(calls, "after appended 1")
+	})
+	cc.AppendAfterWrite(func(*Conn, []byte, int, error) {
+		calls = append(calls, "after appended 2")
+	})
+	if _, err := cc.Write([]byte("foo")); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	exp := []string{
+		"before field",
+		"before appended",
+		"base",
+		"after field",
+		"after appended 1",
+		"after appended 2",
+	}
+	if !reflect.DeepEqual(calls, exp) {
+		t.Errorf("Unexpected calls %v, expected %v", calls, exp)
+	}
+}
+
+func TestHookChainTransformPipes(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return 1, nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		TransformRead: func(_ *Conn, _ []byte, n int, err error) (int, error) {
+			return n + 1, err
+		},
+	}
+	cc.AppendTransformRead(func(_ *Conn, _ []byte, n int, err error) (int, error) {
+		return n * 10, err
+	})
+	if n, err := cc.Read(nil); n != 20 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (20, nil)", n, err)
+	}
+}
+
+func TestHookChainSharedWithTemplate(t *testing.T) {
+	tmplCalls, connCalls := 0, 0
+	tmpl := &Conn{}
+	tmpl.AppendAfterClose(func(*Conn, error) { tmplCalls++ })
+	cc := &Conn{Base: &mockConn{closeHandler: func() error { return nil }}}
+	cc.copyHooks(tmpl)
+	cc.AppendAfterClose(func(*Conn, error) { connCalls++ })
+	tmpl.AppendAfterClose(func(*Conn, error) { tmplCalls += 100 })
+	cc.Close()
+	if tmplCalls != 1 || connCalls != 1 {
+		t.Errorf("Unexpected calls (%d, %d), expected (1, 1)", tmplCalls, connCalls)
+	}
+}
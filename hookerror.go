@@ -0,0 +1,59 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+)
+
+// HookError is returned by methods of Conn and Listener when one of their
+// 'before' hooks (or an Observer's BeforeCall) fails, so that failures caused
+// by instrumentation can be told apart from network errors using errors.As.
+// The error returned by the hook is kept in Err, so errors.Is still matches
+// it. Errors which already are (or wrap) a HookError are returned as they are.
+//
+// HookError implements net.Error, reporting a timeout if Err does.
+type HookError struct {
+	// Method is the name of the method the hook was set up for, as passed to
+	// Observer (eg. "Write" or "Accept").
+	Method string
+
+	// Phase is the kind of hook which failed. It is currently always
+	// "before".
+	Phase string
+
+	// Err is the error returned by the hook.
+	Err error
+}
+
+func (e *HookError) Error() string {
+	return "connxray: " + e.Phase + " " + e.Method + " hook: " + e.Err.Error()
+}
+
+// Unwrap returns the error returned by the hook.
+func (e *HookError) Unwrap() error {
+	return e.Err
+}
+
+// Timeout tells whether the error returned by the hook is a timeout.
+func (e *HookError) Timeout() bool {
+	var ne net.Error
+	return errors.As(e.Err, &ne) && ne.Timeout()
+}
+
+// Temporary tells whether the error returned by the hook is temporary.
+//
+// Deprecated: see net.Error.
+func (e *HookError) Temporary() bool {
+	var ne net.Error
+	return errors.As(e.Err, &ne) && ne.Temporary()
+}
+
+// beforeHookError wraps err, returned by a 'before' hook for method, in a
+// HookError unless it's nil or already a HookError.
+func beforeHookError(method string, err error) error {
+	var hookErr *HookError
+	if err == nil || errors.As(err, &hookErr) {
+		return err
+	}
+	return &HookError{Method: method, Phase: "before", Err: err}
+}
@@ -0,0 +1,85 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+)
+
+func TestBeforeHookErrorIsWrapped(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	cc := &Conn{
+		Base: &mockConn{},
+		BeforeWrite: func(*Conn, []byte) error {
+			return expErr
+		},
+	}
+	_, err := cc.Write(nil)
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) {
+		t.Fatalf("Unexpected error %v, expected a HookError", err)
+	}
+	if hookErr.Method != "Write" || hookErr.Phase != "before" {
+		t.Errorf("Unexpected method and phase (%q, %q), expected (\"Write\", \"before\")", hookErr.Method, hookErr.Phase)
+	}
+	if !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestBeforeHookErrorNotDoubleWrapped(t *testing.T) {
+	expErr := &HookError{Method: "Custom", Phase: "before", Err: errors.New("chunky bacon")}
+	cc := &Conn{
+		Base: &mockConn{},
+		BeforeRead: func(*Conn, []byte) error {
+			return expErr
+		},
+	}
+	if _, err := cc.Read(nil); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestBeforeAcceptErrorIsWrapped(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	cl := &Listener{
+		Base: &mockListener{},
+		BeforeAccept: func(*Listener) error {
+			return expErr
+		},
+	}
+	_, err := cl.Accept()
+	var hookErr *HookError
+	if !errors.As(err, &hookErr) || hookErr.Method != "Accept" {
+		t.Errorf("Unexpected error %v, expected a HookError for Accept", err)
+	}
+	if !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestHookErrorTimeout(t *testing.T) {
+	var err net.Error = &HookError{Method: "Read", Phase: "before", Err: os.ErrDeadlineExceeded}
+	if !err.Timeout() {
+		t.Error("Unexpectedly not a timeout")
+	}
+	err = &HookError{Method: "Read", Phase: "before", Err: errors.New("chunky bacon")}
+	if err.Timeout() {
+		t.Error("Unexpectedly a timeout")
+	}
+}
+
+func TestBaseErrorIsNotWrapped(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	cc := &Conn{
+		Base: &mockConn{
+			readHandler: func([]byte) (int, error) {
+				return 0, expErr
+			},
+		},
+	}
+	if _, err := cc.Read(nil); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
@@ -0,0 +1,3857 @@
+// Code generated by gen_hooks.go; DO NOT EDIT.
+
+package connxray
+
+import (
+	"context"
+	"crypto/tls"
+	"io"
+	"net"
+	"slices"
+	"syscall"
+	"time"
+)
+
+// The methods below provide synchronized access to the hook fields of Conn.
+// Assigning a hook field directly is fine while the Conn is not in use by
+// other goroutines (eg. in Listener's AfterAccept hook), but changing hooks of
+// a live connection must go through the setters, which are safe to call
+// concurrently with any Conn method.
+//
+// Besides the single hook kept in each field, any number of additional hooks
+// can be registered with the Append methods (eg. AppendBeforeRead). The hook
+// in the field always runs first, followed by appended hooks in the order in
+// which they were added. A chain of 'before' hooks stops at the first hook
+// returning an error, which is then returned from the Conn method. All
+// 'after' hooks in a chain run, in order. Transform hooks are chained by
+// passing the (n, err) returned by one hook to the next one. In a chain of
+// interceptors the first one which handles the call wins.
+//
+// If OnHookPanic is set, hooks returned by the getters (eg. BeforeReadHook)
+// recover from panics and report them to OnHookPanic. A 'before' hook which
+// panicked makes the method fail with ErrHookPanic, while a Transform hook
+// which panicked leaves the (n, err) it was given unchanged and an
+// interceptor which panicked handles the call, failing it with ErrHookPanic.
+//
+// If MeasureHookLatency is set, hooks returned by the getters are timed and
+// reported to OnSlowHook when they take longer than SlowHookThreshold. The
+// time reported covers the whole chain, including recovery from panics.
+//
+// Getters return nil while hooks are disabled with SetHooksEnabled.
+//
+// If Observer is set, it is invoked after all other 'before' and 'after' hooks,
+// as if it were appended last to every chain except those of the context-aware,
+// timed and Transform hooks and interceptors.
+
+// SetBeforeRead sets the BeforeRead hook.
+func (c *Conn) SetBeforeRead(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeRead = fn
+}
+
+// AppendBeforeRead adds fn to the chain of BeforeRead hooks.
+func (c *Conn) AppendBeforeRead(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeRead = append(slices.Clip(c.chains.BeforeRead), fn)
+}
+
+// BeforeReadHook returns the BeforeRead hook followed by
+// any hooks added with AppendBeforeRead.
+func (c *Conn) BeforeReadHook() func(*Conn, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeRead
+	chain := c.chains.BeforeRead
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte) error {
+			return obs.BeforeCall(conn, "Read", b)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte) error {
+			for _, hook := range chain {
+				if err := hook(conn, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeRead", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeRead", time.Now())
+			return timed(conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterRead sets the AfterRead hook.
+func (c *Conn) SetAfterRead(fn func(*Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterRead = fn
+}
+
+// AppendAfterRead adds fn to the chain of AfterRead hooks.
+func (c *Conn) AppendAfterRead(fn func(*Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterRead = append(slices.Clip(c.chains.AfterRead), fn)
+}
+
+// AfterReadHook returns the AfterRead hook followed by
+// any hooks added with AppendAfterRead.
+func (c *Conn) AfterReadHook() func(*Conn, []byte, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterRead
+	chain := c.chains.AfterRead
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, n int, err error) {
+			obs.AfterCall(conn, "Read", b, n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			for _, hook := range chain {
+				hook(conn, b, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterRead", r)
+				}
+			}()
+			guarded(conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterRead", time.Now())
+			timed(conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeReadCtx sets the BeforeReadCtx hook.
+func (c *Conn) SetBeforeReadCtx(fn func(context.Context, *Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeReadCtx = fn
+}
+
+// AppendBeforeReadCtx adds fn to the chain of BeforeReadCtx hooks.
+func (c *Conn) AppendBeforeReadCtx(fn func(context.Context, *Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeReadCtx = append(slices.Clip(c.chains.BeforeReadCtx), fn)
+}
+
+// BeforeReadCtxHook returns the BeforeReadCtx hook followed by
+// any hooks added with AppendBeforeReadCtx.
+func (c *Conn) BeforeReadCtxHook() func(context.Context, *Conn, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeReadCtx
+	chain := c.chains.BeforeReadCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn, []byte) error{hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn, b []byte) error {
+			for _, hook := range chain {
+				if err := hook(ctx, conn, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn, b []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeReadCtx", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(ctx, conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn, b []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeReadCtx", time.Now())
+			return timed(ctx, conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadCtx sets the AfterReadCtx hook.
+func (c *Conn) SetAfterReadCtx(fn func(context.Context, *Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadCtx = fn
+}
+
+// AppendAfterReadCtx adds fn to the chain of AfterReadCtx hooks.
+func (c *Conn) AppendAfterReadCtx(fn func(context.Context, *Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadCtx = append(slices.Clip(c.chains.AfterReadCtx), fn)
+}
+
+// AfterReadCtxHook returns the AfterReadCtx hook followed by
+// any hooks added with AppendAfterReadCtx.
+func (c *Conn) AfterReadCtxHook() func(context.Context, *Conn, []byte, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadCtx
+	chain := c.chains.AfterReadCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn, []byte, int, error){hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			for _, hook := range chain {
+				hook(ctx, conn, b, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadCtx", r)
+				}
+			}()
+			guarded(ctx, conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadCtx", time.Now())
+			timed(ctx, conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadTimed sets the AfterReadTimed hook.
+func (c *Conn) SetAfterReadTimed(fn func(*Conn, []byte, int, time.Duration, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadTimed = fn
+}
+
+// AppendAfterReadTimed adds fn to the chain of AfterReadTimed hooks.
+func (c *Conn) AppendAfterReadTimed(fn func(*Conn, []byte, int, time.Duration, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadTimed = append(slices.Clip(c.chains.AfterReadTimed), fn)
+}
+
+// AfterReadTimedHook returns the AfterReadTimed hook followed by
+// any hooks added with AppendAfterReadTimed.
+func (c *Conn) AfterReadTimedHook() func(*Conn, []byte, int, time.Duration, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadTimed
+	chain := c.chains.AfterReadTimed
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, time.Duration, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			for _, hook := range chain {
+				hook(conn, b, n, d, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadTimed", r)
+				}
+			}()
+			guarded(conn, b, n, d, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadTimed", time.Now())
+			timed(conn, b, n, d, err)
+		}
+	}
+	return hook
+}
+
+// SetTransformRead sets the TransformRead hook.
+func (c *Conn) SetTransformRead(fn func(*Conn, []byte, int, error) (int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.TransformRead = fn
+}
+
+// AppendTransformRead adds fn to the chain of TransformRead hooks.
+func (c *Conn) AppendTransformRead(fn func(*Conn, []byte, int, error) (int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.TransformRead = append(slices.Clip(c.chains.TransformRead), fn)
+}
+
+// TransformReadHook returns the TransformRead hook followed by
+// any hooks added with AppendTransformRead.
+func (c *Conn) TransformReadHook() func(*Conn, []byte, int, error) (int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.TransformRead
+	chain := c.chains.TransformRead
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, error) (int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, err error) (int, error) {
+			for _, hook := range chain {
+				n, err = hook(conn, b, n, err)
+			}
+			return n, err
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, err error) (rn int, rerr error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "TransformRead", r)
+					rn, rerr = n, err
+				}
+			}()
+			return guarded(conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, err error) (int, error) {
+			defer reportSlowHook(onSlow, threshold, conn, "TransformRead", time.Now())
+			return timed(conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetReadInterceptor sets the ReadInterceptor hook.
+func (c *Conn) SetReadInterceptor(fn func(*Conn, []byte) (bool, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.ReadInterceptor = fn
+}
+
+// AppendReadInterceptor adds fn to the chain of ReadInterceptor hooks.
+func (c *Conn) AppendReadInterceptor(fn func(*Conn, []byte) (bool, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.ReadInterceptor = append(slices.Clip(c.chains.ReadInterceptor), fn)
+}
+
+// ReadInterceptorHook returns the ReadInterceptor hook followed by
+// any hooks added with AppendReadInterceptor.
+func (c *Conn) ReadInterceptorHook() func(*Conn, []byte) (bool, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.ReadInterceptor
+	chain := c.chains.ReadInterceptor
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte) (bool, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte) (bool, int, error) {
+			for _, hook := range chain {
+				if handled, n, err := hook(conn, b); handled {
+					return true, n, err
+				}
+			}
+			return false, 0, nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte) (handled bool, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "ReadInterceptor", r)
+					handled, n, err = true, 0, ErrHookPanic
+				}
+			}()
+			return guarded(conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte) (bool, int, error) {
+			defer reportSlowHook(onSlow, threshold, conn, "ReadInterceptor", time.Now())
+			return timed(conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterEOF sets the AfterEOF hook.
+func (c *Conn) SetAfterEOF(fn func(*Conn, int)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterEOF = fn
+}
+
+// AppendAfterEOF adds fn to the chain of AfterEOF hooks.
+func (c *Conn) AppendAfterEOF(fn func(*Conn, int)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterEOF = append(slices.Clip(c.chains.AfterEOF), fn)
+}
+
+// AfterEOFHook returns the AfterEOF hook followed by
+// any hooks added with AppendAfterEOF.
+func (c *Conn) AfterEOFHook() func(*Conn, int) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterEOF
+	chain := c.chains.AfterEOF
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, n int) {
+			obs.AfterCall(conn, "EOF", n)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, int){hook}, chain...)
+		}
+		hook = func(conn *Conn, n int) {
+			for _, hook := range chain {
+				hook(conn, n)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, n int) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterEOF", r)
+				}
+			}()
+			guarded(conn, n)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, n int) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterEOF", time.Now())
+			timed(conn, n)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadTimeout sets the AfterReadTimeout hook.
+func (c *Conn) SetAfterReadTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadTimeout = fn
+}
+
+// AppendAfterReadTimeout adds fn to the chain of AfterReadTimeout hooks.
+func (c *Conn) AppendAfterReadTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadTimeout = append(slices.Clip(c.chains.AfterReadTimeout), fn)
+}
+
+// AfterReadTimeoutHook returns the AfterReadTimeout hook followed by
+// any hooks added with AppendAfterReadTimeout.
+func (c *Conn) AfterReadTimeoutHook() func(*Conn) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadTimeout
+	chain := c.chains.AfterReadTimeout
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) {
+			obs.AfterCall(conn, "ReadTimeout")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn){hook}, chain...)
+		}
+		hook = func(conn *Conn) {
+			for _, hook := range chain {
+				hook(conn)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadTimeout", r)
+				}
+			}()
+			guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadTimeout", time.Now())
+			timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterFirstRead sets the AfterFirstRead hook.
+func (c *Conn) SetAfterFirstRead(fn func(*Conn, time.Duration)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterFirstRead = fn
+}
+
+// AppendAfterFirstRead adds fn to the chain of AfterFirstRead hooks.
+func (c *Conn) AppendAfterFirstRead(fn func(*Conn, time.Duration)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterFirstRead = append(slices.Clip(c.chains.AfterFirstRead), fn)
+}
+
+// AfterFirstReadHook returns the AfterFirstRead hook followed by
+// any hooks added with AppendAfterFirstRead.
+func (c *Conn) AfterFirstReadHook() func(*Conn, time.Duration) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterFirstRead
+	chain := c.chains.AfterFirstRead
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, d time.Duration) {
+			obs.AfterCall(conn, "FirstRead", d)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Duration){hook}, chain...)
+		}
+		hook = func(conn *Conn, d time.Duration) {
+			for _, hook := range chain {
+				hook(conn, d)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, d time.Duration) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterFirstRead", r)
+				}
+			}()
+			guarded(conn, d)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, d time.Duration) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterFirstRead", time.Now())
+			timed(conn, d)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadFull sets the AfterReadFull hook.
+func (c *Conn) SetAfterReadFull(fn func(*Conn, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadFull = fn
+}
+
+// AppendAfterReadFull adds fn to the chain of AfterReadFull hooks.
+func (c *Conn) AppendAfterReadFull(fn func(*Conn, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadFull = append(slices.Clip(c.chains.AfterReadFull), fn)
+}
+
+// AfterReadFullHook returns the AfterReadFull hook followed by
+// any hooks added with AppendAfterReadFull.
+func (c *Conn) AfterReadFullHook() func(*Conn, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadFull
+	chain := c.chains.AfterReadFull
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, n int, err error) {
+			obs.AfterCall(conn, "ReadFull", n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, n int, err error) {
+			for _, hook := range chain {
+				hook(conn, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadFull", r)
+				}
+			}()
+			guarded(conn, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadFull", time.Now())
+			timed(conn, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeReadFrom sets the BeforeReadFrom hook.
+func (c *Conn) SetBeforeReadFrom(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeReadFrom = fn
+}
+
+// AppendBeforeReadFrom adds fn to the chain of BeforeReadFrom hooks.
+func (c *Conn) AppendBeforeReadFrom(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeReadFrom = append(slices.Clip(c.chains.BeforeReadFrom), fn)
+}
+
+// BeforeReadFromHook returns the BeforeReadFrom hook followed by
+// any hooks added with AppendBeforeReadFrom.
+func (c *Conn) BeforeReadFromHook() func(*Conn, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeReadFrom
+	chain := c.chains.BeforeReadFrom
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte) error {
+			return obs.BeforeCall(conn, "ReadFrom", b)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte) error {
+			for _, hook := range chain {
+				if err := hook(conn, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeReadFrom", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeReadFrom", time.Now())
+			return timed(conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadFrom sets the AfterReadFrom hook.
+func (c *Conn) SetAfterReadFrom(fn func(*Conn, []byte, int, net.Addr, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadFrom = fn
+}
+
+// AppendAfterReadFrom adds fn to the chain of AfterReadFrom hooks.
+func (c *Conn) AppendAfterReadFrom(fn func(*Conn, []byte, int, net.Addr, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadFrom = append(slices.Clip(c.chains.AfterReadFrom), fn)
+}
+
+// AfterReadFromHook returns the AfterReadFrom hook followed by
+// any hooks added with AppendAfterReadFrom.
+func (c *Conn) AfterReadFromHook() func(*Conn, []byte, int, net.Addr, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadFrom
+	chain := c.chains.AfterReadFrom
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, n int, addr net.Addr, err error) {
+			obs.AfterCall(conn, "ReadFrom", b, n, addr, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, net.Addr, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, addr net.Addr, err error) {
+			for _, hook := range chain {
+				hook(conn, b, n, addr, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, addr net.Addr, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadFrom", r)
+				}
+			}()
+			guarded(conn, b, n, addr, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, addr net.Addr, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadFrom", time.Now())
+			timed(conn, b, n, addr, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeReadMsgUDP sets the BeforeReadMsgUDP hook.
+func (c *Conn) SetBeforeReadMsgUDP(fn func(*Conn, []byte, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeReadMsgUDP = fn
+}
+
+// AppendBeforeReadMsgUDP adds fn to the chain of BeforeReadMsgUDP hooks.
+func (c *Conn) AppendBeforeReadMsgUDP(fn func(*Conn, []byte, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeReadMsgUDP = append(slices.Clip(c.chains.BeforeReadMsgUDP), fn)
+}
+
+// BeforeReadMsgUDPHook returns the BeforeReadMsgUDP hook followed by
+// any hooks added with AppendBeforeReadMsgUDP.
+func (c *Conn) BeforeReadMsgUDPHook() func(*Conn, []byte, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeReadMsgUDP
+	chain := c.chains.BeforeReadMsgUDP
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, oob []byte) error {
+			return obs.BeforeCall(conn, "ReadMsgUDP", b, oob)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, []byte) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, oob []byte) error {
+			for _, hook := range chain {
+				if err := hook(conn, b, oob); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, oob []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeReadMsgUDP", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b, oob)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, oob []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeReadMsgUDP", time.Now())
+			return timed(conn, b, oob)
+		}
+	}
+	return hook
+}
+
+// SetAfterReadMsgUDP sets the AfterReadMsgUDP hook.
+func (c *Conn) SetAfterReadMsgUDP(fn func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterReadMsgUDP = fn
+}
+
+// AppendAfterReadMsgUDP adds fn to the chain of AfterReadMsgUDP hooks.
+func (c *Conn) AppendAfterReadMsgUDP(fn func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterReadMsgUDP = append(slices.Clip(c.chains.AfterReadMsgUDP), fn)
+}
+
+// AfterReadMsgUDPHook returns the AfterReadMsgUDP hook followed by
+// any hooks added with AppendAfterReadMsgUDP.
+func (c *Conn) AfterReadMsgUDPHook() func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterReadMsgUDP
+	chain := c.chains.AfterReadMsgUDP
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, oob []byte, n int, oobn int, flags int, addr *net.UDPAddr, err error) {
+			obs.AfterCall(conn, "ReadMsgUDP", b, oob, n, oobn, flags, addr, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, oob []byte, n int, oobn int, flags int, addr *net.UDPAddr, err error) {
+			for _, hook := range chain {
+				hook(conn, b, oob, n, oobn, flags, addr, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, oob []byte, n int, oobn int, flags int, addr *net.UDPAddr, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterReadMsgUDP", r)
+				}
+			}()
+			guarded(conn, b, oob, n, oobn, flags, addr, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, oob []byte, n int, oobn int, flags int, addr *net.UDPAddr, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterReadMsgUDP", time.Now())
+			timed(conn, b, oob, n, oobn, flags, addr, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeWrite sets the BeforeWrite hook.
+func (c *Conn) SetBeforeWrite(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeWrite = fn
+}
+
+// AppendBeforeWrite adds fn to the chain of BeforeWrite hooks.
+func (c *Conn) AppendBeforeWrite(fn func(*Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeWrite = append(slices.Clip(c.chains.BeforeWrite), fn)
+}
+
+// BeforeWriteHook returns the BeforeWrite hook followed by
+// any hooks added with AppendBeforeWrite.
+func (c *Conn) BeforeWriteHook() func(*Conn, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeWrite
+	chain := c.chains.BeforeWrite
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte) error {
+			return obs.BeforeCall(conn, "Write", b)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte) error {
+			for _, hook := range chain {
+				if err := hook(conn, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeWrite", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeWrite", time.Now())
+			return timed(conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterWrite sets the AfterWrite hook.
+func (c *Conn) SetAfterWrite(fn func(*Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWrite = fn
+}
+
+// AppendAfterWrite adds fn to the chain of AfterWrite hooks.
+func (c *Conn) AppendAfterWrite(fn func(*Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWrite = append(slices.Clip(c.chains.AfterWrite), fn)
+}
+
+// AfterWriteHook returns the AfterWrite hook followed by
+// any hooks added with AppendAfterWrite.
+func (c *Conn) AfterWriteHook() func(*Conn, []byte, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWrite
+	chain := c.chains.AfterWrite
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, n int, err error) {
+			obs.AfterCall(conn, "Write", b, n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			for _, hook := range chain {
+				hook(conn, b, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWrite", r)
+				}
+			}()
+			guarded(conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWrite", time.Now())
+			timed(conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeWriteCtx sets the BeforeWriteCtx hook.
+func (c *Conn) SetBeforeWriteCtx(fn func(context.Context, *Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeWriteCtx = fn
+}
+
+// AppendBeforeWriteCtx adds fn to the chain of BeforeWriteCtx hooks.
+func (c *Conn) AppendBeforeWriteCtx(fn func(context.Context, *Conn, []byte) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeWriteCtx = append(slices.Clip(c.chains.BeforeWriteCtx), fn)
+}
+
+// BeforeWriteCtxHook returns the BeforeWriteCtx hook followed by
+// any hooks added with AppendBeforeWriteCtx.
+func (c *Conn) BeforeWriteCtxHook() func(context.Context, *Conn, []byte) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeWriteCtx
+	chain := c.chains.BeforeWriteCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn, []byte) error{hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn, b []byte) error {
+			for _, hook := range chain {
+				if err := hook(ctx, conn, b); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn, b []byte) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeWriteCtx", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(ctx, conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn, b []byte) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeWriteCtx", time.Now())
+			return timed(ctx, conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteCtx sets the AfterWriteCtx hook.
+func (c *Conn) SetAfterWriteCtx(fn func(context.Context, *Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteCtx = fn
+}
+
+// AppendAfterWriteCtx adds fn to the chain of AfterWriteCtx hooks.
+func (c *Conn) AppendAfterWriteCtx(fn func(context.Context, *Conn, []byte, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteCtx = append(slices.Clip(c.chains.AfterWriteCtx), fn)
+}
+
+// AfterWriteCtxHook returns the AfterWriteCtx hook followed by
+// any hooks added with AppendAfterWriteCtx.
+func (c *Conn) AfterWriteCtxHook() func(context.Context, *Conn, []byte, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteCtx
+	chain := c.chains.AfterWriteCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn, []byte, int, error){hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			for _, hook := range chain {
+				hook(ctx, conn, b, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteCtx", r)
+				}
+			}()
+			guarded(ctx, conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn, b []byte, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteCtx", time.Now())
+			timed(ctx, conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteTimed sets the AfterWriteTimed hook.
+func (c *Conn) SetAfterWriteTimed(fn func(*Conn, []byte, int, time.Duration, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteTimed = fn
+}
+
+// AppendAfterWriteTimed adds fn to the chain of AfterWriteTimed hooks.
+func (c *Conn) AppendAfterWriteTimed(fn func(*Conn, []byte, int, time.Duration, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteTimed = append(slices.Clip(c.chains.AfterWriteTimed), fn)
+}
+
+// AfterWriteTimedHook returns the AfterWriteTimed hook followed by
+// any hooks added with AppendAfterWriteTimed.
+func (c *Conn) AfterWriteTimedHook() func(*Conn, []byte, int, time.Duration, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteTimed
+	chain := c.chains.AfterWriteTimed
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, time.Duration, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			for _, hook := range chain {
+				hook(conn, b, n, d, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteTimed", r)
+				}
+			}()
+			guarded(conn, b, n, d, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, d time.Duration, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteTimed", time.Now())
+			timed(conn, b, n, d, err)
+		}
+	}
+	return hook
+}
+
+// SetTransformWrite sets the TransformWrite hook.
+func (c *Conn) SetTransformWrite(fn func(*Conn, []byte, int, error) (int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.TransformWrite = fn
+}
+
+// AppendTransformWrite adds fn to the chain of TransformWrite hooks.
+func (c *Conn) AppendTransformWrite(fn func(*Conn, []byte, int, error) (int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.TransformWrite = append(slices.Clip(c.chains.TransformWrite), fn)
+}
+
+// TransformWriteHook returns the TransformWrite hook followed by
+// any hooks added with AppendTransformWrite.
+func (c *Conn) TransformWriteHook() func(*Conn, []byte, int, error) (int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.TransformWrite
+	chain := c.chains.TransformWrite
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, int, error) (int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, n int, err error) (int, error) {
+			for _, hook := range chain {
+				n, err = hook(conn, b, n, err)
+			}
+			return n, err
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, n int, err error) (rn int, rerr error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "TransformWrite", r)
+					rn, rerr = n, err
+				}
+			}()
+			return guarded(conn, b, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, n int, err error) (int, error) {
+			defer reportSlowHook(onSlow, threshold, conn, "TransformWrite", time.Now())
+			return timed(conn, b, n, err)
+		}
+	}
+	return hook
+}
+
+// SetWriteInterceptor sets the WriteInterceptor hook.
+func (c *Conn) SetWriteInterceptor(fn func(*Conn, []byte) (bool, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.WriteInterceptor = fn
+}
+
+// AppendWriteInterceptor adds fn to the chain of WriteInterceptor hooks.
+func (c *Conn) AppendWriteInterceptor(fn func(*Conn, []byte) (bool, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.WriteInterceptor = append(slices.Clip(c.chains.WriteInterceptor), fn)
+}
+
+// WriteInterceptorHook returns the WriteInterceptor hook followed by
+// any hooks added with AppendWriteInterceptor.
+func (c *Conn) WriteInterceptorHook() func(*Conn, []byte) (bool, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.WriteInterceptor
+	chain := c.chains.WriteInterceptor
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte) (bool, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte) (bool, int, error) {
+			for _, hook := range chain {
+				if handled, n, err := hook(conn, b); handled {
+					return true, n, err
+				}
+			}
+			return false, 0, nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte) (handled bool, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "WriteInterceptor", r)
+					handled, n, err = true, 0, ErrHookPanic
+				}
+			}()
+			return guarded(conn, b)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte) (bool, int, error) {
+			defer reportSlowHook(onSlow, threshold, conn, "WriteInterceptor", time.Now())
+			return timed(conn, b)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteTimeout sets the AfterWriteTimeout hook.
+func (c *Conn) SetAfterWriteTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteTimeout = fn
+}
+
+// AppendAfterWriteTimeout adds fn to the chain of AfterWriteTimeout hooks.
+func (c *Conn) AppendAfterWriteTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteTimeout = append(slices.Clip(c.chains.AfterWriteTimeout), fn)
+}
+
+// AfterWriteTimeoutHook returns the AfterWriteTimeout hook followed by
+// any hooks added with AppendAfterWriteTimeout.
+func (c *Conn) AfterWriteTimeoutHook() func(*Conn) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteTimeout
+	chain := c.chains.AfterWriteTimeout
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) {
+			obs.AfterCall(conn, "WriteTimeout")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn){hook}, chain...)
+		}
+		hook = func(conn *Conn) {
+			for _, hook := range chain {
+				hook(conn)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteTimeout", r)
+				}
+			}()
+			guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteTimeout", time.Now())
+			timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterFirstWrite sets the AfterFirstWrite hook.
+func (c *Conn) SetAfterFirstWrite(fn func(*Conn, time.Duration)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterFirstWrite = fn
+}
+
+// AppendAfterFirstWrite adds fn to the chain of AfterFirstWrite hooks.
+func (c *Conn) AppendAfterFirstWrite(fn func(*Conn, time.Duration)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterFirstWrite = append(slices.Clip(c.chains.AfterFirstWrite), fn)
+}
+
+// AfterFirstWriteHook returns the AfterFirstWrite hook followed by
+// any hooks added with AppendAfterFirstWrite.
+func (c *Conn) AfterFirstWriteHook() func(*Conn, time.Duration) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterFirstWrite
+	chain := c.chains.AfterFirstWrite
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, d time.Duration) {
+			obs.AfterCall(conn, "FirstWrite", d)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Duration){hook}, chain...)
+		}
+		hook = func(conn *Conn, d time.Duration) {
+			for _, hook := range chain {
+				hook(conn, d)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, d time.Duration) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterFirstWrite", r)
+				}
+			}()
+			guarded(conn, d)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, d time.Duration) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterFirstWrite", time.Now())
+			timed(conn, d)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteAll sets the AfterWriteAll hook.
+func (c *Conn) SetAfterWriteAll(fn func(*Conn, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteAll = fn
+}
+
+// AppendAfterWriteAll adds fn to the chain of AfterWriteAll hooks.
+func (c *Conn) AppendAfterWriteAll(fn func(*Conn, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteAll = append(slices.Clip(c.chains.AfterWriteAll), fn)
+}
+
+// AfterWriteAllHook returns the AfterWriteAll hook followed by
+// any hooks added with AppendAfterWriteAll.
+func (c *Conn) AfterWriteAllHook() func(*Conn, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteAll
+	chain := c.chains.AfterWriteAll
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, n int, err error) {
+			obs.AfterCall(conn, "WriteAll", n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, n int, err error) {
+			for _, hook := range chain {
+				hook(conn, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteAll", r)
+				}
+			}()
+			guarded(conn, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteAll", time.Now())
+			timed(conn, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeWriteBuffers sets the BeforeWriteBuffers hook.
+func (c *Conn) SetBeforeWriteBuffers(fn func(*Conn, *net.Buffers) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeWriteBuffers = fn
+}
+
+// AppendBeforeWriteBuffers adds fn to the chain of BeforeWriteBuffers hooks.
+func (c *Conn) AppendBeforeWriteBuffers(fn func(*Conn, *net.Buffers) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeWriteBuffers = append(slices.Clip(c.chains.BeforeWriteBuffers), fn)
+}
+
+// BeforeWriteBuffersHook returns the BeforeWriteBuffers hook followed by
+// any hooks added with AppendBeforeWriteBuffers.
+func (c *Conn) BeforeWriteBuffersHook() func(*Conn, *net.Buffers) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeWriteBuffers
+	chain := c.chains.BeforeWriteBuffers
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, bufs *net.Buffers) error {
+			return obs.BeforeCall(conn, "WriteBuffers", bufs)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, *net.Buffers) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, bufs *net.Buffers) error {
+			for _, hook := range chain {
+				if err := hook(conn, bufs); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, bufs *net.Buffers) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeWriteBuffers", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, bufs)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, bufs *net.Buffers) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeWriteBuffers", time.Now())
+			return timed(conn, bufs)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteBuffers sets the AfterWriteBuffers hook.
+func (c *Conn) SetAfterWriteBuffers(fn func(*Conn, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteBuffers = fn
+}
+
+// AppendAfterWriteBuffers adds fn to the chain of AfterWriteBuffers hooks.
+func (c *Conn) AppendAfterWriteBuffers(fn func(*Conn, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteBuffers = append(slices.Clip(c.chains.AfterWriteBuffers), fn)
+}
+
+// AfterWriteBuffersHook returns the AfterWriteBuffers hook followed by
+// any hooks added with AppendAfterWriteBuffers.
+func (c *Conn) AfterWriteBuffersHook() func(*Conn, int64, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteBuffers
+	chain := c.chains.AfterWriteBuffers
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, n int64, err error) {
+			obs.AfterCall(conn, "WriteBuffers", n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, int64, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, n int64, err error) {
+			for _, hook := range chain {
+				hook(conn, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, n int64, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteBuffers", r)
+				}
+			}()
+			guarded(conn, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, n int64, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteBuffers", time.Now())
+			timed(conn, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeWriteTo sets the BeforeWriteTo hook.
+func (c *Conn) SetBeforeWriteTo(fn func(*Conn, []byte, net.Addr) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeWriteTo = fn
+}
+
+// AppendBeforeWriteTo adds fn to the chain of BeforeWriteTo hooks.
+func (c *Conn) AppendBeforeWriteTo(fn func(*Conn, []byte, net.Addr) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeWriteTo = append(slices.Clip(c.chains.BeforeWriteTo), fn)
+}
+
+// BeforeWriteToHook returns the BeforeWriteTo hook followed by
+// any hooks added with AppendBeforeWriteTo.
+func (c *Conn) BeforeWriteToHook() func(*Conn, []byte, net.Addr) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeWriteTo
+	chain := c.chains.BeforeWriteTo
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, addr net.Addr) error {
+			return obs.BeforeCall(conn, "WriteTo", b, addr)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, net.Addr) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, addr net.Addr) error {
+			for _, hook := range chain {
+				if err := hook(conn, b, addr); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, addr net.Addr) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeWriteTo", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b, addr)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, addr net.Addr) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeWriteTo", time.Now())
+			return timed(conn, b, addr)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteTo sets the AfterWriteTo hook.
+func (c *Conn) SetAfterWriteTo(fn func(*Conn, []byte, net.Addr, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteTo = fn
+}
+
+// AppendAfterWriteTo adds fn to the chain of AfterWriteTo hooks.
+func (c *Conn) AppendAfterWriteTo(fn func(*Conn, []byte, net.Addr, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteTo = append(slices.Clip(c.chains.AfterWriteTo), fn)
+}
+
+// AfterWriteToHook returns the AfterWriteTo hook followed by
+// any hooks added with AppendAfterWriteTo.
+func (c *Conn) AfterWriteToHook() func(*Conn, []byte, net.Addr, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteTo
+	chain := c.chains.AfterWriteTo
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, addr net.Addr, n int, err error) {
+			obs.AfterCall(conn, "WriteTo", b, addr, n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, net.Addr, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, addr net.Addr, n int, err error) {
+			for _, hook := range chain {
+				hook(conn, b, addr, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, addr net.Addr, n int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteTo", r)
+				}
+			}()
+			guarded(conn, b, addr, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, addr net.Addr, n int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteTo", time.Now())
+			timed(conn, b, addr, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeWriteMsgUDP sets the BeforeWriteMsgUDP hook.
+func (c *Conn) SetBeforeWriteMsgUDP(fn func(*Conn, []byte, []byte, *net.UDPAddr) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeWriteMsgUDP = fn
+}
+
+// AppendBeforeWriteMsgUDP adds fn to the chain of BeforeWriteMsgUDP hooks.
+func (c *Conn) AppendBeforeWriteMsgUDP(fn func(*Conn, []byte, []byte, *net.UDPAddr) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeWriteMsgUDP = append(slices.Clip(c.chains.BeforeWriteMsgUDP), fn)
+}
+
+// BeforeWriteMsgUDPHook returns the BeforeWriteMsgUDP hook followed by
+// any hooks added with AppendBeforeWriteMsgUDP.
+func (c *Conn) BeforeWriteMsgUDPHook() func(*Conn, []byte, []byte, *net.UDPAddr) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeWriteMsgUDP
+	chain := c.chains.BeforeWriteMsgUDP
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr) error {
+			return obs.BeforeCall(conn, "WriteMsgUDP", b, oob, addr)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, []byte, *net.UDPAddr) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr) error {
+			for _, hook := range chain {
+				if err := hook(conn, b, oob, addr); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeWriteMsgUDP", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, b, oob, addr)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeWriteMsgUDP", time.Now())
+			return timed(conn, b, oob, addr)
+		}
+	}
+	return hook
+}
+
+// SetAfterWriteMsgUDP sets the AfterWriteMsgUDP hook.
+func (c *Conn) SetAfterWriteMsgUDP(fn func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterWriteMsgUDP = fn
+}
+
+// AppendAfterWriteMsgUDP adds fn to the chain of AfterWriteMsgUDP hooks.
+func (c *Conn) AppendAfterWriteMsgUDP(fn func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterWriteMsgUDP = append(slices.Clip(c.chains.AfterWriteMsgUDP), fn)
+}
+
+// AfterWriteMsgUDPHook returns the AfterWriteMsgUDP hook followed by
+// any hooks added with AppendAfterWriteMsgUDP.
+func (c *Conn) AfterWriteMsgUDPHook() func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterWriteMsgUDP
+	chain := c.chains.AfterWriteMsgUDP
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr, n int, oobn int, err error) {
+			obs.AfterCall(conn, "WriteMsgUDP", b, oob, addr, n, oobn, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr, n int, oobn int, err error) {
+			for _, hook := range chain {
+				hook(conn, b, oob, addr, n, oobn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr, n int, oobn int, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterWriteMsgUDP", r)
+				}
+			}()
+			guarded(conn, b, oob, addr, n, oobn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, b []byte, oob []byte, addr *net.UDPAddr, n int, oobn int, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterWriteMsgUDP", time.Now())
+			timed(conn, b, oob, addr, n, oobn, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeClose sets the BeforeClose hook.
+func (c *Conn) SetBeforeClose(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeClose = fn
+}
+
+// AppendBeforeClose adds fn to the chain of BeforeClose hooks.
+func (c *Conn) AppendBeforeClose(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeClose = append(slices.Clip(c.chains.BeforeClose), fn)
+}
+
+// BeforeCloseHook returns the BeforeClose hook followed by
+// any hooks added with AppendBeforeClose.
+func (c *Conn) BeforeCloseHook() func(*Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeClose
+	chain := c.chains.BeforeClose
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) error {
+			return obs.BeforeCall(conn, "Close")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn) error{hook}, chain...)
+		}
+		hook = func(conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeClose", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeClose", time.Now())
+			return timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterClose sets the AfterClose hook.
+func (c *Conn) SetAfterClose(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterClose = fn
+}
+
+// AppendAfterClose adds fn to the chain of AfterClose hooks.
+func (c *Conn) AppendAfterClose(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterClose = append(slices.Clip(c.chains.AfterClose), fn)
+}
+
+// AfterCloseHook returns the AfterClose hook followed by
+// any hooks added with AppendAfterClose.
+func (c *Conn) AfterCloseHook() func(*Conn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterClose
+	chain := c.chains.AfterClose
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, err error) {
+			obs.AfterCall(conn, "Close", err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, err error) {
+			for _, hook := range chain {
+				hook(conn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterClose", r)
+				}
+			}()
+			guarded(conn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterClose", time.Now())
+			timed(conn, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeCloseWrite sets the BeforeCloseWrite hook.
+func (c *Conn) SetBeforeCloseWrite(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeCloseWrite = fn
+}
+
+// AppendBeforeCloseWrite adds fn to the chain of BeforeCloseWrite hooks.
+func (c *Conn) AppendBeforeCloseWrite(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeCloseWrite = append(slices.Clip(c.chains.BeforeCloseWrite), fn)
+}
+
+// BeforeCloseWriteHook returns the BeforeCloseWrite hook followed by
+// any hooks added with AppendBeforeCloseWrite.
+func (c *Conn) BeforeCloseWriteHook() func(*Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeCloseWrite
+	chain := c.chains.BeforeCloseWrite
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) error {
+			return obs.BeforeCall(conn, "CloseWrite")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn) error{hook}, chain...)
+		}
+		hook = func(conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeCloseWrite", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeCloseWrite", time.Now())
+			return timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterCloseWrite sets the AfterCloseWrite hook.
+func (c *Conn) SetAfterCloseWrite(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterCloseWrite = fn
+}
+
+// AppendAfterCloseWrite adds fn to the chain of AfterCloseWrite hooks.
+func (c *Conn) AppendAfterCloseWrite(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterCloseWrite = append(slices.Clip(c.chains.AfterCloseWrite), fn)
+}
+
+// AfterCloseWriteHook returns the AfterCloseWrite hook followed by
+// any hooks added with AppendAfterCloseWrite.
+func (c *Conn) AfterCloseWriteHook() func(*Conn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterCloseWrite
+	chain := c.chains.AfterCloseWrite
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, err error) {
+			obs.AfterCall(conn, "CloseWrite", err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, err error) {
+			for _, hook := range chain {
+				hook(conn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterCloseWrite", r)
+				}
+			}()
+			guarded(conn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterCloseWrite", time.Now())
+			timed(conn, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeCloseRead sets the BeforeCloseRead hook.
+func (c *Conn) SetBeforeCloseRead(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeCloseRead = fn
+}
+
+// AppendBeforeCloseRead adds fn to the chain of BeforeCloseRead hooks.
+func (c *Conn) AppendBeforeCloseRead(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeCloseRead = append(slices.Clip(c.chains.BeforeCloseRead), fn)
+}
+
+// BeforeCloseReadHook returns the BeforeCloseRead hook followed by
+// any hooks added with AppendBeforeCloseRead.
+func (c *Conn) BeforeCloseReadHook() func(*Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeCloseRead
+	chain := c.chains.BeforeCloseRead
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) error {
+			return obs.BeforeCall(conn, "CloseRead")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn) error{hook}, chain...)
+		}
+		hook = func(conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeCloseRead", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeCloseRead", time.Now())
+			return timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterCloseRead sets the AfterCloseRead hook.
+func (c *Conn) SetAfterCloseRead(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterCloseRead = fn
+}
+
+// AppendAfterCloseRead adds fn to the chain of AfterCloseRead hooks.
+func (c *Conn) AppendAfterCloseRead(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterCloseRead = append(slices.Clip(c.chains.AfterCloseRead), fn)
+}
+
+// AfterCloseReadHook returns the AfterCloseRead hook followed by
+// any hooks added with AppendAfterCloseRead.
+func (c *Conn) AfterCloseReadHook() func(*Conn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterCloseRead
+	chain := c.chains.AfterCloseRead
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, err error) {
+			obs.AfterCall(conn, "CloseRead", err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, err error) {
+			for _, hook := range chain {
+				hook(conn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterCloseRead", r)
+				}
+			}()
+			guarded(conn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterCloseRead", time.Now())
+			timed(conn, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeCloseCtx sets the BeforeCloseCtx hook.
+func (c *Conn) SetBeforeCloseCtx(fn func(context.Context, *Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeCloseCtx = fn
+}
+
+// AppendBeforeCloseCtx adds fn to the chain of BeforeCloseCtx hooks.
+func (c *Conn) AppendBeforeCloseCtx(fn func(context.Context, *Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeCloseCtx = append(slices.Clip(c.chains.BeforeCloseCtx), fn)
+}
+
+// BeforeCloseCtxHook returns the BeforeCloseCtx hook followed by
+// any hooks added with AppendBeforeCloseCtx.
+func (c *Conn) BeforeCloseCtxHook() func(context.Context, *Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeCloseCtx
+	chain := c.chains.BeforeCloseCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn) error{hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(ctx, conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeCloseCtx", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(ctx, conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeCloseCtx", time.Now())
+			return timed(ctx, conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterCloseCtx sets the AfterCloseCtx hook.
+func (c *Conn) SetAfterCloseCtx(fn func(context.Context, *Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterCloseCtx = fn
+}
+
+// AppendAfterCloseCtx adds fn to the chain of AfterCloseCtx hooks.
+func (c *Conn) AppendAfterCloseCtx(fn func(context.Context, *Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterCloseCtx = append(slices.Clip(c.chains.AfterCloseCtx), fn)
+}
+
+// AfterCloseCtxHook returns the AfterCloseCtx hook followed by
+// any hooks added with AppendAfterCloseCtx.
+func (c *Conn) AfterCloseCtxHook() func(context.Context, *Conn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterCloseCtx
+	chain := c.chains.AfterCloseCtx
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(context.Context, *Conn, error){hook}, chain...)
+		}
+		hook = func(ctx context.Context, conn *Conn, err error) {
+			for _, hook := range chain {
+				hook(ctx, conn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(ctx context.Context, conn *Conn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterCloseCtx", r)
+				}
+			}()
+			guarded(ctx, conn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(ctx context.Context, conn *Conn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterCloseCtx", time.Now())
+			timed(ctx, conn, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterLocalAddr sets the AfterLocalAddr hook.
+func (c *Conn) SetAfterLocalAddr(fn func(*Conn, net.Addr)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterLocalAddr = fn
+}
+
+// AppendAfterLocalAddr adds fn to the chain of AfterLocalAddr hooks.
+func (c *Conn) AppendAfterLocalAddr(fn func(*Conn, net.Addr)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterLocalAddr = append(slices.Clip(c.chains.AfterLocalAddr), fn)
+}
+
+// AfterLocalAddrHook returns the AfterLocalAddr hook followed by
+// any hooks added with AppendAfterLocalAddr.
+func (c *Conn) AfterLocalAddrHook() func(*Conn, net.Addr) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterLocalAddr
+	chain := c.chains.AfterLocalAddr
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, addr net.Addr) {
+			obs.AfterCall(conn, "LocalAddr", addr)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, net.Addr){hook}, chain...)
+		}
+		hook = func(conn *Conn, addr net.Addr) {
+			for _, hook := range chain {
+				hook(conn, addr)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, addr net.Addr) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterLocalAddr", r)
+				}
+			}()
+			guarded(conn, addr)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, addr net.Addr) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterLocalAddr", time.Now())
+			timed(conn, addr)
+		}
+	}
+	return hook
+}
+
+// SetAfterRemoteAddr sets the AfterRemoteAddr hook.
+func (c *Conn) SetAfterRemoteAddr(fn func(*Conn, net.Addr)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterRemoteAddr = fn
+}
+
+// AppendAfterRemoteAddr adds fn to the chain of AfterRemoteAddr hooks.
+func (c *Conn) AppendAfterRemoteAddr(fn func(*Conn, net.Addr)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterRemoteAddr = append(slices.Clip(c.chains.AfterRemoteAddr), fn)
+}
+
+// AfterRemoteAddrHook returns the AfterRemoteAddr hook followed by
+// any hooks added with AppendAfterRemoteAddr.
+func (c *Conn) AfterRemoteAddrHook() func(*Conn, net.Addr) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterRemoteAddr
+	chain := c.chains.AfterRemoteAddr
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, addr net.Addr) {
+			obs.AfterCall(conn, "RemoteAddr", addr)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, net.Addr){hook}, chain...)
+		}
+		hook = func(conn *Conn, addr net.Addr) {
+			for _, hook := range chain {
+				hook(conn, addr)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, addr net.Addr) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterRemoteAddr", r)
+				}
+			}()
+			guarded(conn, addr)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, addr net.Addr) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterRemoteAddr", time.Now())
+			timed(conn, addr)
+		}
+	}
+	return hook
+}
+
+// SetBeforeSetDeadline sets the BeforeSetDeadline hook.
+func (c *Conn) SetBeforeSetDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeSetDeadline = fn
+}
+
+// AppendBeforeSetDeadline adds fn to the chain of BeforeSetDeadline hooks.
+func (c *Conn) AppendBeforeSetDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeSetDeadline = append(slices.Clip(c.chains.BeforeSetDeadline), fn)
+}
+
+// BeforeSetDeadlineHook returns the BeforeSetDeadline hook followed by
+// any hooks added with AppendBeforeSetDeadline.
+func (c *Conn) BeforeSetDeadlineHook() func(*Conn, time.Time) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeSetDeadline
+	chain := c.chains.BeforeSetDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time) error {
+			return obs.BeforeCall(conn, "SetDeadline", t)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time) error {
+			for _, hook := range chain {
+				if err := hook(conn, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeSetDeadline", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, t)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeSetDeadline", time.Now())
+			return timed(conn, t)
+		}
+	}
+	return hook
+}
+
+// SetAfterSetDeadline sets the AfterSetDeadline hook.
+func (c *Conn) SetAfterSetDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterSetDeadline = fn
+}
+
+// AppendAfterSetDeadline adds fn to the chain of AfterSetDeadline hooks.
+func (c *Conn) AppendAfterSetDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterSetDeadline = append(slices.Clip(c.chains.AfterSetDeadline), fn)
+}
+
+// AfterSetDeadlineHook returns the AfterSetDeadline hook followed by
+// any hooks added with AppendAfterSetDeadline.
+func (c *Conn) AfterSetDeadlineHook() func(*Conn, time.Time, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterSetDeadline
+	chain := c.chains.AfterSetDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time, err error) {
+			obs.AfterCall(conn, "SetDeadline", t, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time, err error) {
+			for _, hook := range chain {
+				hook(conn, t, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterSetDeadline", r)
+				}
+			}()
+			guarded(conn, t, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterSetDeadline", time.Now())
+			timed(conn, t, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeSetReadDeadline sets the BeforeSetReadDeadline hook.
+func (c *Conn) SetBeforeSetReadDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeSetReadDeadline = fn
+}
+
+// AppendBeforeSetReadDeadline adds fn to the chain of BeforeSetReadDeadline hooks.
+func (c *Conn) AppendBeforeSetReadDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeSetReadDeadline = append(slices.Clip(c.chains.BeforeSetReadDeadline), fn)
+}
+
+// BeforeSetReadDeadlineHook returns the BeforeSetReadDeadline hook followed by
+// any hooks added with AppendBeforeSetReadDeadline.
+func (c *Conn) BeforeSetReadDeadlineHook() func(*Conn, time.Time) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeSetReadDeadline
+	chain := c.chains.BeforeSetReadDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time) error {
+			return obs.BeforeCall(conn, "SetReadDeadline", t)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time) error {
+			for _, hook := range chain {
+				if err := hook(conn, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeSetReadDeadline", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, t)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeSetReadDeadline", time.Now())
+			return timed(conn, t)
+		}
+	}
+	return hook
+}
+
+// SetAfterSetReadDeadline sets the AfterSetReadDeadline hook.
+func (c *Conn) SetAfterSetReadDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterSetReadDeadline = fn
+}
+
+// AppendAfterSetReadDeadline adds fn to the chain of AfterSetReadDeadline hooks.
+func (c *Conn) AppendAfterSetReadDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterSetReadDeadline = append(slices.Clip(c.chains.AfterSetReadDeadline), fn)
+}
+
+// AfterSetReadDeadlineHook returns the AfterSetReadDeadline hook followed by
+// any hooks added with AppendAfterSetReadDeadline.
+func (c *Conn) AfterSetReadDeadlineHook() func(*Conn, time.Time, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterSetReadDeadline
+	chain := c.chains.AfterSetReadDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time, err error) {
+			obs.AfterCall(conn, "SetReadDeadline", t, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time, err error) {
+			for _, hook := range chain {
+				hook(conn, t, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterSetReadDeadline", r)
+				}
+			}()
+			guarded(conn, t, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterSetReadDeadline", time.Now())
+			timed(conn, t, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeSetWriteDeadline sets the BeforeSetWriteDeadline hook.
+func (c *Conn) SetBeforeSetWriteDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeSetWriteDeadline = fn
+}
+
+// AppendBeforeSetWriteDeadline adds fn to the chain of BeforeSetWriteDeadline hooks.
+func (c *Conn) AppendBeforeSetWriteDeadline(fn func(*Conn, time.Time) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeSetWriteDeadline = append(slices.Clip(c.chains.BeforeSetWriteDeadline), fn)
+}
+
+// BeforeSetWriteDeadlineHook returns the BeforeSetWriteDeadline hook followed by
+// any hooks added with AppendBeforeSetWriteDeadline.
+func (c *Conn) BeforeSetWriteDeadlineHook() func(*Conn, time.Time) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeSetWriteDeadline
+	chain := c.chains.BeforeSetWriteDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time) error {
+			return obs.BeforeCall(conn, "SetWriteDeadline", t)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time) error {
+			for _, hook := range chain {
+				if err := hook(conn, t); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeSetWriteDeadline", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, t)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeSetWriteDeadline", time.Now())
+			return timed(conn, t)
+		}
+	}
+	return hook
+}
+
+// SetAfterSetWriteDeadline sets the AfterSetWriteDeadline hook.
+func (c *Conn) SetAfterSetWriteDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterSetWriteDeadline = fn
+}
+
+// AppendAfterSetWriteDeadline adds fn to the chain of AfterSetWriteDeadline hooks.
+func (c *Conn) AppendAfterSetWriteDeadline(fn func(*Conn, time.Time, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterSetWriteDeadline = append(slices.Clip(c.chains.AfterSetWriteDeadline), fn)
+}
+
+// AfterSetWriteDeadlineHook returns the AfterSetWriteDeadline hook followed by
+// any hooks added with AppendAfterSetWriteDeadline.
+func (c *Conn) AfterSetWriteDeadlineHook() func(*Conn, time.Time, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterSetWriteDeadline
+	chain := c.chains.AfterSetWriteDeadline
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, t time.Time, err error) {
+			obs.AfterCall(conn, "SetWriteDeadline", t, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, time.Time, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, t time.Time, err error) {
+			for _, hook := range chain {
+				hook(conn, t, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterSetWriteDeadline", r)
+				}
+			}()
+			guarded(conn, t, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, t time.Time, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterSetWriteDeadline", time.Now())
+			timed(conn, t, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterSetSocketOption sets the AfterSetSocketOption hook.
+func (c *Conn) SetAfterSetSocketOption(fn func(*Conn, string, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterSetSocketOption = fn
+}
+
+// AppendAfterSetSocketOption adds fn to the chain of AfterSetSocketOption hooks.
+func (c *Conn) AppendAfterSetSocketOption(fn func(*Conn, string, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterSetSocketOption = append(slices.Clip(c.chains.AfterSetSocketOption), fn)
+}
+
+// AfterSetSocketOptionHook returns the AfterSetSocketOption hook followed by
+// any hooks added with AppendAfterSetSocketOption.
+func (c *Conn) AfterSetSocketOptionHook() func(*Conn, string, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterSetSocketOption
+	chain := c.chains.AfterSetSocketOption
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, name string, err error) {
+			obs.AfterCall(conn, "SetSocketOption", name, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, string, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, name string, err error) {
+			for _, hook := range chain {
+				hook(conn, name, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, name string, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterSetSocketOption", r)
+				}
+			}()
+			guarded(conn, name, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, name string, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterSetSocketOption", time.Now())
+			timed(conn, name, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeCopyFrom sets the BeforeCopyFrom hook.
+func (c *Conn) SetBeforeCopyFrom(fn func(*Conn, io.Reader) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeCopyFrom = fn
+}
+
+// AppendBeforeCopyFrom adds fn to the chain of BeforeCopyFrom hooks.
+func (c *Conn) AppendBeforeCopyFrom(fn func(*Conn, io.Reader) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeCopyFrom = append(slices.Clip(c.chains.BeforeCopyFrom), fn)
+}
+
+// BeforeCopyFromHook returns the BeforeCopyFrom hook followed by
+// any hooks added with AppendBeforeCopyFrom.
+func (c *Conn) BeforeCopyFromHook() func(*Conn, io.Reader) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeCopyFrom
+	chain := c.chains.BeforeCopyFrom
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, r io.Reader) error {
+			return obs.BeforeCall(conn, "CopyFrom", r)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, io.Reader) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, r io.Reader) error {
+			for _, hook := range chain {
+				if err := hook(conn, r); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, r io.Reader) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeCopyFrom", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, r)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, r io.Reader) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeCopyFrom", time.Now())
+			return timed(conn, r)
+		}
+	}
+	return hook
+}
+
+// SetAfterCopyFrom sets the AfterCopyFrom hook.
+func (c *Conn) SetAfterCopyFrom(fn func(*Conn, io.Reader, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterCopyFrom = fn
+}
+
+// AppendAfterCopyFrom adds fn to the chain of AfterCopyFrom hooks.
+func (c *Conn) AppendAfterCopyFrom(fn func(*Conn, io.Reader, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterCopyFrom = append(slices.Clip(c.chains.AfterCopyFrom), fn)
+}
+
+// AfterCopyFromHook returns the AfterCopyFrom hook followed by
+// any hooks added with AppendAfterCopyFrom.
+func (c *Conn) AfterCopyFromHook() func(*Conn, io.Reader, int64, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterCopyFrom
+	chain := c.chains.AfterCopyFrom
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, r io.Reader, n int64, err error) {
+			obs.AfterCall(conn, "CopyFrom", r, n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, io.Reader, int64, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, r io.Reader, n int64, err error) {
+			for _, hook := range chain {
+				hook(conn, r, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, r io.Reader, n int64, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterCopyFrom", r)
+				}
+			}()
+			guarded(conn, r, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, r io.Reader, n int64, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterCopyFrom", time.Now())
+			timed(conn, r, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeCopyTo sets the BeforeCopyTo hook.
+func (c *Conn) SetBeforeCopyTo(fn func(*Conn, io.Writer) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeCopyTo = fn
+}
+
+// AppendBeforeCopyTo adds fn to the chain of BeforeCopyTo hooks.
+func (c *Conn) AppendBeforeCopyTo(fn func(*Conn, io.Writer) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeCopyTo = append(slices.Clip(c.chains.BeforeCopyTo), fn)
+}
+
+// BeforeCopyToHook returns the BeforeCopyTo hook followed by
+// any hooks added with AppendBeforeCopyTo.
+func (c *Conn) BeforeCopyToHook() func(*Conn, io.Writer) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeCopyTo
+	chain := c.chains.BeforeCopyTo
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, w io.Writer) error {
+			return obs.BeforeCall(conn, "CopyTo", w)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, io.Writer) error{hook}, chain...)
+		}
+		hook = func(conn *Conn, w io.Writer) error {
+			for _, hook := range chain {
+				if err := hook(conn, w); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, w io.Writer) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeCopyTo", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn, w)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, w io.Writer) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeCopyTo", time.Now())
+			return timed(conn, w)
+		}
+	}
+	return hook
+}
+
+// SetAfterCopyTo sets the AfterCopyTo hook.
+func (c *Conn) SetAfterCopyTo(fn func(*Conn, io.Writer, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterCopyTo = fn
+}
+
+// AppendAfterCopyTo adds fn to the chain of AfterCopyTo hooks.
+func (c *Conn) AppendAfterCopyTo(fn func(*Conn, io.Writer, int64, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterCopyTo = append(slices.Clip(c.chains.AfterCopyTo), fn)
+}
+
+// AfterCopyToHook returns the AfterCopyTo hook followed by
+// any hooks added with AppendAfterCopyTo.
+func (c *Conn) AfterCopyToHook() func(*Conn, io.Writer, int64, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterCopyTo
+	chain := c.chains.AfterCopyTo
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, w io.Writer, n int64, err error) {
+			obs.AfterCall(conn, "CopyTo", w, n, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, io.Writer, int64, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, w io.Writer, n int64, err error) {
+			for _, hook := range chain {
+				hook(conn, w, n, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, w io.Writer, n int64, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterCopyTo", r)
+				}
+			}()
+			guarded(conn, w, n, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, w io.Writer, n int64, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterCopyTo", time.Now())
+			timed(conn, w, n, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeSyscallConn sets the BeforeSyscallConn hook.
+func (c *Conn) SetBeforeSyscallConn(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeSyscallConn = fn
+}
+
+// AppendBeforeSyscallConn adds fn to the chain of BeforeSyscallConn hooks.
+func (c *Conn) AppendBeforeSyscallConn(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeSyscallConn = append(slices.Clip(c.chains.BeforeSyscallConn), fn)
+}
+
+// BeforeSyscallConnHook returns the BeforeSyscallConn hook followed by
+// any hooks added with AppendBeforeSyscallConn.
+func (c *Conn) BeforeSyscallConnHook() func(*Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeSyscallConn
+	chain := c.chains.BeforeSyscallConn
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) error {
+			return obs.BeforeCall(conn, "SyscallConn")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn) error{hook}, chain...)
+		}
+		hook = func(conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeSyscallConn", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeSyscallConn", time.Now())
+			return timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterSyscallConn sets the AfterSyscallConn hook.
+func (c *Conn) SetAfterSyscallConn(fn func(*Conn, syscall.RawConn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterSyscallConn = fn
+}
+
+// AppendAfterSyscallConn adds fn to the chain of AfterSyscallConn hooks.
+func (c *Conn) AppendAfterSyscallConn(fn func(*Conn, syscall.RawConn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterSyscallConn = append(slices.Clip(c.chains.AfterSyscallConn), fn)
+}
+
+// AfterSyscallConnHook returns the AfterSyscallConn hook followed by
+// any hooks added with AppendAfterSyscallConn.
+func (c *Conn) AfterSyscallConnHook() func(*Conn, syscall.RawConn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterSyscallConn
+	chain := c.chains.AfterSyscallConn
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, raw syscall.RawConn, err error) {
+			obs.AfterCall(conn, "SyscallConn", raw, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, syscall.RawConn, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, raw syscall.RawConn, err error) {
+			for _, hook := range chain {
+				hook(conn, raw, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, raw syscall.RawConn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterSyscallConn", r)
+				}
+			}()
+			guarded(conn, raw, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, raw syscall.RawConn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterSyscallConn", time.Now())
+			timed(conn, raw, err)
+		}
+	}
+	return hook
+}
+
+// SetBeforeHandshake sets the BeforeHandshake hook.
+func (c *Conn) SetBeforeHandshake(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeHandshake = fn
+}
+
+// AppendBeforeHandshake adds fn to the chain of BeforeHandshake hooks.
+func (c *Conn) AppendBeforeHandshake(fn func(*Conn) error) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.BeforeHandshake = append(slices.Clip(c.chains.BeforeHandshake), fn)
+}
+
+// BeforeHandshakeHook returns the BeforeHandshake hook followed by
+// any hooks added with AppendBeforeHandshake.
+func (c *Conn) BeforeHandshakeHook() func(*Conn) error {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.BeforeHandshake
+	chain := c.chains.BeforeHandshake
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) error {
+			return obs.BeforeCall(conn, "Handshake")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn) error{hook}, chain...)
+		}
+		hook = func(conn *Conn) error {
+			for _, hook := range chain {
+				if err := hook(conn); err != nil {
+					return err
+				}
+			}
+			return nil
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) (err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "BeforeHandshake", r)
+					err = ErrHookPanic
+				}
+			}()
+			return guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) error {
+			defer reportSlowHook(onSlow, threshold, conn, "BeforeHandshake", time.Now())
+			return timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterHandshake sets the AfterHandshake hook.
+func (c *Conn) SetAfterHandshake(fn func(*Conn, tls.ConnectionState, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterHandshake = fn
+}
+
+// AppendAfterHandshake adds fn to the chain of AfterHandshake hooks.
+func (c *Conn) AppendAfterHandshake(fn func(*Conn, tls.ConnectionState, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterHandshake = append(slices.Clip(c.chains.AfterHandshake), fn)
+}
+
+// AfterHandshakeHook returns the AfterHandshake hook followed by
+// any hooks added with AppendAfterHandshake.
+func (c *Conn) AfterHandshakeHook() func(*Conn, tls.ConnectionState, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterHandshake
+	chain := c.chains.AfterHandshake
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, state tls.ConnectionState, err error) {
+			obs.AfterCall(conn, "Handshake", state, err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, tls.ConnectionState, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, state tls.ConnectionState, err error) {
+			for _, hook := range chain {
+				hook(conn, state, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, state tls.ConnectionState, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterHandshake", r)
+				}
+			}()
+			guarded(conn, state, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, state tls.ConnectionState, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterHandshake", time.Now())
+			timed(conn, state, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterIdleTimeout sets the AfterIdleTimeout hook.
+func (c *Conn) SetAfterIdleTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterIdleTimeout = fn
+}
+
+// AppendAfterIdleTimeout adds fn to the chain of AfterIdleTimeout hooks.
+func (c *Conn) AppendAfterIdleTimeout(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterIdleTimeout = append(slices.Clip(c.chains.AfterIdleTimeout), fn)
+}
+
+// AfterIdleTimeoutHook returns the AfterIdleTimeout hook followed by
+// any hooks added with AppendAfterIdleTimeout.
+func (c *Conn) AfterIdleTimeoutHook() func(*Conn) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterIdleTimeout
+	chain := c.chains.AfterIdleTimeout
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) {
+			obs.AfterCall(conn, "IdleTimeout")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn){hook}, chain...)
+		}
+		hook = func(conn *Conn) {
+			for _, hook := range chain {
+				hook(conn)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterIdleTimeout", r)
+				}
+			}()
+			guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterIdleTimeout", time.Now())
+			timed(conn)
+		}
+	}
+	return hook
+}
+
+// SetAfterStreamOpened sets the AfterStreamOpened hook.
+func (c *Conn) SetAfterStreamOpened(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterStreamOpened = fn
+}
+
+// AppendAfterStreamOpened adds fn to the chain of AfterStreamOpened hooks.
+func (c *Conn) AppendAfterStreamOpened(fn func(*Conn, error)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterStreamOpened = append(slices.Clip(c.chains.AfterStreamOpened), fn)
+}
+
+// AfterStreamOpenedHook returns the AfterStreamOpened hook followed by
+// any hooks added with AppendAfterStreamOpened.
+func (c *Conn) AfterStreamOpenedHook() func(*Conn, error) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterStreamOpened
+	chain := c.chains.AfterStreamOpened
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn, err error) {
+			obs.AfterCall(conn, "StreamOpened", err)
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn, error){hook}, chain...)
+		}
+		hook = func(conn *Conn, err error) {
+			for _, hook := range chain {
+				hook(conn, err)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn, err error) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterStreamOpened", r)
+				}
+			}()
+			guarded(conn, err)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn, err error) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterStreamOpened", time.Now())
+			timed(conn, err)
+		}
+	}
+	return hook
+}
+
+// SetAfterStreamClosed sets the AfterStreamClosed hook.
+func (c *Conn) SetAfterStreamClosed(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.AfterStreamClosed = fn
+}
+
+// AppendAfterStreamClosed adds fn to the chain of AfterStreamClosed hooks.
+func (c *Conn) AppendAfterStreamClosed(fn func(*Conn)) {
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.chains.AfterStreamClosed = append(slices.Clip(c.chains.AfterStreamClosed), fn)
+}
+
+// AfterStreamClosedHook returns the AfterStreamClosed hook followed by
+// any hooks added with AppendAfterStreamClosed.
+func (c *Conn) AfterStreamClosedHook() func(*Conn) {
+	if c.hooksDisabled.Load() {
+		return nil
+	}
+	c.hooksMu.RLock()
+	defer c.hooksMu.RUnlock()
+	hook := c.AfterStreamClosed
+	chain := c.chains.AfterStreamClosed
+	if obs := c.Observer; obs != nil {
+		chain = append(slices.Clip(chain), func(conn *Conn) {
+			obs.AfterCall(conn, "StreamClosed")
+		})
+	}
+	if len(chain) > 0 {
+		if hook != nil {
+			chain = append([]func(*Conn){hook}, chain...)
+		}
+		hook = func(conn *Conn) {
+			for _, hook := range chain {
+				hook(conn)
+			}
+		}
+	}
+	if hook == nil {
+		return nil
+	}
+	if onPanic := c.OnHookPanic; onPanic != nil {
+		guarded := hook
+		hook = func(conn *Conn) {
+			defer func() {
+				if r := recover(); r != nil {
+					onPanic(conn, "AfterStreamClosed", r)
+				}
+			}()
+			guarded(conn)
+		}
+	}
+	if c.MeasureHookLatency && c.OnSlowHook != nil {
+		timed, onSlow, threshold := hook, c.OnSlowHook, c.SlowHookThreshold
+		hook = func(conn *Conn) {
+			defer reportSlowHook(onSlow, threshold, conn, "AfterStreamClosed", time.Now())
+			timed(conn)
+		}
+	}
+	return hook
+}
+
+// hookChains holds hooks added with the Append methods of Conn. Slices are
+// never appended to in place, so they can be safely shared between Conns.
+type hookChains struct {
+	BeforeRead             []func(*Conn, []byte) error
+	AfterRead              []func(*Conn, []byte, int, error)
+	BeforeReadCtx          []func(context.Context, *Conn, []byte) error
+	AfterReadCtx           []func(context.Context, *Conn, []byte, int, error)
+	AfterReadTimed         []func(*Conn, []byte, int, time.Duration, error)
+	TransformRead          []func(*Conn, []byte, int, error) (int, error)
+	ReadInterceptor        []func(*Conn, []byte) (bool, int, error)
+	AfterEOF               []func(*Conn, int)
+	AfterReadTimeout       []func(*Conn)
+	AfterFirstRead         []func(*Conn, time.Duration)
+	AfterReadFull          []func(*Conn, int, error)
+	BeforeReadFrom         []func(*Conn, []byte) error
+	AfterReadFrom          []func(*Conn, []byte, int, net.Addr, error)
+	BeforeReadMsgUDP       []func(*Conn, []byte, []byte) error
+	AfterReadMsgUDP        []func(*Conn, []byte, []byte, int, int, int, *net.UDPAddr, error)
+	BeforeWrite            []func(*Conn, []byte) error
+	AfterWrite             []func(*Conn, []byte, int, error)
+	BeforeWriteCtx         []func(context.Context, *Conn, []byte) error
+	AfterWriteCtx          []func(context.Context, *Conn, []byte, int, error)
+	AfterWriteTimed        []func(*Conn, []byte, int, time.Duration, error)
+	TransformWrite         []func(*Conn, []byte, int, error) (int, error)
+	WriteInterceptor       []func(*Conn, []byte) (bool, int, error)
+	AfterWriteTimeout      []func(*Conn)
+	AfterFirstWrite        []func(*Conn, time.Duration)
+	AfterWriteAll          []func(*Conn, int, error)
+	BeforeWriteBuffers     []func(*Conn, *net.Buffers) error
+	AfterWriteBuffers      []func(*Conn, int64, error)
+	BeforeWriteTo          []func(*Conn, []byte, net.Addr) error
+	AfterWriteTo           []func(*Conn, []byte, net.Addr, int, error)
+	BeforeWriteMsgUDP      []func(*Conn, []byte, []byte, *net.UDPAddr) error
+	AfterWriteMsgUDP       []func(*Conn, []byte, []byte, *net.UDPAddr, int, int, error)
+	BeforeClose            []func(*Conn) error
+	AfterClose             []func(*Conn, error)
+	BeforeCloseWrite       []func(*Conn) error
+	AfterCloseWrite        []func(*Conn, error)
+	BeforeCloseRead        []func(*Conn) error
+	AfterCloseRead         []func(*Conn, error)
+	BeforeCloseCtx         []func(context.Context, *Conn) error
+	AfterCloseCtx          []func(context.Context, *Conn, error)
+	AfterLocalAddr         []func(*Conn, net.Addr)
+	AfterRemoteAddr        []func(*Conn, net.Addr)
+	BeforeSetDeadline      []func(*Conn, time.Time) error
+	AfterSetDeadline       []func(*Conn, time.Time, error)
+	BeforeSetReadDeadline  []func(*Conn, time.Time) error
+	AfterSetReadDeadline   []func(*Conn, time.Time, error)
+	BeforeSetWriteDeadline []func(*Conn, time.Time) error
+	AfterSetWriteDeadline  []func(*Conn, time.Time, error)
+	AfterSetSocketOption   []func(*Conn, string, error)
+	BeforeCopyFrom         []func(*Conn, io.Reader) error
+	AfterCopyFrom          []func(*Conn, io.Reader, int64, error)
+	BeforeCopyTo           []func(*Conn, io.Writer) error
+	AfterCopyTo            []func(*Conn, io.Writer, int64, error)
+	BeforeSyscallConn      []func(*Conn) error
+	AfterSyscallConn       []func(*Conn, syscall.RawConn, error)
+	BeforeHandshake        []func(*Conn) error
+	AfterHandshake         []func(*Conn, tls.ConnectionState, error)
+	AfterIdleTimeout       []func(*Conn)
+	AfterStreamOpened      []func(*Conn, error)
+	AfterStreamClosed      []func(*Conn)
+}
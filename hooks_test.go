@@ -0,0 +1,122 @@
+package connxray
+
+import (
+	"reflect"
+	"regexp"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestHookSettersAreRaceFree(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	var reads, writes atomic.Int64
+	countRead := func(_ *Conn, _ []byte, n int, _ error) {
+		reads.Add(int64(n))
+	}
+	countWrite := func(_ *Conn, _ []byte, n int, _ error) {
+		writes.Add(int64(n))
+	}
+	var wg sync.WaitGroup
+	stop := make(chan struct{})
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			buf := make([]byte, 1)
+			for {
+				select {
+				case <-stop:
+					return
+				default:
+				}
+				cc.Read(buf)
+				cc.Write(buf)
+			}
+		}()
+	}
+	for i := 0; i < 1000; i++ {
+		if i%2 == 0 {
+			cc.SetAfterRead(countRead)
+			cc.SetAfterWrite(countWrite)
+		} else {
+			cc.SetAfterRead(nil)
+			cc.SetAfterWrite(nil)
+		}
+	}
+	cc.SetAfterRead(countRead)
+	before := reads.Load()
+	for reads.Load() == before {
+		cc.Read(make([]byte, 1))
+	}
+	close(stop)
+	wg.Wait()
+	if cc.AfterReadHook() == nil {
+		t.Error("AfterRead hook not set")
+	}
+	if cc.AfterWriteHook() != nil {
+		t.Error("AfterWrite hook set")
+	}
+}
+
+func TestHookTemplateCopyIsRaceFree(t *testing.T) {
+	template := &Conn{}
+	cc := &Conn{}
+	var wg sync.WaitGroup
+	wg.Add(2)
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			template.SetBeforeRead(func(_ *Conn, _ []byte) error {
+				return nil
+			})
+		}
+	}()
+	go func() {
+		defer wg.Done()
+		for i := 0; i < 1000; i++ {
+			cc.copyHooks(template)
+		}
+	}()
+	wg.Wait()
+	cc.copyHooks(template)
+	if cc.BeforeReadHook() == nil {
+		t.Error("BeforeRead hook not copied")
+	}
+}
+
+// TestHooksUpToDate makes sure hooks.go was regenerated (see gen_hooks.go)
+// after hook fields of Conn changed.
+func TestHooksUpToDate(t *testing.T) {
+	isHook := regexp.MustCompile(`^((Before|After|Transform)\w+|\w+Interceptor)$`)
+	connType := reflect.TypeOf(Conn{})
+	chainsType := reflect.TypeOf(hookChains{})
+	connPtr := reflect.TypeOf(&Conn{})
+	hooks := 0
+	for i := 0; i < connType.NumField(); i++ {
+		field := connType.Field(i)
+		if field.Type.Kind() != reflect.Func || !isHook.MatchString(field.Name) {
+			continue
+		}
+		hooks++
+		if chain, ok := chainsType.FieldByName(field.Name); !ok || chain.Type.Elem() != field.Type {
+			t.Errorf("Hook %s has no matching chain, run go generate", field.Name)
+		}
+		for _, method := range []string{"Set" + field.Name, "Append" + field.Name, field.Name + "Hook"} {
+			if _, ok := connPtr.MethodByName(method); !ok {
+				t.Errorf("Method %s is missing, run go generate", method)
+			}
+		}
+	}
+	if n := chainsType.NumField(); n != hooks {
+		t.Errorf("Unexpected number of hook chains %d, expected %d, run go generate", n, hooks)
+	}
+}
@@ -0,0 +1,101 @@
+package connxray
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// idleTimer holds the state behind Conn.IdleTimeout. The timer is armed on
+// the first successful I/O and, rather than being reset on every subsequent
+// one, checks when it fires whether there was activity in the meantime.
+type idleTimer struct {
+	mu      sync.Mutex
+	timer   *time.Timer
+	armed   atomic.Bool
+	last    atomic.Int64
+	stopped bool
+
+	// busy counts transfers in progress (see beginBusy), during which the
+	// Conn is not idle however long they take.
+	busy atomic.Int32
+}
+
+// touchIdle records activity on the Conn after a Read or Write which returned
+// err, arming the idle timer if needed. Failed I/O doesn't count as activity.
+func (c *Conn) touchIdle(err error) {
+	timeout := c.IdleTimeout
+	if timeout <= 0 || err != nil {
+		return
+	}
+	it := &c.idle
+	it.last.Store(time.Now().UnixNano())
+	if it.armed.Load() {
+		return
+	}
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.timer == nil && !it.stopped {
+		it.timer = time.AfterFunc(timeout, func() { c.checkIdle(timeout) })
+	}
+	it.armed.Store(true)
+}
+
+// beginBusy marks the start of a transfer which may take arbitrarily long
+// (eg. sendfile on behalf of StreamConn.ReadFrom), during which the Conn is
+// not considered idle. It must be followed by endBusy.
+func (c *Conn) beginBusy() {
+	if c.IdleTimeout <= 0 {
+		return
+	}
+	c.idle.busy.Add(1)
+	c.touchIdle(nil)
+}
+
+// endBusy marks the end of a transfer started with beginBusy, which returned
+// err.
+func (c *Conn) endBusy(err error) {
+	if c.IdleTimeout <= 0 {
+		return
+	}
+	c.idle.busy.Add(-1)
+	c.touchIdle(err)
+}
+
+// checkIdle runs when the idle timer fires. It closes the Conn if there was
+// no activity within timeout and re-arms the timer otherwise.
+func (c *Conn) checkIdle(timeout time.Duration) {
+	it := &c.idle
+	idle := time.Since(time.Unix(0, it.last.Load()))
+	it.mu.Lock()
+	if it.stopped {
+		it.mu.Unlock()
+		return
+	}
+	if it.busy.Load() > 0 {
+		it.timer.Reset(timeout)
+		it.mu.Unlock()
+		return
+	}
+	if idle < timeout {
+		it.timer.Reset(timeout - idle)
+		it.mu.Unlock()
+		return
+	}
+	it.stopped = true
+	it.mu.Unlock()
+	if hook := c.AfterIdleTimeoutHook(); hook != nil {
+		hook(c)
+	}
+	c.Close()
+}
+
+// stop cancels the idle timer for good. It is called when the Conn is closed.
+func (it *idleTimer) stop() {
+	it.mu.Lock()
+	defer it.mu.Unlock()
+	if it.timer != nil {
+		it.timer.Stop()
+	}
+	it.stopped = true
+}
@@ -0,0 +1,114 @@
+package connxray
+
+import (
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// idleMockConn returns a mockConn whose Read and Write always succeed and
+// whose Close increments closes.
+func idleMockConn(closes *atomic.Int32) *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		closeHandler: func() error {
+			closes.Add(1)
+			return nil
+		},
+	}
+}
+
+func TestIdleTimeoutClosesConn(t *testing.T) {
+	var closes, timeouts atomic.Int32
+	closed := make(chan struct{})
+	cc := &Conn{
+		Base:        idleMockConn(&closes),
+		IdleTimeout: 20 * time.Millisecond,
+		AfterIdleTimeout: func(*Conn) {
+			timeouts.Add(1)
+		},
+		AfterClose: func(*Conn, error) {
+			close(closed)
+		},
+	}
+	cc.Write([]byte("foo"))
+	select {
+	case <-closed:
+	case <-time.After(time.Second):
+		t.Fatal("Conn not closed after idle timeout")
+	}
+	if closes.Load() != 1 || timeouts.Load() != 1 {
+		t.Errorf("Unexpected calls (%d, %d), expected (1, 1)", closes.Load(), timeouts.Load())
+	}
+}
+
+func TestIdleTimeoutResetByActivity(t *testing.T) {
+	var closes atomic.Int32
+	cc := &Conn{
+		Base:        idleMockConn(&closes),
+		IdleTimeout: 50 * time.Millisecond,
+	}
+	for i := 0; i < 10; i++ {
+		cc.Read(make([]byte, 1))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closes.Load() != 0 {
+		t.Error("Active conn closed due to idle timeout")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if closes.Load() != 1 {
+		t.Errorf("Unexpected number of closes %d, expected 1", closes.Load())
+	}
+}
+
+func TestIdleTimeoutStoppedByClose(t *testing.T) {
+	var closes atomic.Int32
+	cc := &Conn{
+		Base:        idleMockConn(&closes),
+		IdleTimeout: 20 * time.Millisecond,
+		AfterIdleTimeout: func(*Conn) {
+			t.Error("Idle timeout hook invoked")
+		},
+	}
+	cc.Write([]byte("foo"))
+	cc.Close()
+	time.Sleep(50 * time.Millisecond)
+	if closes.Load() != 1 {
+		t.Errorf("Unexpected number of closes %d, expected 1", closes.Load())
+	}
+}
+
+func TestIdleTimeoutDisabled(t *testing.T) {
+	var closes atomic.Int32
+	cc := &Conn{Base: idleMockConn(&closes)}
+	cc.Write([]byte("foo"))
+	if cc.idle.timer != nil {
+		t.Error("Idle timer started with zero IdleTimeout")
+	}
+}
+
+func TestIdleTimeoutResetByPacketIO(t *testing.T) {
+	var closes atomic.Int32
+	mc := idleMockConn(&closes)
+	mc.readFromHandler = func(b []byte) (int, net.Addr, error) {
+		return len(b), nil, nil
+	}
+	cc := &Conn{Base: mc, IdleTimeout: 50 * time.Millisecond}
+	for i := 0; i < 10; i++ {
+		cc.ReadFrom(make([]byte, 1))
+		time.Sleep(10 * time.Millisecond)
+	}
+	if closes.Load() != 0 {
+		t.Error("Active conn closed due to idle timeout")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if closes.Load() != 1 {
+		t.Errorf("Unexpected number of closes %d, expected 1", closes.Load())
+	}
+}
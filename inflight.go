@@ -0,0 +1,58 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInFlightUnsupported signifies that the amount of data queued in the
+	// kernel can't be determined for this connection, either because the
+	// platform doesn't support it or because the base does not expose its
+	// file descriptor.
+	ErrInFlightUnsupported = errors.New("in-flight bytes can't be determined for this connection")
+)
+
+// inFlightPollInterval is how often a Write blocked on MaxInFlight rechecks
+// the amount of data queued in the kernel.
+const inFlightPollInterval = 5 * time.Millisecond
+
+// InFlight returns the number of bytes written to this connection which the
+// kernel has not yet delivered to the peer (on Linux: unsent plus
+// unacknowledged data in the socket send queue).
+func (c *Conn) InFlight() (int, error) {
+	var sc syscall.Conn
+	c.walkBase(func(base net.Conn) bool {
+		if _, nested := base.(*Conn); nested {
+			return false
+		}
+		sc, _ = base.(syscall.Conn)
+		return sc != nil
+	})
+	if sc == nil {
+		return 0, ErrInFlightUnsupported
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return 0, err
+	}
+	return inFlight(raw)
+}
+
+// waitForInFlight blocks while at least MaxInFlight bytes are queued in the
+// kernel. It returns immediately if no cap is set or if the amount of queued
+// data can't be determined.
+func (c *Conn) waitForInFlight() {
+	if c.MaxInFlight <= 0 {
+		return
+	}
+	for {
+		queued, err := c.InFlight()
+		if err != nil || queued < c.MaxInFlight {
+			return
+		}
+		time.Sleep(inFlightPollInterval)
+	}
+}
@@ -0,0 +1,27 @@
+package connxray
+
+import (
+	"syscall"
+	"unsafe"
+)
+
+// inFlight returns the number of bytes in the socket's send queue.
+func inFlight(raw syscall.RawConn) (int, error) {
+	var queued int32
+	var errno syscall.Errno
+	err := raw.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(
+			syscall.SYS_IOCTL,
+			fd,
+			syscall.TIOCOUTQ,
+			uintptr(unsafe.Pointer(&queued)),
+		)
+	})
+	if err != nil {
+		return 0, err
+	}
+	if errno != 0 {
+		return 0, errno
+	}
+	return int(queued), nil
+}
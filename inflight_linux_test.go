@@ -0,0 +1,103 @@
+package connxray
+
+import (
+	"context"
+	"io"
+	"net"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+)
+
+// stalledPair returns both ends of a loopback TCP connection where the server
+// side has a tiny receive buffer, so that the client can easily fill it up.
+func stalledPair(t *testing.T) (*net.TCPConn, *net.TCPConn) {
+	lc := net.ListenConfig{
+		Control: func(_, _ string, raw syscall.RawConn) error {
+			var serr error
+			err := raw.Control(func(fd uintptr) {
+				serr = syscall.SetsockoptInt(
+					int(fd),
+					syscall.SOL_SOCKET,
+					syscall.SO_RCVBUF,
+					4096,
+				)
+			})
+			if err != nil {
+				return err
+			}
+			return serr
+		},
+	}
+	l, err := lc.Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error creating a TCP listener: %v", err)
+	}
+	defer l.Close()
+	client, err := net.DialTCP("tcp", nil, l.Addr().(*net.TCPAddr))
+	if err != nil {
+		t.Fatalf("Error dialing: %v", err)
+	}
+	server, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Error accepting: %v", err)
+	}
+	t.Cleanup(func() {
+		client.Close()
+		server.Close()
+	})
+	return client, server.(*net.TCPConn)
+}
+
+func TestInFlightUnsupported(t *testing.T) {
+	cc := &Conn{Base: &Conn{Base: &mockConn{}}}
+	if _, err := cc.InFlight(); err != ErrInFlightUnsupported {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrInFlightUnsupported)
+	}
+}
+
+func TestWriteBlocksAtMaxInFlight(t *testing.T) {
+	client, server := stalledPair(t)
+	if err := client.SetWriteBuffer(4 << 20); err != nil {
+		t.Fatalf("Error setting write buffer: %v", err)
+	}
+	maxInFlight := 16 << 10
+	cc := &Conn{Base: client, MaxInFlight: maxInFlight}
+	total := 1 << 20
+	var written atomic.Int64
+	done := make(chan error, 1)
+	go func() {
+		chunk := make([]byte, 1024)
+		for written.Load() < int64(total) {
+			n, err := cc.Write(chunk)
+			written.Add(int64(n))
+			if err != nil {
+				done <- err
+				return
+			}
+		}
+		done <- nil
+	}()
+	time.Sleep(200 * time.Millisecond)
+	select {
+	case err := <-done:
+		t.Fatalf("Write did not block (err: %v)", err)
+	default:
+	}
+	stalledAt := written.Load()
+	if stalledAt >= int64(total)/2 {
+		t.Errorf("Writes stalled after %d bytes, expected far fewer", stalledAt)
+	}
+	if queued, err := cc.InFlight(); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	} else if queued < maxInFlight {
+		t.Errorf("Unexpected in-flight bytes %d, expected at least %d", queued, maxInFlight)
+	}
+	if _, err := io.CopyN(io.Discard, server, int64(total)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := <-done; err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
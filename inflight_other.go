@@ -0,0 +1,12 @@
+//go:build !linux
+
+package connxray
+
+import (
+	"syscall"
+)
+
+// inFlight is not supported on this platform.
+func inFlight(_ syscall.RawConn) (int, error) {
+	return 0, ErrInFlightUnsupported
+}
@@ -0,0 +1,118 @@
+package connxray
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestReadInterceptorHandled(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			t.Error("Base Read called despite the interceptor handling it")
+			return 0, nil
+		},
+	}
+	var afterN int
+	cc := &Conn{
+		Base: mc,
+		ReadInterceptor: func(_ *Conn, b []byte) (bool, int, error) {
+			return true, copy(b, "fake"), nil
+		},
+		AfterRead: func(_ *Conn, _ []byte, n int, _ error) {
+			afterN = n
+		},
+	}
+	buf := make([]byte, 16)
+	n, err := cc.Read(buf)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if string(buf[:n]) != "fake" {
+		t.Errorf("Unexpected data %q, expected \"fake\"", buf[:n])
+	}
+	if afterN != 4 {
+		t.Errorf("Unexpected n %d in 'after' hook, expected 4", afterN)
+	}
+}
+
+func TestReadInterceptorNotHandled(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "real"), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		ReadInterceptor: func(*Conn, []byte) (bool, int, error) {
+			return false, 0, nil
+		},
+	}
+	buf := make([]byte, 16)
+	n, _ := cc.Read(buf)
+	if string(buf[:n]) != "real" {
+		t.Errorf("Unexpected data %q, expected \"real\"", buf[:n])
+	}
+}
+
+func TestReadInterceptorAfterBeforeHook(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	cc := &Conn{
+		Base: &mockConn{},
+		BeforeRead: func(*Conn, []byte) error {
+			return expectedErr
+		},
+		ReadInterceptor: func(*Conn, []byte) (bool, int, error) {
+			t.Error("Interceptor called despite the 'before' hook failing")
+			return true, 0, nil
+		},
+	}
+	if _, err := cc.Read(nil); !errors.Is(err, expectedErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expectedErr)
+	}
+}
+
+func TestWriteInterceptorHandled(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			t.Error("Base Write called despite the interceptor handling it")
+			return 0, nil
+		},
+	}
+	var intercepted string
+	cc := &Conn{
+		Base:       mc,
+		TrackStats: true,
+		WriteInterceptor: func(_ *Conn, b []byte) (bool, int, error) {
+			intercepted = string(b)
+			return true, len(b), nil
+		},
+	}
+	n, err := cc.Write([]byte("bacon"))
+	if n != 5 || err != nil {
+		t.Errorf("Unexpected result (%d, %v), expected (5, nil)", n, err)
+	}
+	if intercepted != "bacon" {
+		t.Errorf("Unexpected intercepted data %q, expected \"bacon\"", intercepted)
+	}
+	if written := cc.Stats().BytesWritten; written != 0 {
+		t.Errorf("Unexpected bytes written %d, expected 0", written)
+	}
+}
+
+func TestInterceptorChainFirstHandledWins(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	cc := &Conn{Base: &mockConn{}}
+	cc.AppendWriteInterceptor(func(*Conn, []byte) (bool, int, error) {
+		return false, 0, nil
+	})
+	cc.AppendWriteInterceptor(func(*Conn, []byte) (bool, int, error) {
+		return true, 0, expectedErr
+	})
+	cc.AppendWriteInterceptor(func(*Conn, []byte) (bool, int, error) {
+		t.Error("Interceptor called after the call was handled")
+		return true, 0, nil
+	})
+	if _, err := cc.Write([]byte("x")); err != expectedErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expectedErr)
+	}
+}
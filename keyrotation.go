@@ -0,0 +1,83 @@
+package connxray
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// keyState holds the key managed by RotateKey.
+type keyState struct {
+	// inUse is set by the first RotateKey, so that Conns which don't use
+	// keys don't pay for the locking.
+	inUse   atomic.Bool
+	current atomic.Pointer[connKey]
+
+	// rotateMu serializes RotateKey. readMu is held by Read while the
+	// TransformRead hook runs and writeMu by Write and WriteBuffers while
+	// their 'before' hooks and TransformWrite run, so that rotations happen
+	// in between. Neither is held across the underlying I/O, so that a
+	// blocked Read or Write doesn't hold up RotateKey (nor other Writes
+	// queued behind it).
+	rotateMu        sync.Mutex
+	readMu, writeMu sync.RWMutex
+}
+
+// connKey is a key along with its epoch.
+type connKey struct {
+	key   []byte
+	epoch uint64
+}
+
+// pin holds mu for reading while keys are in use. It returns the function
+// releasing it.
+func (ks *keyState) pin(mu *sync.RWMutex) func() {
+	if !ks.inUse.Load() {
+		return func() {}
+	}
+	mu.RLock()
+	return mu.RUnlock
+}
+
+// Key returns the current key of the Conn, set with RotateKey, and its epoch:
+// 1 for the first key, 2 for the next one and so on. It returns (nil, 0) if
+// no key was set. It is meant for hooks implementing encryption, which see
+// the same key for the whole of a 'before' hook chain of Write or WriteBuffers
+// and of a TransformRead or TransformWrite hook. Hooks which look up the key
+// and then write themselves (eg. WriteInterceptor) should call Key once and
+// use that value throughout.
+func (c *Conn) Key() ([]byte, uint64) {
+	if k := c.keys.current.Load(); k != nil {
+		return k.key, k.epoch
+	}
+	return nil, 0
+}
+
+// RotateKey replaces the key returned by Key and returns its epoch. It waits
+// for 'before' hooks of Write and WriteBuffers and for TransformRead and
+// TransformWrite hooks in progress to return, so that each of them sees a
+// single key, but not for the underlying I/O: a Write blocked on the network
+// doesn't delay the rotation. OnKeyRotation is invoked once the key is
+// replaced, eg. to let the peer know. The first key should be set before the
+// Conn is used.
+//
+// RotateKey must not be called from the hooks listed above, which would
+// deadlock.
+func (c *Conn) RotateKey(key []byte) uint64 {
+	ks := &c.keys
+	ks.inUse.Store(true)
+	ks.rotateMu.Lock()
+	ks.writeMu.Lock()
+	ks.readMu.Lock()
+	epoch := uint64(1)
+	if prev := ks.current.Load(); prev != nil {
+		epoch = prev.epoch + 1
+	}
+	ks.current.Store(&connKey{key: key, epoch: epoch})
+	ks.readMu.Unlock()
+	ks.writeMu.Unlock()
+	ks.rotateMu.Unlock()
+	if hook := c.OnKeyRotation; hook != nil {
+		hook(c, epoch)
+	}
+	return epoch
+}
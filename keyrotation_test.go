@@ -0,0 +1,217 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+// xorWith XORs b in place with key.
+func xorWith(b, key []byte) {
+	for i := range b {
+		b[i] ^= key[i%len(key)]
+	}
+}
+
+// encryptingConn returns a Conn which encrypts writes with its key.
+func encryptingConn(base net.Conn) *Conn {
+	return &Conn{
+		Base: base,
+		WriteInterceptor: func(c *Conn, b []byte) (bool, int, error) {
+			key, _ := c.Key()
+			sealed := append([]byte(nil), b...)
+			xorWith(sealed, key)
+			n, err := c.Base.Write(sealed)
+			return true, n, err
+		},
+	}
+}
+
+// decryptingConn returns a Conn which decrypts reads with its key.
+func decryptingConn(base net.Conn) *Conn {
+	return &Conn{
+		Base: base,
+		TransformRead: func(c *Conn, b []byte, n int, err error) (int, error) {
+			key, _ := c.Key()
+			xorWith(b[:n], key)
+			return n, err
+		},
+	}
+}
+
+func TestRotateKey(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	defer server.Close()
+	writer, reader := encryptingConn(client), decryptingConn(server)
+	var rotations []uint64
+	// Let the peer know out of band, before anything is written with the
+	// new key.
+	writer.OnKeyRotation = func(c *Conn, epoch uint64) {
+		rotations = append(rotations, epoch)
+		key, _ := c.Key()
+		reader.RotateKey(key)
+	}
+	if epoch := writer.RotateKey([]byte("chunky")); epoch != 1 {
+		t.Errorf("Unexpected epoch %d, expected 1", epoch)
+	}
+	go func() {
+		writer.Write([]byte("hello"))
+		writer.RotateKey([]byte("bacon"))
+		writer.Write([]byte("world"))
+		writer.Close()
+	}()
+	buf := make([]byte, 5)
+	for _, exp := range []string{"hello", "world"} {
+		if _, err := io.ReadFull(reader, buf); err != nil || string(buf) != exp {
+			t.Errorf("Unexpected results (%q, %v), expected (%q, nil)", buf, err, exp)
+		}
+	}
+	if key, epoch := reader.Key(); string(key) != "bacon" || epoch != 2 {
+		t.Errorf("Unexpected key (%q, %d), expected (\"bacon\", 2)", key, epoch)
+	}
+	if len(rotations) != 2 || rotations[0] != 1 || rotations[1] != 2 {
+		t.Errorf("Unexpected rotations %v, expected [1 2]", rotations)
+	}
+}
+
+func TestRotateKeyWaitsForWrites(t *testing.T) {
+	var mixed, writes int
+	var mu sync.Mutex
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				return len(b), nil
+			},
+		},
+		BeforeWrite: func(c *Conn, b []byte) error {
+			_, before := c.Key()
+			time.Sleep(time.Millisecond)
+			_, after := c.Key()
+			mu.Lock()
+			defer mu.Unlock()
+			writes++
+			if before != after {
+				mixed++
+			}
+			return nil
+		},
+	}
+	cc.RotateKey([]byte("chunky"))
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				cc.Write([]byte("bacon"))
+			}
+		}()
+	}
+	for i := 0; i < 10; i++ {
+		cc.RotateKey([]byte("bacon"))
+		time.Sleep(time.Millisecond)
+	}
+	wg.Wait()
+	if writes != 40 || mixed != 0 {
+		t.Errorf("Unexpected %d out of %d writes spanning a rotation, expected 0 out of 40", mixed, writes)
+	}
+	if _, epoch := cc.Key(); epoch != 11 {
+		t.Errorf("Unexpected epoch %d, expected 11", epoch)
+	}
+}
+
+func TestRotateKeyDuringBlockedWrite(t *testing.T) {
+	blocked, unblock := make(chan struct{}), make(chan struct{})
+	var writes sync.WaitGroup
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				if string(b) == "slow" {
+					close(blocked)
+					<-unblock
+				}
+				return len(b), nil
+			},
+		},
+		BeforeWrite: func(*Conn, []byte) error {
+			return nil
+		},
+	}
+	cc.RotateKey([]byte("chunky"))
+	writes.Add(1)
+	go func() {
+		defer writes.Done()
+		cc.Write([]byte("slow"))
+	}()
+	<-blocked
+	rotated := make(chan struct{})
+	go func() {
+		cc.RotateKey([]byte("bacon"))
+		close(rotated)
+	}()
+	select {
+	case <-rotated:
+	case <-time.After(time.Second):
+		t.Fatal("RotateKey blocked behind a Write")
+	}
+	// Other Writes are not queued behind the blocked one either.
+	if _, err := cc.Write([]byte("fast")); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	close(unblock)
+	writes.Wait()
+	if _, epoch := cc.Key(); epoch != 2 {
+		t.Errorf("Unexpected epoch %d, expected 2", epoch)
+	}
+}
+
+func TestRotateKeyWriteBuffers(t *testing.T) {
+	var mixed, writes int
+	var mu sync.Mutex
+	cc := &Conn{
+		Base: &mockConn{
+			writeHandler: func(b []byte) (int, error) {
+				return len(b), nil
+			},
+		},
+		BeforeWriteBuffers: func(c *Conn, _ *net.Buffers) error {
+			_, before := c.Key()
+			time.Sleep(time.Millisecond)
+			_, after := c.Key()
+			mu.Lock()
+			defer mu.Unlock()
+			writes++
+			if before != after {
+				mixed++
+			}
+			return nil
+		},
+	}
+	cc.RotateKey([]byte("chunky"))
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		for i := 0; i < 20; i++ {
+			bufs := net.Buffers{[]byte("bacon")}
+			cc.WriteBuffers(&bufs)
+		}
+	}()
+	for i := 0; i < 10; i++ {
+		cc.RotateKey([]byte("bacon"))
+		time.Sleep(time.Millisecond)
+	}
+	<-done
+	if writes != 20 || mixed != 0 {
+		t.Errorf("Unexpected %d out of %d writes spanning a rotation, expected 0 out of 20", mixed, writes)
+	}
+}
+
+func TestKeyUnset(t *testing.T) {
+	cc := &Conn{Base: &mockConn{}}
+	if key, epoch := cc.Key(); key != nil || epoch != 0 {
+		t.Errorf("Unexpected key (%q, %d), expected (nil, 0)", key, epoch)
+	}
+}
@@ -1,7 +1,10 @@
 package connxray
 
 import (
+	"context"
 	"net"
+	"sync/atomic"
+	"time"
 )
 
 // Listener wraps a net.Listener and presents the same interface while allowing
@@ -12,12 +15,17 @@ type Listener struct {
 	// Underlying net.Listener.
 	Base net.Listener
 
+	// Origin tells whether the underlying socket was bound by this process or
+	// inherited from another one. It is copied onto every accepted Conn.
+	Origin Origin
+
 	// BeforeAccept is a 'before' hook for the Accept method. If it returns
 	// an error neither the base method nor the 'after' callback will be
 	// called.
 	BeforeAccept func(*Listener) error
 
-	// AfterAccept is an 'after' hook for the Accept method.
+	// AfterAccept is an 'after' hook for the Accept method. The Conn is nil
+	// if Accept failed.
 	AfterAccept func(*Listener, *Conn, error)
 
 	// BeforeClose is a 'before' hook for the Close method.
@@ -26,37 +34,336 @@ type Listener struct {
 	// AfterClose is an 'after' hook for the Close method.
 	AfterClose func(*Listener, error)
 
+	// OnCloseVetoed, if set, is invoked when BeforeClose makes Close fail,
+	// in which case the underlying net.Listener is not closed and AfterClose
+	// is not invoked. It receives the error returned by Close.
+	OnCloseVetoed func(*Listener, error)
+
 	// AfterAddr is an 'after' hook for the Addr method.
 	AfterAddr func(*Listener, net.Addr)
+
+	// StreamConns makes Accept return connections as *StreamConn rather
+	// than *Conn, so that io.Copy and net/http can use fast paths like
+	// sendfile and splice. AfterAccept still receives the *Conn.
+	StreamConns bool
+
+	// TransparentConns makes Accept return connections as returned by
+	// Conn.Transparent, which mirror the optional interfaces of the
+	// underlying connections. It takes precedence over StreamConns.
+	// AfterAccept still receives the *Conn.
+	TransparentConns bool
+
+	// WrapConn, if set, is called with every successfully accepted Conn and
+	// Accept returns its result instead of the Conn, eg. to wrap it in a
+	// tls.Conn or a protocol decoder while keeping hooks on the inner Conn.
+	// It takes precedence over TransparentConns and StreamConns, and is
+	// called after AfterAccept, which still receives the *Conn.
+	WrapConn func(*Conn) net.Conn
+
+	// BaseContext, if set, is called with every connection returned by the
+	// underlying net.Listener to obtain the Context of the resulting Conn,
+	// similarly to http.Server's BaseContext.
+	BaseContext func(net.Conn) context.Context
+
+	// ConnTemplate, if set, provides hooks for every accepted Conn: all of
+	// its hook function fields and hook chains are copied onto the new Conn
+	// before it is passed to AfterAccept (which can still override them) and
+	// returned.
+	// The template's Base and other non-hook fields are ignored.
+	ConnTemplate *Conn
+
+	// ValidateConn, if set, is run on every connection returned by the
+	// underlying net.Listener before it is handed to the caller. If it
+	// returns an error the connection is closed, the error is passed to
+	// OnInvalidConn and Accept proceeds to the next connection. This is
+	// useful with bases which perform a handshake during Accept and may
+	// return connections which are accepted but unusable.
+	ValidateConn func(*Conn) error
+
+	// OnInvalidConn is invoked with connections rejected by ValidateConn or
+	// due to MaxConnsPerIP (with ErrTooManyConnsPerIP). The connection is
+	// already closed by the time it is called.
+	OnInvalidConn func(*Listener, *Conn, error)
+
+	// MaxConnsPerIP, when positive, caps the number of concurrently open
+	// connections accepted from a single remote IP address, as reported by
+	// the underlying net.Conn. Connections over the limit are closed straight
+	// away and reported to OnInvalidConn, and Accept proceeds to the next
+	// one. It must not be changed once Accept has been called.
+	MaxConnsPerIP int
+
+	// RecordConnEvents and MaxConnEvents are copied onto the RecordEvents
+	// and MaxEvents fields of every accepted Conn, so that its event log
+	// starts with the accept itself.
+	RecordConnEvents bool
+	MaxConnEvents    int
+
+	// TrackConnStats is copied onto the TrackStats field of every accepted
+	// Conn. It's needed for Stats to report the traffic of the Listener.
+	TrackConnStats bool
+
+	// TrackConns makes the Listener keep track of every accepted Conn until
+	// it is closed, so that they can be listed with ActiveConns, notified by
+	// OnGoingAway and closed forcibly by Shutdown, and so that Stats covers
+	// the traffic of open connections. This costs a map insertion and removal
+	// per connection. Open connections are counted (see Len) either way. It
+	// must not be changed once Accept has been called.
+	TrackConns bool
+
+	// ShortLivedThreshold, together with OnShortLivedConn, enables detection
+	// of connection churn: accepted connections closed within less than
+	// ShortLivedThreshold are reported as short-lived.
+	ShortLivedThreshold time.Duration
+
+	// OnShortLivedConn is invoked with the connection and its lifetime when a
+	// connection closes faster than ShortLivedThreshold. This helps identify
+	// broken clients or scanners.
+	OnShortLivedConn func(*Conn, time.Duration)
+
+	// FDHeadroom, when non-zero, makes Accept shed load while the process has
+	// fewer than FDHeadroom file descriptors left before hitting its limit:
+	// newly accepted connections are closed straight away and Accept waits
+	// for the next one, backing off like RetryTemporary between attempts.
+	// The usage is sampled at most every 100ms, so the headroom should allow
+	// for connections accepted in between. This is only supported on Linux
+	// and is a no-op elsewhere.
+	FDHeadroom uint64
+
+	// OnFDPressure is invoked with the current and maximum number of open
+	// file descriptors whenever a connection is shed due to FDHeadroom.
+	OnFDPressure func(l *Listener, used, limit uint64)
+
+	// MaxConns, when positive, caps the number of concurrently open
+	// connections accepted by this Listener: Accept blocks until one of them
+	// is closed. Zero means no limit. It must not be changed once Accept has
+	// been called.
+	MaxConns int
+
+	// AfterAcceptThrottled is invoked whenever Accept has to wait because
+	// MaxConns connections are open.
+	AfterAcceptThrottled func(*Listener)
+
+	// MaxHandshakes, when positive, caps the number of TLS handshakes which
+	// connections accepted by this Listener perform concurrently through
+	// Conn.Handshake, as they are CPU-intensive: Handshake waits for one of
+	// the others to complete before starting, or fails if the Conn is closed
+	// or either the context passed to Handshake or the Conn's Context is done
+	// in the meantime. The slot is held from before the BeforeHandshake hooks
+	// until Handshake returns, whether or not hooks are enabled. Handshakes
+	// performed implicitly by Read or Write are not limited. It must not be
+	// changed once Accept has been called. See also Handshakes.
+	MaxHandshakes int
+
+	// AcceptLimiter, if set, throttles Accept so that bursts of incoming
+	// connections are smoothed out before they reach the application: every
+	// Accept takes a token from it, waiting for one before calling the
+	// underlying net.Listener if needed. A Limiter created with
+	// NewLimiter(n, burst) allows n accepts per second and bursts of up to
+	// burst accepts. It can be shared by many Listeners.
+	AcceptLimiter *Limiter
+
+	// AfterAcceptRateLimit is invoked by every Accept throttled with
+	// AcceptLimiter, with the time it had to wait (possibly zero).
+	AfterAcceptRateLimit func(*Listener, time.Duration)
+
+	// RetryTemporary makes Accept retry when the underlying net.Listener
+	// returns a temporary error (eg. EMFILE), rather than returning it, like
+	// http.Server does. Retries are spaced with an exponential backoff,
+	// starting at 5ms and capped at 1s, which is reset by every successful
+	// accept. This keeps loops calling Accept from spinning.
+	RetryTemporary bool
+
+	// AfterAcceptRetry is invoked with the temporary error and the delay
+	// before every retry enabled by RetryTemporary.
+	AfterAcceptRetry func(*Listener, error, time.Duration)
+
+	// OnHookPanic, if set, is invoked with the name of the hook (eg.
+	// "AfterAccept") and the recovered value whenever one of the Listener's
+	// hooks panics, instead of letting the panic crash the program. A
+	// 'before' hook which panicked makes the method fail with ErrHookPanic.
+	// Hooks of accepted connections are covered by Conn.OnHookPanic.
+	OnHookPanic func(l *Listener, hook string, recovered interface{})
+
+	// OnGoingAway, if set, is invoked by Shutdown with every connection still
+	// open once the Listener stops accepting, before waiting for them to be
+	// closed. It lets the application ask peers to go away gracefully (eg. by
+	// sending "Connection: close" or an HTTP/2 GOAWAY frame) so that they
+	// drain before Shutdown has to close them forcibly. It needs TrackConns.
+	OnGoingAway func(*Listener, *Conn)
+
+	// stats are the counters behind Stats.
+	stats listenerStats
+
+	// conns tracks open accepted connections. See Shutdown.
+	conns connRegistry
+
+	// connSlots is the semaphore behind MaxConns.
+	connSlots connSlots
+
+	// handshakeSlots is the semaphore behind MaxHandshakes.
+	handshakeSlots handshakeSlots
+
+	// fdSampler caches the file descriptor usage checked for FDHeadroom.
+	fdSampler fdSampler
+
+	// closing is closed by Close, to interrupt waiting for AcceptLimiter.
+	closing closeSignal
+
+	// perIP counts open connections for MaxConnsPerIP.
+	perIP perIPConns
+
+	// acceptDelay is a synthetic delay (in nanoseconds) injected after each
+	// call to the underlying Accept. See SetAcceptDelay.
+	acceptDelay atomic.Int64
+}
+
+// SetAcceptDelay makes every subsequent Accept wait for d after the
+// underlying net.Listener returns and before the 'after' hook is invoked. This
+// is a fault injection mechanism meant for testing servers under slow-accept
+// conditions. It is safe to call at any time, including while Accept is
+// running in another goroutine. A zero or negative d disables the delay.
+func (l *Listener) SetAcceptDelay(d time.Duration) {
+	l.acceptDelay.Store(int64(d))
+}
+
+// AcceptDelay returns the synthetic delay set with SetAcceptDelay.
+func (l *Listener) AcceptDelay() time.Duration {
+	return time.Duration(l.acceptDelay.Load())
 }
 
 // Accept runs Accept on the underlying net.Listener plus any relevant hooks
 // ('before' and 'after') that were set up.
 func (l *Listener) Accept() (net.Conn, error) {
+	if l.conns.isShutdown() {
+		return nil, ErrListenerClosed
+	}
 	if l.BeforeAccept != nil {
-		if err := l.BeforeAccept(l); err != nil {
-			return nil, err
+		if err := l.guardErr("BeforeAccept", func() error { return l.BeforeAccept(l) }); err != nil {
+			return nil, beforeHookError("Accept", err)
 		}
 	}
-	netconn, err := l.Base.Accept()
-	conn := &Conn{Base: netconn}
+	if err := l.throttleAccept(); err != nil {
+		return nil, err
+	}
+	if err := l.acquireSlot(); err != nil {
+		return nil, err
+	}
+	conn, err := l.acceptConn()
+	l.stats.recordAccept(err)
+	if err != nil {
+		l.releaseSlot()
+	} else if l.MaxConns > 0 {
+		conn.onClose(func(*Conn) { l.releaseSlot() })
+	}
 	if l.AfterAccept != nil {
-		defer l.AfterAccept(l, conn, err)
+		l.guard("AfterAccept", func() { l.AfterAccept(l, conn, err) })
+	}
+	if err != nil {
+		return nil, err
+	}
+	if l.WrapConn != nil {
+		return l.WrapConn(conn), nil
+	}
+	if l.TransparentConns {
+		return conn.Transparent(), nil
+	}
+	if l.StreamConns {
+		return conn.Stream(), nil
+	}
+	return conn, nil
+}
+
+// acceptConn runs Accept on the underlying net.Listener and wraps the result,
+// applying the synthetic accept delay, retrying temporary errors, shedding
+// connections while under file descriptor pressure and skipping connections
+// rejected by ValidateConn or due to MaxConnsPerIP.
+func (l *Listener) acceptConn() (*Conn, error) {
+	var backoff, shedBackoff acceptBackoff
+	for {
+		netconn, err := l.Base.Accept()
+		if delay := l.AcceptDelay(); delay > 0 {
+			time.Sleep(delay)
+		}
+		if err != nil && backoff.retry(l, err) {
+			continue
+		}
+		if err != nil {
+			if l.conns.isShutdown() {
+				err = ErrListenerClosed
+			}
+			return nil, err
+		}
+		conn := &Conn{
+			Base:         netconn,
+			Origin:       l.Origin,
+			RecordEvents: l.RecordConnEvents,
+			MaxEvents:    l.MaxConnEvents,
+			TrackStats:   l.TrackConnStats,
+			created:      time.Now(),
+		}
+		if l.underFDPressure() {
+			netconn.Close()
+			if !shedBackoff.wait(l) {
+				return nil, ErrListenerClosed
+			}
+			continue
+		}
+		if l.ValidateConn != nil {
+			if verr := l.ValidateConn(conn); verr != nil {
+				netconn.Close()
+				if l.OnInvalidConn != nil {
+					l.guard("OnInvalidConn", func() { l.OnInvalidConn(l, conn, verr) })
+				}
+				continue
+			}
+		}
+		ip, ok := l.acquireIPSlot(netconn)
+		if !ok {
+			netconn.Close()
+			if l.OnInvalidConn != nil {
+				l.guard("OnInvalidConn", func() { l.OnInvalidConn(l, conn, ErrTooManyConnsPerIP) })
+			}
+			continue
+		}
+		if l.BaseContext != nil {
+			conn.Context = l.BaseContext(netconn)
+		}
+		if l.ConnTemplate != nil {
+			conn.copyHooks(l.ConnTemplate)
+		}
+		if !l.register(conn) {
+			netconn.Close()
+			if ip != "" {
+				l.perIP.release(ip)
+			}
+			return nil, ErrListenerClosed
+		}
+		if ip != "" {
+			conn.onClose(func(*Conn) { l.perIP.release(ip) })
+		}
+		l.trackLifetime(conn)
+		l.limitHandshakes(conn)
+		conn.recordEvent(Event{Kind: EventAccept})
+		return conn, nil
 	}
-	return conn, err
 }
 
 // Close runs Close on the underlying net.Listener plus any relevant hooks
 // ('before' and 'after') that were set up.
 func (l *Listener) Close() error {
 	if l.BeforeClose != nil {
-		if err := l.BeforeClose(l); err != nil {
+		if err := l.guardErr("BeforeClose", func() error { return l.BeforeClose(l) }); err != nil {
+			err = beforeHookError("Close", err)
+			if l.OnCloseVetoed != nil {
+				l.guard("OnCloseVetoed", func() { l.OnCloseVetoed(l, err) })
+			}
 			return err
 		}
 	}
 	err := l.Base.Close()
+	l.unblockAccept()
 	if l.AfterClose != nil {
-		defer l.AfterClose(l, err)
+		l.guard("AfterClose", func() { l.AfterClose(l, err) })
 	}
 	return err
 }
@@ -66,7 +373,7 @@ func (l *Listener) Close() error {
 func (l *Listener) Addr() net.Addr {
 	addr := l.Base.Addr()
 	if l.AfterAddr != nil {
-		defer l.AfterAddr(l, addr)
+		l.guard("AfterAddr", func() { l.AfterAddr(l, addr) })
 	}
 	return addr
 }
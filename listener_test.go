@@ -4,6 +4,7 @@ import (
 	"errors"
 	"net"
 	"testing"
+	"time"
 )
 
 func TestAcceptWithSucceedingBeforeCallback(t *testing.T) {
@@ -68,7 +69,7 @@ func TestAcceptWithFailingBeforeCallback(t *testing.T) {
 			afterCalled = true
 		},
 	}
-	if _, err := cl.Accept(); err != expErr {
+	if _, err := cl.Accept(); !errors.Is(err, expErr) {
 		t.Errorf("Unexpected error %v, expected %v", err, expErr)
 	}
 	if baseCalled {
@@ -141,7 +142,7 @@ func TestCloseWithFailingBeforeCallback(t *testing.T) {
 			afterCalled = true
 		},
 	}
-	if err := cl.Close(); err != expErr {
+	if err := cl.Close(); !errors.Is(err, expErr) {
 		t.Errorf("Unexpected error %v, expected %v", err, expErr)
 	}
 	if baseCalled {
@@ -184,3 +185,188 @@ func TestAfterAddr(t *testing.T) {
 		t.Error("After callback not invoked")
 	}
 }
+
+func TestAcceptDelay(t *testing.T) {
+	beforeCalled, afterCalled := false, false
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{}, nil
+		},
+	}
+	cl := &Listener{
+		Base: ml,
+		BeforeAccept: func(_ *Listener) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterAccept: func(_ *Listener, _ *Conn, _ error) {
+			afterCalled = true
+		},
+	}
+	delay := 50 * time.Millisecond
+	cl.SetAcceptDelay(delay)
+	if got := cl.AcceptDelay(); got != delay {
+		t.Errorf("Unexpected delay %v, expected %v", got, delay)
+	}
+	start := time.Now()
+	if _, err := cl.Accept(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if elapsed := time.Since(start); elapsed < delay {
+		t.Errorf("Accept returned after %v, expected at least %v", elapsed, delay)
+	}
+	if !beforeCalled {
+		t.Error("Before callback not invoked")
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+	cl.SetAcceptDelay(0)
+	start = time.Now()
+	if _, err := cl.Accept(); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if elapsed := time.Since(start); elapsed >= delay {
+		t.Errorf("Accept returned after %v, expected no delay", elapsed)
+	}
+}
+
+func TestAcceptWithValidateConn(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	invalid, valid := &mockConn{}, &mockConn{}
+	invalidClosed := false
+	invalid.closeHandler = func() error {
+		invalidClosed = true
+		return nil
+	}
+	conns := []net.Conn{invalid, valid}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			conn := conns[0]
+			conns = conns[1:]
+			return conn, nil
+		},
+	}
+	rejected := []net.Conn{}
+	afterCalls := 0
+	cl := &Listener{
+		Base: ml,
+		ValidateConn: func(c *Conn) error {
+			if c.Base == invalid {
+				return expErr
+			}
+			return nil
+		},
+		OnInvalidConn: func(_ *Listener, c *Conn, err error) {
+			if err != expErr {
+				t.Errorf(
+					"Unexpected error %v, expected %v",
+					err,
+					expErr,
+				)
+			}
+			rejected = append(rejected, c.Base)
+		},
+		AfterAccept: func(_ *Listener, c *Conn, _ error) {
+			if c.Base != valid {
+				t.Errorf("Unexpected conn %v, expected %v", c.Base, valid)
+			}
+			afterCalls++
+		},
+	}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if base := nc.(*Conn).Base; base != valid {
+		t.Errorf("Unexpected conn %v, expected %v", base, valid)
+	}
+	if !invalidClosed {
+		t.Error("Invalid conn not closed")
+	}
+	if len(rejected) != 1 || rejected[0] != invalid {
+		t.Errorf("Unexpected rejected conns %v", rejected)
+	}
+	if afterCalls != 1 {
+		t.Errorf("Unexpected number of after callbacks %d, expected 1", afterCalls)
+	}
+}
+
+func TestAcceptReturnsNilConnOnError(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, expErr
+		},
+	}
+	afterCalled := false
+	cl := &Listener{
+		Base:        ml,
+		StreamConns: true,
+		AfterAccept: func(_ *Listener, conn *Conn, err error) {
+			afterCalled = true
+			if conn != nil || err != expErr {
+				t.Errorf("Unexpected results (%v, %v), expected (nil, %v)", conn, err, expErr)
+			}
+		},
+	}
+	conn, err := cl.Accept()
+	if conn != nil || err != expErr {
+		t.Errorf("Unexpected results (%v, %v), expected (nil, %v)", conn, err, expErr)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestAcceptWithWrapConn(t *testing.T) {
+	mc := &mockConn{}
+	wrapped := &mockConn{}
+	var afterConn, wrapArg *Conn
+	cl := NewListener(
+		&mockListener{
+			acceptHandler: func() (net.Conn, error) {
+				return mc, nil
+			},
+		},
+		WithAfterAccept(func(_ *Listener, conn *Conn, _ error) {
+			afterConn = conn
+		}),
+		WithWrapConn(func(conn *Conn) net.Conn {
+			wrapArg = conn
+			return wrapped
+		}),
+	)
+	conn, err := cl.Accept()
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if conn != wrapped {
+		t.Errorf("Unexpected conn %v, expected the wrapper %v", conn, wrapped)
+	}
+	if afterConn == nil || afterConn.Base != mc {
+		t.Errorf("Unexpected conn %v in 'after' hook, expected a Conn wrapping %v", afterConn, mc)
+	}
+	if wrapArg != afterConn {
+		t.Errorf("Unexpected conn %v passed to WrapConn, expected %v", wrapArg, afterConn)
+	}
+}
+
+func TestAcceptWithWrapConnOnError(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	cl := &Listener{
+		Base: &mockListener{
+			acceptHandler: func() (net.Conn, error) {
+				return nil, expErr
+			},
+		},
+		WrapConn: func(*Conn) net.Conn {
+			t.Error("WrapConn called despite Accept failing")
+			return nil
+		},
+	}
+	conn, err := cl.Accept()
+	if conn != nil || err != expErr {
+		t.Errorf("Unexpected results (%v, %v), expected (nil, %v)", conn, err, expErr)
+	}
+}
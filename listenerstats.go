@@ -0,0 +1,66 @@
+package connxray
+
+import (
+	"sync/atomic"
+)
+
+// listenerStats holds the Listener's own counters. Counters covering accepted
+// connections are kept by the connRegistry.
+type listenerStats struct {
+	accepted     atomic.Int64
+	acceptErrors atomic.Int64
+}
+
+// recordAccept accounts for a call to Accept which returned err.
+func (s *listenerStats) recordAccept(err error) {
+	if err != nil {
+		s.acceptErrors.Add(1)
+	} else {
+		s.accepted.Add(1)
+	}
+}
+
+// ListenerStatsSnapshot is a point-in-time copy of the counters of a
+// Listener.
+type ListenerStatsSnapshot struct {
+	// Accepted is the number of connections returned by Accept.
+	Accepted int64
+
+	// Open is the number of accepted connections which are not closed yet.
+	Open int64
+
+	// AcceptErrors is the number of Accept calls which failed, not counting
+	// those rejected by a BeforeAccept hook.
+	AcceptErrors int64
+
+	// BytesRead and BytesWritten are the totals of the traffic counters (see
+	// Conn.Stats) of all connections accepted, whether open or closed. They
+	// are only maintained while TrackConnStats is set, and only cover closed
+	// connections unless TrackConns is set too.
+	BytesRead    int64
+	BytesWritten int64
+}
+
+// Stats returns the counters of the Listener. It is safe to call concurrently
+// with Accept and I/O on the accepted connections.
+//
+// Traffic is not aggregated on the I/O path: the counters of a connection are
+// added to the Listener's totals when it's closed, while those of open
+// connections tracked with TrackConns are summed up on every call, so Stats
+// takes time proportional to the number of open connections.
+func (l *Listener) Stats() ListenerStatsSnapshot {
+	snap := ListenerStatsSnapshot{
+		Accepted:     l.stats.accepted.Load(),
+		AcceptErrors: l.stats.acceptErrors.Load(),
+	}
+	r := &l.conns
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	snap.Open = int64(r.open)
+	snap.BytesRead, snap.BytesWritten = r.closedRead, r.closedWritten
+	for c := range r.conns {
+		snap.BytesRead += c.stats.BytesRead.Load()
+		snap.BytesWritten += c.stats.BytesWritten.Load()
+	}
+	return snap
+}
@@ -0,0 +1,106 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestListenerStats(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	results := []error{nil, nil, expErr, nil}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			err := results[0]
+			results = results[1:]
+			if err != nil {
+				return nil, err
+			}
+			return &mockConn{
+				readHandler: func(b []byte) (int, error) {
+					return copy(b, "bacon"), nil
+				},
+				writeHandler: func(b []byte) (int, error) {
+					return len(b), nil
+				},
+				closeHandler: func() error {
+					return nil
+				},
+			}, nil
+		},
+	}
+	ll := &Listener{Base: ml, TrackConnStats: true, TrackConns: true}
+	var conns []net.Conn
+	for i := 0; i < 4; i++ {
+		if conn, err := ll.Accept(); err == nil {
+			conns = append(conns, conn)
+		}
+	}
+	buf := make([]byte, 8)
+	for _, conn := range conns {
+		conn.Read(buf)
+		conn.Write([]byte("chunky"))
+	}
+	exp := ListenerStatsSnapshot{Accepted: 3, Open: 3, AcceptErrors: 1, BytesRead: 15, BytesWritten: 18}
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	conns[0].Close()
+	conns[1].Close()
+	exp.Open = 1
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	conns[2].Write([]byte("chunky"))
+	conns[2].Close()
+	exp.Open, exp.BytesWritten = 0, 24
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
+
+func TestListenerStatsWithoutConnStats(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{
+				writeHandler: func(b []byte) (int, error) {
+					return len(b), nil
+				},
+			}, nil
+		},
+	}
+	ll := &Listener{Base: ml}
+	conn, _ := ll.Accept()
+	conn.Write([]byte("chunky"))
+	exp := ListenerStatsSnapshot{Accepted: 1, Open: 1}
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
+
+func TestListenerStatsUntrackedConns(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{
+				writeHandler: func(b []byte) (int, error) {
+					return len(b), nil
+				},
+				closeHandler: func() error {
+					return nil
+				},
+			}, nil
+		},
+	}
+	ll := &Listener{Base: ml, TrackConnStats: true}
+	conn, _ := ll.Accept()
+	conn.Write([]byte("chunky"))
+	exp := ListenerStatsSnapshot{Accepted: 1, Open: 1}
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	conn.Close()
+	exp = ListenerStatsSnapshot{Accepted: 1, BytesWritten: 6}
+	if got := ll.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
@@ -0,0 +1,66 @@
+package connxray
+
+import (
+	"net"
+	"sync"
+)
+
+// connSlots is the semaphore behind Listener.MaxConns.
+type connSlots struct {
+	once      sync.Once
+	slots     chan struct{}
+	done      chan struct{}
+	closeOnce sync.Once
+}
+
+// init creates the semaphore with n slots, unless that already happened.
+func (cs *connSlots) init(n int) {
+	cs.once.Do(func() {
+		cs.slots = make(chan struct{}, n)
+		cs.done = make(chan struct{})
+	})
+}
+
+// acquireSlot blocks until the number of open connections accepted by the
+// Listener drops below MaxConns, invoking AfterAcceptThrottled if it has to
+// wait. It returns net.ErrClosed if the Listener gets closed in the meantime.
+func (l *Listener) acquireSlot() error {
+	if l.MaxConns <= 0 {
+		return nil
+	}
+	cs := &l.connSlots
+	cs.init(l.MaxConns)
+	select {
+	case cs.slots <- struct{}{}:
+		return nil
+	default:
+	}
+	if l.AfterAcceptThrottled != nil {
+		l.guard("AfterAcceptThrottled", func() { l.AfterAcceptThrottled(l) })
+	}
+	select {
+	case cs.slots <- struct{}{}:
+		return nil
+	case <-cs.done:
+		return net.ErrClosed
+	}
+}
+
+// releaseSlot frees a slot taken with acquireSlot.
+func (l *Listener) releaseSlot() {
+	if l.MaxConns > 0 {
+		<-l.connSlots.slots
+	}
+}
+
+// unblockAccept makes Accept calls waiting for a slot or for AcceptLimiter
+// return. It is called when the Listener is closed.
+func (l *Listener) unblockAccept() {
+	l.closing.close()
+	if l.MaxConns <= 0 {
+		return
+	}
+	cs := &l.connSlots
+	cs.init(l.MaxConns)
+	cs.closeOnce.Do(func() { close(cs.done) })
+}
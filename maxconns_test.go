@@ -0,0 +1,111 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// pipeListener returns a mockListener whose Accept returns server ends of
+// fresh net.Pipe connections until closed.
+func pipeListener(t *testing.T) *mockListener {
+	t.Helper()
+	done := make(chan struct{})
+	return &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			select {
+			case <-done:
+				return nil, net.ErrClosed
+			default:
+			}
+			client, server := net.Pipe()
+			t.Cleanup(func() { client.Close() })
+			return server, nil
+		},
+		closeHandler: func() error {
+			close(done)
+			return nil
+		},
+	}
+}
+
+func TestMaxConnsBlocksAccept(t *testing.T) {
+	var throttled atomic.Int32
+	l := &Listener{
+		Base:     pipeListener(t),
+		MaxConns: 2,
+		AfterAcceptThrottled: func(*Listener) {
+			throttled.Add(1)
+		},
+	}
+	conns := []net.Conn{}
+	for i := 0; i < 2; i++ {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+		conns = append(conns, conn)
+	}
+	accepted := make(chan net.Conn)
+	go func() {
+		conn, _ := l.Accept()
+		accepted <- conn
+	}()
+	select {
+	case <-accepted:
+		t.Fatal("Accept did not block with MaxConns connections open")
+	case <-time.After(50 * time.Millisecond):
+	}
+	conns[0].Close()
+	conns[0].Close()
+	select {
+	case conn := <-accepted:
+		defer conn.Close()
+	case <-time.After(time.Second):
+		t.Fatal("Accept still blocked after a connection was closed")
+	}
+	if n := throttled.Load(); n != 1 {
+		t.Errorf("Unexpected number of throttled accepts %d, expected 1", n)
+	}
+	if n := len(l.connSlots.slots); n != 2 {
+		t.Errorf("Unexpected number of slots taken %d, expected 2", n)
+	}
+}
+
+func TestMaxConnsUnblockedByClose(t *testing.T) {
+	l := &Listener{Base: pipeListener(t), MaxConns: 1}
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer conn.Close()
+	errs := make(chan error)
+	go func() {
+		_, err := l.Accept()
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	l.Close()
+	select {
+	case err := <-errs:
+		if !errors.Is(err, net.ErrClosed) {
+			t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Accept still blocked after the listener was closed")
+	}
+}
+
+func TestMaxConnsZeroIsUnbounded(t *testing.T) {
+	l := &Listener{Base: pipeListener(t)}
+	for i := 0; i < 10; i++ {
+		if _, err := l.Accept(); err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+	}
+	if l.connSlots.slots != nil {
+		t.Error("Semaphore created with zero MaxConns")
+	}
+}
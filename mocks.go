@@ -1,7 +1,10 @@
 package connxray
 
 import (
+	"context"
+	"io"
 	"net"
+	"syscall"
 	"time"
 )
 
@@ -61,6 +64,90 @@ func (c *mockConn) SetWriteDeadline(t time.Time) error {
 	return c.setWriteDeadlineHandler(t)
 }
 
+// mockSyscallConn is a mockConn which also implements the syscall.Conn
+// interface.
+type mockSyscallConn struct {
+	mockConn
+	syscallConnHandler func() (syscall.RawConn, error)
+}
+
+func (c *mockSyscallConn) SyscallConn() (syscall.RawConn, error) {
+	return c.syscallConnHandler()
+}
+
+// mockStreamConn is a mockConn which also implements the io.ReaderFrom and
+// io.WriterTo interfaces, shadowing the net.PacketConn methods of mockConn.
+type mockStreamConn struct {
+	mockConn
+	readFromReaderHandler func(io.Reader) (int64, error)
+	writeToWriterHandler  func(io.Writer) (int64, error)
+}
+
+func (c *mockStreamConn) ReadFrom(r io.Reader) (int64, error) {
+	return c.readFromReaderHandler(r)
+}
+
+func (c *mockStreamConn) WriteTo(w io.Writer) (int64, error) {
+	return c.writeToWriterHandler(w)
+}
+
+// mockHalfCloseConn is a mockConn which also supports closing either
+// direction of the connection.
+type mockHalfCloseConn struct {
+	mockConn
+	closeReadHandler  func() error
+	closeWriteHandler func() error
+}
+
+func (c *mockHalfCloseConn) CloseRead() error {
+	return c.closeReadHandler()
+}
+
+func (c *mockHalfCloseConn) CloseWrite() error {
+	return c.closeWriteHandler()
+}
+
+// mockSocketOptionConn is a mockConn which also supports some of the socket
+// options of *net.TCPConn.
+type mockSocketOptionConn struct {
+	mockConn
+	setReadBufferHandler func(int) error
+	setNoDelayHandler    func(bool) error
+}
+
+func (c *mockSocketOptionConn) SetReadBuffer(bytes int) error {
+	return c.setReadBufferHandler(bytes)
+}
+
+func (c *mockSocketOptionConn) SetNoDelay(noDelay bool) error {
+	return c.setNoDelayHandler(noDelay)
+}
+
+// mockUDPConn is a mockConn which also reads and writes messages with
+// out-of-band data, like *net.UDPConn.
+type mockUDPConn struct {
+	mockConn
+	readMsgUDPHandler  func(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error)
+	writeMsgUDPHandler func(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error)
+}
+
+func (c *mockUDPConn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	return c.readMsgUDPHandler(b, oob)
+}
+
+func (c *mockUDPConn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	return c.writeMsgUDPHandler(b, oob, addr)
+}
+
+// mockDialer is a mock implementation of ContextDialer.
+type mockDialer struct {
+	dialHandler func(context.Context, string, string) (net.Conn, error)
+}
+
+func (d *mockDialer) DialContext(ctx context.Context, network, address string) (net.Conn, error) {
+	return d.dialHandler(ctx, network, address)
+}
+
 // mockListener is a mock implementation of net.Listener interface. This is
 // generated manually since there standard mocking solutions like gomock do not
 // handle mocking out standard library.
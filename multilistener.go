@@ -0,0 +1,126 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+// MultiListener is a net.Listener which accepts connections from all of its
+// Bases, eg. to serve on IPv4 and IPv6 or on TCP and a Unix socket through a
+// single Accept loop. Wrap it in a Listener (see NewMultiListener) to
+// instrument connections from all the bases with the same hooks.
+//
+// Each base is accepted from in its own goroutine, started on the first call to
+// Accept. Connections are handed out in the order in which they arrive, so no
+// base can starve the others. A temporary error returned by a base is passed on
+// by Accept and the base is accepted from again, while any other error is
+// passed on once, after which the base is no longer used. Once none of the
+// bases is usable Accept fails with net.ErrClosed.
+type MultiListener struct {
+	// Bases are the underlying net.Listeners. They must not be changed once
+	// Accept has been called.
+	Bases []net.Listener
+
+	startOnce sync.Once
+	closeOnce sync.Once
+	accepted  chan acceptResult
+	done      chan struct{}
+	exhausted chan struct{}
+	wg        sync.WaitGroup
+}
+
+// acceptResult is the outcome of Accept on one of the bases.
+type acceptResult struct {
+	conn net.Conn
+	err  error
+}
+
+// NewMultiListener returns a Listener, configured with opts, which accepts
+// connections from all of bases. See MultiListener.
+func NewMultiListener(bases []net.Listener, opts ...ListenerOption) *Listener {
+	return NewListener(&MultiListener{Bases: bases}, opts...)
+}
+
+// Accept returns the next connection accepted by any of the bases. It fails
+// with net.ErrClosed once the MultiListener is closed or all of its bases
+// failed.
+func (m *MultiListener) Accept() (net.Conn, error) {
+	m.start()
+	select {
+	case <-m.done:
+		return nil, net.ErrClosed
+	default:
+	}
+	select {
+	case res := <-m.accepted:
+		return res.conn, res.err
+	case <-m.done:
+		return nil, net.ErrClosed
+	case <-m.exhausted:
+		return nil, net.ErrClosed
+	}
+}
+
+// Close closes all the bases, unblocks pending Accept calls and waits for the
+// goroutines accepting from the bases to exit. It returns the errors returned
+// by the bases, joined.
+func (m *MultiListener) Close() error {
+	m.start()
+	err := net.ErrClosed
+	m.closeOnce.Do(func() {
+		close(m.done)
+		errs := make([]error, 0, len(m.Bases))
+		for _, base := range m.Bases {
+			errs = append(errs, base.Close())
+		}
+		m.wg.Wait()
+		err = errors.Join(errs...)
+	})
+	return err
+}
+
+// Addr returns the address of the first base, or nil if there are none.
+func (m *MultiListener) Addr() net.Addr {
+	if len(m.Bases) == 0 {
+		return nil
+	}
+	return m.Bases[0].Addr()
+}
+
+// start launches the goroutines accepting from the bases.
+func (m *MultiListener) start() {
+	m.startOnce.Do(func() {
+		m.accepted = make(chan acceptResult)
+		m.done = make(chan struct{})
+		m.exhausted = make(chan struct{})
+		m.wg.Add(len(m.Bases))
+		for _, base := range m.Bases {
+			go m.acceptFrom(base)
+		}
+		go func() {
+			m.wg.Wait()
+			close(m.exhausted)
+		}()
+	})
+}
+
+// acceptFrom accepts from base until it fails with a non-temporary error or
+// the MultiListener is closed.
+func (m *MultiListener) acceptFrom(base net.Listener) {
+	defer m.wg.Done()
+	for {
+		conn, err := base.Accept()
+		select {
+		case m.accepted <- acceptResult{conn, err}:
+		case <-m.done:
+			if conn != nil {
+				conn.Close()
+			}
+			return
+		}
+		if err != nil && !isTemporary(err) {
+			return
+		}
+	}
+}
@@ -0,0 +1,115 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"runtime"
+	"sync"
+	"testing"
+	"time"
+)
+
+// chanListener returns a mockListener whose Accept returns conns sent to the
+// returned channel, and fails with net.ErrClosed once it's closed.
+func chanListener() (*mockListener, chan<- net.Conn) {
+	conns := make(chan net.Conn)
+	closed := make(chan struct{})
+	var once sync.Once
+	return &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			select {
+			case conn := <-conns:
+				return conn, nil
+			case <-closed:
+				return nil, net.ErrClosed
+			}
+		},
+		closeHandler: func() error {
+			once.Do(func() { close(closed) })
+			return nil
+		},
+	}, conns
+}
+
+func TestMultiListenerAccept(t *testing.T) {
+	first, firstConns := chanListener()
+	second, secondConns := chanListener()
+	var accepted []*Conn
+	ll := NewMultiListener([]net.Listener{first, second}, WithAfterAccept(func(_ *Listener, c *Conn, _ error) {
+		accepted = append(accepted, c)
+	}))
+	firstConn, secondConn := &mockConn{}, &mockConn{}
+	go func() {
+		firstConns <- firstConn
+		secondConns <- secondConn
+	}()
+	bases := make(map[net.Conn]bool)
+	for i := 0; i < 2; i++ {
+		conn, err := ll.Accept()
+		if err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+		bases[conn.(*Conn).Base] = true
+	}
+	if !bases[firstConn] || !bases[secondConn] {
+		t.Errorf("Unexpected accepted conns %v, expected one from each base", bases)
+	}
+	if len(accepted) != 2 {
+		t.Errorf("Unexpected number of AfterAccept calls %d, expected 2", len(accepted))
+	}
+	if err := ll.Close(); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+}
+
+func TestMultiListenerCloseUnblocksAccept(t *testing.T) {
+	goroutines := runtime.NumGoroutine()
+	first, _ := chanListener()
+	second, _ := chanListener()
+	ml := &MultiListener{Bases: []net.Listener{first, second}}
+	errs := make(chan error)
+	go func() {
+		_, err := ml.Accept()
+		errs <- err
+	}()
+	time.Sleep(10 * time.Millisecond)
+	if err := ml.Close(); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if err := <-errs; err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	if _, err := ml.Accept(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	if err := ml.Close(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+	deadline := time.Now().Add(time.Second)
+	for runtime.NumGoroutine() > goroutines && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	if n := runtime.NumGoroutine(); n > goroutines {
+		t.Errorf("Unexpected number of goroutines %d, expected %d", n, goroutines)
+	}
+}
+
+func TestMultiListenerTerminalError(t *testing.T) {
+	expectedErr := errors.New("bacon")
+	failing := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return nil, expectedErr
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	ml := &MultiListener{Bases: []net.Listener{failing}}
+	defer ml.Close()
+	if _, err := ml.Accept(); err != expectedErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expectedErr)
+	}
+	if _, err := ml.Accept(); err != net.ErrClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, net.ErrClosed)
+	}
+}
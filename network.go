@@ -0,0 +1,44 @@
+package connxray
+
+import (
+	"net"
+)
+
+// NetworkType returns the type of network of the underlying net.Conn: "tcp",
+// "udp" or "unix" for the respective connection types of the net package and
+// the result of LocalAddr().Network() for any other net.Conn. If the
+// underlying net.Conn is itself a Conn, its NetworkType is returned. The
+// result is computed once and cached, which makes it cheap enough to use as
+// eg. a metric label on every call. It returns "" if there is no underlying
+// net.Conn.
+func (c *Conn) NetworkType() string {
+	if cached := c.networkType.Load(); cached != nil {
+		return *cached
+	}
+	if c.Base == nil {
+		return ""
+	}
+	network := networkType(c.Base)
+	c.networkType.Store(&network)
+	return network
+}
+
+func networkType(base net.Conn) string {
+	switch base := base.(type) {
+	case *net.TCPConn:
+		return "tcp"
+	case *net.UDPConn:
+		return "udp"
+	case *net.UnixConn:
+		return "unix"
+	case *Conn:
+		if base == nil {
+			return ""
+		}
+		return base.NetworkType()
+	}
+	if addr := base.LocalAddr(); addr != nil {
+		return addr.Network()
+	}
+	return ""
+}
@@ -0,0 +1,91 @@
+package connxray
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+)
+
+func TestNetworkTypeTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer ln.Close()
+	base, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer base.Close()
+	if network := (&Conn{Base: base}).NetworkType(); network != "tcp" {
+		t.Errorf("Unexpected network type %q, expected \"tcp\"", network)
+	}
+}
+
+func TestNetworkTypeUDP(t *testing.T) {
+	base, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer base.Close()
+	if network := (&Conn{Base: base}).NetworkType(); network != "udp" {
+		t.Errorf("Unexpected network type %q, expected \"udp\"", network)
+	}
+}
+
+func TestNetworkTypeUnix(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "sock")
+	ln, err := net.Listen("unix", path)
+	if err != nil {
+		t.Skipf("Unix sockets unavailable: %v", err)
+	}
+	defer ln.Close()
+	base, err := net.Dial("unix", path)
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer base.Close()
+	if network := (&Conn{Base: base}).NetworkType(); network != "unix" {
+		t.Errorf("Unexpected network type %q, expected \"unix\"", network)
+	}
+}
+
+func TestNetworkTypeFallback(t *testing.T) {
+	calls := 0
+	mc := &mockConn{
+		localAddrHandler: func() net.Addr {
+			calls++
+			return &net.IPAddr{IP: net.IPv4(127, 0, 0, 1)}
+		},
+	}
+	cc := &Conn{Base: mc}
+	for i := 0; i < 2; i++ {
+		if network := cc.NetworkType(); network != "ip" {
+			t.Errorf("Unexpected network type %q, expected \"ip\"", network)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Unexpected number of LocalAddr calls %d, expected 1", calls)
+	}
+}
+
+func TestNetworkTypeNested(t *testing.T) {
+	base, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer base.Close()
+	cc := &Conn{Base: &Conn{Base: base}}
+	if network := cc.NetworkType(); network != "udp" {
+		t.Errorf("Unexpected network type %q, expected \"udp\"", network)
+	}
+}
+
+func TestNetworkTypeNilBase(t *testing.T) {
+	var nilConn *Conn
+	for _, cc := range []*Conn{{}, {Base: nilConn}} {
+		if network := cc.NetworkType(); network != "" {
+			t.Errorf("Unexpected network type %q, expected \"\"", network)
+		}
+	}
+}
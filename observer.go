@@ -0,0 +1,56 @@
+package connxray
+
+// Observer receives callbacks for all methods of a Conn, as an alternative to
+// setting up individual hooks. It is invoked alongside the typed hooks, after
+// them (see hooks.go).
+//
+// The method name is the name of the hook without its Before or After prefix,
+// which is the name of the Conn method, except for "CopyFrom" and "CopyTo"
+// which stand for the ReadFrom and WriteTo methods of StreamConn. The args and
+// results are the arguments of the respective 'before' and 'after' hook,
+// without the *Conn:
+//
+//	method            args          results
+//	Read              b             b, n, err
+//	EOF               -             n
+//	ReadTimeout       -
+//	FirstRead         -             d
+//	ReadFull          -             n, err
+//	ReadFrom          b             b, n, addr, err
+//	ReadMsgUDP        b, oob        b, oob, n, oobn, flags, addr, err
+//	Write             b             b, n, err
+//	WriteTimeout      -
+//	FirstWrite        -             d
+//	WriteAll          -             n, err
+//	WriteBuffers      bufs          n, err
+//	WriteTo           b, addr       b, addr, n, err
+//	WriteMsgUDP       b, oob, addr  b, oob, addr, n, oobn, err
+//	Close                           err
+//	CloseWrite                      err
+//	CloseRead                       err
+//	LocalAddr         -             addr
+//	RemoteAddr        -             addr
+//	SetDeadline       t             t, err
+//	SetReadDeadline   t             t, err
+//	SetWriteDeadline  t             t, err
+//	SetSocketOption   -             name, err
+//	CopyFrom          r             r, n, err
+//	CopyTo            w             w, n, err
+//	SyscallConn                     raw, err
+//	Handshake                       state, err
+//	IdleTimeout       -
+//	StreamOpened      -             err
+//	StreamClosed      -
+//
+// BeforeCall is not invoked for methods marked with "-", since they have no
+// 'before' hook.
+type Observer interface {
+	// BeforeCall is invoked before the underlying method is called. If it
+	// returns an error neither the underlying method nor AfterCall are
+	// called, and the error is returned to the caller wrapped in a
+	// HookError.
+	BeforeCall(c *Conn, method string, args ...interface{}) error
+
+	// AfterCall is invoked after the underlying method returns.
+	AfterCall(c *Conn, method string, results ...interface{})
+}
@@ -0,0 +1,97 @@
+package connxray
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"reflect"
+	"testing"
+)
+
+// recordingObserver records all calls it receives and fails BeforeCall for
+// the method named in fail.
+type recordingObserver struct {
+	calls []string
+	fail  string
+}
+
+func (o *recordingObserver) BeforeCall(_ *Conn, method string, args ...interface{}) error {
+	o.calls = append(o.calls, fmt.Sprintf("before %s %v", method, args))
+	if method == o.fail {
+		return errors.New("chunky bacon")
+	}
+	return nil
+}
+
+func (o *recordingObserver) AfterCall(_ *Conn, method string, results ...interface{}) {
+	o.calls = append(o.calls, fmt.Sprintf("after %s %v", method, results))
+}
+
+func TestObserverAlongsideTypedHooks(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	obs := &recordingObserver{}
+	cc := &Conn{
+		Base:     mc,
+		Observer: obs,
+		BeforeWrite: func(*Conn, []byte) error {
+			obs.calls = append(obs.calls, "typed before")
+			return nil
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			obs.calls = append(obs.calls, "typed after")
+		},
+	}
+	cc.Write([]byte("ab"))
+	cc.Close()
+	exp := []string{
+		"typed before",
+		"before Write [[97 98]]",
+		"typed after",
+		"after Write [[97 98] 2 <nil>]",
+		"after FirstWrite [0s]",
+		"before Close []",
+		"after Close [<nil>]",
+	}
+	if !reflect.DeepEqual(obs.calls, exp) {
+		t.Errorf("Unexpected calls %q, expected %q", obs.calls, exp)
+	}
+}
+
+func TestObserverBeforeCallFailure(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	obs := &recordingObserver{fail: "Read"}
+	cc := &Conn{Base: mc, Observer: obs}
+	if _, err := cc.Read(nil); err == nil || errors.Unwrap(err).Error() != "chunky bacon" {
+		t.Errorf("Unexpected error %v, expected chunky bacon", err)
+	}
+	if exp := []string{"before Read [[]]"}; !reflect.DeepEqual(obs.calls, exp) {
+		t.Errorf("Unexpected calls %q, expected %q", obs.calls, exp)
+	}
+}
+
+func TestObserverAfterOnlyMethod(t *testing.T) {
+	addr := &net.TCPAddr{Port: 1983}
+	mc := &mockConn{
+		localAddrHandler: func() net.Addr {
+			return addr
+		},
+	}
+	obs := &recordingObserver{}
+	cc := &Conn{Base: mc, Observer: obs}
+	cc.LocalAddr()
+	if exp := []string{fmt.Sprintf("after LocalAddr [%v]", addr)}; !reflect.DeepEqual(obs.calls, exp) {
+		t.Errorf("Unexpected calls %q, expected %q", obs.calls, exp)
+	}
+}
@@ -0,0 +1,188 @@
+package connxray
+
+import (
+	"context"
+	"net"
+	"time"
+)
+
+// ConnOption configures a Conn created with NewConn.
+type ConnOption func(*Conn)
+
+// NewConn wraps base in a Conn configured with opts, which are applied in
+// order.
+func NewConn(base net.Conn, opts ...ConnOption) *Conn {
+	c := &Conn{Base: base, created: time.Now()}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+// WithTemplate copies hooks from template onto the Conn, like
+// Listener.ConnTemplate does. It replaces hooks set by earlier options.
+func WithTemplate(template *Conn) ConnOption {
+	return func(c *Conn) {
+		c.copyHooks(template)
+	}
+}
+
+// WithContext sets the Conn's Context.
+func WithContext(ctx context.Context) ConnOption {
+	return func(c *Conn) {
+		c.Context = ctx
+	}
+}
+
+// WithBeforeRead adds a BeforeRead hook. Like all hook options, it can be
+// used multiple times, in which case the hooks are chained (see
+// AppendBeforeRead).
+func WithBeforeRead(fn func(*Conn, []byte) error) ConnOption {
+	return func(c *Conn) {
+		c.AppendBeforeRead(fn)
+	}
+}
+
+// WithAfterRead adds an AfterRead hook.
+func WithAfterRead(fn func(*Conn, []byte, int, error)) ConnOption {
+	return func(c *Conn) {
+		c.AppendAfterRead(fn)
+	}
+}
+
+// WithBeforeWrite adds a BeforeWrite hook.
+func WithBeforeWrite(fn func(*Conn, []byte) error) ConnOption {
+	return func(c *Conn) {
+		c.AppendBeforeWrite(fn)
+	}
+}
+
+// WithAfterWrite adds an AfterWrite hook.
+func WithAfterWrite(fn func(*Conn, []byte, int, error)) ConnOption {
+	return func(c *Conn) {
+		c.AppendAfterWrite(fn)
+	}
+}
+
+// WithAfterClose adds an AfterClose hook.
+func WithAfterClose(fn func(*Conn, error)) ConnOption {
+	return func(c *Conn) {
+		c.AppendAfterClose(fn)
+	}
+}
+
+// WithObserver sets the Conn's Observer.
+func WithObserver(o Observer) ConnOption {
+	return func(c *Conn) {
+		c.Observer = o
+	}
+}
+
+// WithIdleTimeout sets the Conn's IdleTimeout.
+func WithIdleTimeout(d time.Duration) ConnOption {
+	return func(c *Conn) {
+		c.IdleTimeout = d
+	}
+}
+
+// WithStats enables the Conn's traffic counters (see TrackStats).
+func WithStats() ConnOption {
+	return func(c *Conn) {
+		c.TrackStats = true
+	}
+}
+
+// WithEvents enables the Conn's event log, capped at max events (see
+// RecordEvents and MaxEvents).
+func WithEvents(max int) ConnOption {
+	return func(c *Conn) {
+		c.RecordEvents = true
+		c.MaxEvents = max
+	}
+}
+
+// ListenerOption configures a Listener created with NewListener.
+type ListenerOption func(*Listener)
+
+// NewListener wraps base in a Listener configured with opts, which are applied
+// in order.
+func NewListener(base net.Listener, opts ...ListenerOption) *Listener {
+	l := &Listener{Base: base}
+	for _, opt := range opts {
+		opt(l)
+	}
+	return l
+}
+
+// WithConnTemplate sets the Listener's ConnTemplate.
+func WithConnTemplate(template *Conn) ListenerOption {
+	return func(l *Listener) {
+		l.ConnTemplate = template
+	}
+}
+
+// WithAfterAccept sets the Listener's AfterAccept hook. If used multiple
+// times, all the hooks are invoked in order.
+func WithAfterAccept(fn func(*Listener, *Conn, error)) ListenerOption {
+	return func(l *Listener) {
+		prev := l.AfterAccept
+		if prev == nil {
+			l.AfterAccept = fn
+			return
+		}
+		l.AfterAccept = func(l *Listener, c *Conn, err error) {
+			prev(l, c, err)
+			fn(l, c, err)
+		}
+	}
+}
+
+// WithMaxConns sets the Listener's MaxConns.
+func WithMaxConns(n int) ListenerOption {
+	return func(l *Listener) {
+		l.MaxConns = n
+	}
+}
+
+// WithMaxConnsPerIP sets the Listener's MaxConnsPerIP.
+func WithMaxConnsPerIP(n int) ListenerOption {
+	return func(l *Listener) {
+		l.MaxConnsPerIP = n
+	}
+}
+
+// WithAcceptLimiter sets the Listener's AcceptLimiter.
+func WithAcceptLimiter(limiter *Limiter) ListenerOption {
+	return func(l *Listener) {
+		l.AcceptLimiter = limiter
+	}
+}
+
+// WithStreamConns makes the Listener return *StreamConn (see StreamConns).
+func WithStreamConns() ListenerOption {
+	return func(l *Listener) {
+		l.StreamConns = true
+	}
+}
+
+// WithTransparentConns makes the Listener return connections which mirror the
+// optional interfaces of the underlying ones (see TransparentConns).
+func WithTransparentConns() ListenerOption {
+	return func(l *Listener) {
+		l.TransparentConns = true
+	}
+}
+
+// WithWrapConn sets the Listener's WrapConn.
+func WithWrapConn(fn func(*Conn) net.Conn) ListenerOption {
+	return func(l *Listener) {
+		l.WrapConn = fn
+	}
+}
+
+// WithBaseContext sets the Listener's BaseContext.
+func WithBaseContext(fn func(net.Conn) context.Context) ListenerOption {
+	return func(l *Listener) {
+		l.BaseContext = fn
+	}
+}
@@ -0,0 +1,72 @@
+package connxray
+
+import (
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestNewConnOptionsCompose(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	calls := []string{}
+	cc := NewConn(
+		mc,
+		WithTemplate(&Conn{
+			AfterRead: func(*Conn, []byte, int, error) {
+				calls = append(calls, "template")
+			},
+		}),
+		WithAfterRead(func(*Conn, []byte, int, error) {
+			calls = append(calls, "first")
+		}),
+		WithAfterRead(func(*Conn, []byte, int, error) {
+			calls = append(calls, "second")
+		}),
+		WithStats(),
+		WithIdleTimeout(time.Minute),
+	)
+	defer cc.idle.stop()
+	cc.Read(make([]byte, 4))
+	if exp := []string{"template", "first", "second"}; !reflect.DeepEqual(calls, exp) {
+		t.Errorf("Unexpected calls %v, expected %v", calls, exp)
+	}
+	if cc.Stats().BytesRead != 4 {
+		t.Errorf("Unexpected stats %+v, expected 4 bytes read", cc.Stats())
+	}
+	if cc.IdleTimeout != time.Minute {
+		t.Errorf("Unexpected idle timeout %v, expected %v", cc.IdleTimeout, time.Minute)
+	}
+}
+
+func TestNewConnNilBase(t *testing.T) {
+	cc := NewConn(nil, WithStats())
+	if cc.Base != nil || !cc.TrackStats {
+		t.Errorf("Unexpected Conn %+v, expected a nil Base with stats enabled", cc)
+	}
+}
+
+func TestNewListenerOptionsCompose(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return server, nil
+		},
+	}
+	calls := 0
+	after := func(*Listener, *Conn, error) { calls++ }
+	l := NewListener(ml, WithAfterAccept(after), WithAfterAccept(after), WithMaxConns(5))
+	conn, err := l.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer conn.Close()
+	if calls != 2 || l.MaxConns != 5 {
+		t.Errorf("Unexpected results (%d, %d), expected (2, 5)", calls, l.MaxConns)
+	}
+}
@@ -0,0 +1,52 @@
+package connxray
+
+import (
+	"net"
+	"os"
+)
+
+// Origin describes how the socket underlying a Listener came into existence.
+// It is propagated to every Conn accepted by that Listener so that connections
+// can be correlated across zero-downtime restarts.
+type Origin int
+
+const (
+	// OriginFresh means that the listening socket was bound by this process.
+	// This is the zero value, so a Listener created with a struct literal is
+	// considered fresh.
+	OriginFresh Origin = iota
+
+	// OriginInherited means that the listening socket was inherited from
+	// another process (eg. a parent handing over its file descriptors during
+	// a restart).
+	OriginInherited
+)
+
+// String returns a human-readable name of the origin.
+func (o Origin) String() string {
+	switch o {
+	case OriginFresh:
+		return "fresh"
+	case OriginInherited:
+		return "inherited"
+	default:
+		return "unknown"
+	}
+}
+
+// ListenerFromFile creates a Listener from an inherited file descriptor using
+// net.FileListener and tags it (and all connections it accepts) with
+// OriginInherited. The optional configure callback can be used to set up hooks
+// before the Listener is returned. As with net.FileListener, it is the
+// caller's responsibility to close f when done.
+func ListenerFromFile(f *os.File, configure func(*Listener)) (*Listener, error) {
+	base, err := net.FileListener(f)
+	if err != nil {
+		return nil, err
+	}
+	l := &Listener{Base: base, Origin: OriginInherited}
+	if configure != nil {
+		configure(l)
+	}
+	return l, nil
+}
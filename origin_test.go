@@ -0,0 +1,62 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+)
+
+func TestListenerFromFile(t *testing.T) {
+	tl, err := net.ListenTCP("tcp", &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Error creating a TCP listener: %v", err)
+	}
+	defer tl.Close()
+	f, err := tl.File()
+	if err != nil {
+		t.Fatalf("Error getting listener file: %v", err)
+	}
+	defer f.Close()
+	configured := false
+	cl, err := ListenerFromFile(f, func(l *Listener) {
+		configured = true
+	})
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer cl.Close()
+	if !configured {
+		t.Error("Configure callback not invoked")
+	}
+	if cl.Origin != OriginInherited {
+		t.Errorf("Unexpected origin %v, expected %v", cl.Origin, OriginInherited)
+	}
+	go func() {
+		if c, err := net.Dial("tcp", cl.Addr().String()); err == nil {
+			c.Close()
+		}
+	}()
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	defer nc.Close()
+	if origin := nc.(*Conn).Origin; origin != OriginInherited {
+		t.Errorf("Unexpected origin %v, expected %v", origin, OriginInherited)
+	}
+}
+
+func TestAcceptFreshOrigin(t *testing.T) {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{}, nil
+		},
+	}
+	cl := &Listener{Base: ml}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if origin := nc.(*Conn).Origin; origin != OriginFresh {
+		t.Errorf("Unexpected origin %v, expected %v", origin, OriginFresh)
+	}
+}
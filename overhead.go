@@ -0,0 +1,48 @@
+package connxray
+
+import (
+	"time"
+)
+
+// now returns the current time if overhead measurement is enabled and the zero
+// time otherwise, so that disabled measurement costs next to nothing.
+func (c *Conn) now() time.Time {
+	if !c.MeasureOverhead {
+		return time.Time{}
+	}
+	return time.Now()
+}
+
+// spentInMethod accounts for the total time spent in a Conn method, including
+// its hooks. It is meant to be deferred at the top of the method.
+func (c *Conn) spentInMethod(start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	c.methodTime.Add(int64(time.Since(start)))
+}
+
+// spentInBase accounts for the time spent in a call to the underlying
+// net.Conn.
+func (c *Conn) spentInBase(start time.Time) {
+	if start.IsZero() {
+		return
+	}
+	c.baseTime.Add(int64(time.Since(start)))
+}
+
+// OverheadRatio returns the fraction (between 0 and 1) of time spent in Conn
+// methods which was not spent in the underlying net.Conn, ie. the overhead of
+// hook execution. It is only tracked while MeasureOverhead is set and returns
+// 0 if nothing has been measured yet.
+func (c *Conn) OverheadRatio() float64 {
+	total := c.methodTime.Load()
+	if total <= 0 {
+		return 0
+	}
+	overhead := total - c.baseTime.Load()
+	if overhead < 0 {
+		overhead = 0
+	}
+	return float64(overhead) / float64(total)
+}
@@ -0,0 +1,71 @@
+package connxray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOverheadRatioWithSlowHook(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, MeasureOverhead: true}
+	if ratio := cc.OverheadRatio(); ratio != 0 {
+		t.Errorf("Unexpected ratio %v, expected 0", ratio)
+	}
+	if _, err := cc.Read(make([]byte, 8)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	baseline := cc.OverheadRatio()
+	cc.AfterRead = func(_ *Conn, _ []byte, _ int, _ error) {
+		time.Sleep(20 * time.Millisecond)
+	}
+	if _, err := cc.Read(make([]byte, 8)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if ratio := cc.OverheadRatio(); ratio <= baseline || ratio < 0.5 {
+		t.Errorf("Unexpected ratio %v, expected it to rise above %v", ratio, baseline)
+	}
+}
+
+func TestOverheadRatioWithSlowBase(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			time.Sleep(20 * time.Millisecond)
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base:            mc,
+		MeasureOverhead: true,
+		AfterWrite:      func(_ *Conn, _ []byte, _ int, _ error) {},
+	}
+	if _, err := cc.Write(make([]byte, 8)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if ratio := cc.OverheadRatio(); ratio >= 0.5 {
+		t.Errorf("Unexpected ratio %v, expected less than 0.5", ratio)
+	}
+}
+
+func TestOverheadRatioDisabled(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		AfterRead: func(_ *Conn, _ []byte, _ int, _ error) {
+			time.Sleep(time.Millisecond)
+		},
+	}
+	if _, err := cc.Read(make([]byte, 8)); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if ratio := cc.OverheadRatio(); ratio != 0 {
+		t.Errorf("Unexpected ratio %v, expected 0", ratio)
+	}
+}
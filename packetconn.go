@@ -0,0 +1,160 @@
+package connxray
+
+import (
+	"net"
+	"time"
+)
+
+// PacketConn wraps a net.PacketConn (eg. the result of net.ListenPacket) and
+// presents the same interface while allowing hook functions to be injected
+// that will be called before and/or after the underlying net.PacketConn calls
+// are invoked. Unlike Conn it does not require the base to implement
+// net.Conn, so it can wrap unconnected sockets.
+type PacketConn struct {
+	// Underlying net.PacketConn.
+	Base net.PacketConn
+
+	// BeforeReadFrom is a 'before' hook for the ReadFrom method. If it
+	// returns an error neither the base method nor the 'after' callback will
+	// be called. The same applies to all other 'before' hooks.
+	BeforeReadFrom func(*PacketConn, []byte) error
+
+	// AfterReadFrom is an 'after' hook for the ReadFrom method.
+	AfterReadFrom func(*PacketConn, []byte, int, net.Addr, error)
+
+	// BeforeWriteTo is a 'before' hook for the WriteTo method.
+	BeforeWriteTo func(*PacketConn, []byte, net.Addr) error
+
+	// AfterWriteTo is an 'after' hook for the WriteTo method.
+	AfterWriteTo func(*PacketConn, []byte, net.Addr, int, error)
+
+	// BeforeClose is a 'before' hook for the Close method.
+	BeforeClose func(*PacketConn) error
+
+	// AfterClose is an 'after' hook for the Close method.
+	AfterClose func(*PacketConn, error)
+
+	// AfterLocalAddr is an 'after' hook for the LocalAddr method.
+	AfterLocalAddr func(*PacketConn, net.Addr)
+
+	// BeforeSetDeadline is a 'before' hook for the SetDeadline method.
+	BeforeSetDeadline func(*PacketConn, time.Time) error
+
+	// AfterSetDeadline is an 'after' hook for the SetDeadline method.
+	AfterSetDeadline func(*PacketConn, time.Time, error)
+
+	// BeforeSetReadDeadline is a 'before' hook for the SetReadDeadline
+	// method.
+	BeforeSetReadDeadline func(*PacketConn, time.Time) error
+
+	// AfterSetReadDeadline is an 'after' hook for the SetReadDeadline method.
+	AfterSetReadDeadline func(*PacketConn, time.Time, error)
+
+	// BeforeSetWriteDeadline is a 'before' hook for the SetWriteDeadline
+	// method.
+	BeforeSetWriteDeadline func(*PacketConn, time.Time) error
+
+	// AfterSetWriteDeadline is an 'after' hook for the SetWriteDeadline
+	// method.
+	AfterSetWriteDeadline func(*PacketConn, time.Time, error)
+}
+
+// ReadFrom reads a packet from the underlying net.PacketConn and invokes
+// relevant hooks ('before' and 'after') that were set up.
+func (c *PacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	if c.BeforeReadFrom != nil {
+		if err := c.BeforeReadFrom(c, b); err != nil {
+			return 0, nil, err
+		}
+	}
+	n, addr, err := c.Base.ReadFrom(b)
+	if c.AfterReadFrom != nil {
+		c.AfterReadFrom(c, b, n, addr, err)
+	}
+	return n, addr, err
+}
+
+// WriteTo writes a packet to the underlying net.PacketConn and invokes
+// relevant hooks ('before' and 'after') that were set up.
+func (c *PacketConn) WriteTo(b []byte, addr net.Addr) (int, error) {
+	if c.BeforeWriteTo != nil {
+		if err := c.BeforeWriteTo(c, b, addr); err != nil {
+			return 0, err
+		}
+	}
+	n, err := c.Base.WriteTo(b, addr)
+	if c.AfterWriteTo != nil {
+		c.AfterWriteTo(c, b, addr, n, err)
+	}
+	return n, err
+}
+
+// Close closes the underlying net.PacketConn and invokes relevant hooks
+// ('before' and 'after') that were set up.
+func (c *PacketConn) Close() error {
+	if c.BeforeClose != nil {
+		if err := c.BeforeClose(c); err != nil {
+			return err
+		}
+	}
+	err := c.Base.Close()
+	if c.AfterClose != nil {
+		c.AfterClose(c, err)
+	}
+	return err
+}
+
+// LocalAddr gets the local address from the underlying net.PacketConn and
+// invokes an 'after' hook if it was set up.
+func (c *PacketConn) LocalAddr() net.Addr {
+	addr := c.Base.LocalAddr()
+	if c.AfterLocalAddr != nil {
+		c.AfterLocalAddr(c, addr)
+	}
+	return addr
+}
+
+// SetDeadline sets a deadline on the underlying net.PacketConn and invokes
+// relevant hooks ('before' and 'after') that were set up.
+func (c *PacketConn) SetDeadline(t time.Time) error {
+	if c.BeforeSetDeadline != nil {
+		if err := c.BeforeSetDeadline(c, t); err != nil {
+			return err
+		}
+	}
+	err := c.Base.SetDeadline(t)
+	if c.AfterSetDeadline != nil {
+		c.AfterSetDeadline(c, t, err)
+	}
+	return err
+}
+
+// SetReadDeadline sets a read deadline on the underlying net.PacketConn and
+// invokes relevant hooks ('before' and 'after') that were set up.
+func (c *PacketConn) SetReadDeadline(t time.Time) error {
+	if c.BeforeSetReadDeadline != nil {
+		if err := c.BeforeSetReadDeadline(c, t); err != nil {
+			return err
+		}
+	}
+	err := c.Base.SetReadDeadline(t)
+	if c.AfterSetReadDeadline != nil {
+		c.AfterSetReadDeadline(c, t, err)
+	}
+	return err
+}
+
+// SetWriteDeadline sets a write deadline on the underlying net.PacketConn and
+// invokes relevant hooks ('before' and 'after') that were set up.
+func (c *PacketConn) SetWriteDeadline(t time.Time) error {
+	if c.BeforeSetWriteDeadline != nil {
+		if err := c.BeforeSetWriteDeadline(c, t); err != nil {
+			return err
+		}
+	}
+	err := c.Base.SetWriteDeadline(t)
+	if c.AfterSetWriteDeadline != nil {
+		c.AfterSetWriteDeadline(c, t, err)
+	}
+	return err
+}
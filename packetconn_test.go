@@ -0,0 +1,116 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"testing"
+	"time"
+)
+
+func TestPacketConnReadFromWithSucceedingBeforeCallback(t *testing.T) {
+	baseCalled, beforeCalled, afterCalled := false, false, false
+	expErr := errors.New("chunky bacon")
+	expAddr := &net.UDPAddr{Port: 1983}
+	mc := &mockConn{
+		readFromHandler: func(b []byte) (int, net.Addr, error) {
+			if !beforeCalled {
+				t.Error("Before callback not invoked")
+			}
+			baseCalled = true
+			return 3, expAddr, expErr
+		},
+	}
+	pc := &PacketConn{
+		Base: mc,
+		BeforeReadFrom: func(_ *PacketConn, _ []byte) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterReadFrom: func(_ *PacketConn, _ []byte, n int, addr net.Addr, err error) {
+			if !baseCalled {
+				t.Error("Base method not invoked")
+			}
+			if n != 3 || addr != expAddr || err != expErr {
+				t.Errorf("Unexpected results (%d, %v, %v), expected (3, %v, %v)", n, addr, err, expAddr, expErr)
+			}
+			afterCalled = true
+		},
+	}
+	if _, _, err := pc.ReadFrom(nil); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestPacketConnWriteToWithFailingBeforeCallback(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		writeToHandler: func(b []byte, _ net.Addr) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	pc := &PacketConn{
+		Base: mc,
+		BeforeWriteTo: func(_ *PacketConn, _ []byte, _ net.Addr) error {
+			return expErr
+		},
+		AfterWriteTo: func(_ *PacketConn, _ []byte, _ net.Addr, _ int, _ error) {
+			t.Error("After callback invoked")
+		},
+	}
+	if _, err := pc.WriteTo(nil, nil); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestPacketConnCloseAndDeadlines(t *testing.T) {
+	calls := []string{}
+	mc := &mockConn{
+		closeHandler: func() error {
+			return nil
+		},
+		setReadDeadlineHandler: func(time.Time) error {
+			return nil
+		},
+	}
+	pc := &PacketConn{
+		Base: mc,
+		AfterSetReadDeadline: func(_ *PacketConn, _ time.Time, _ error) {
+			calls = append(calls, "SetReadDeadline")
+		},
+		AfterClose: func(_ *PacketConn, _ error) {
+			calls = append(calls, "Close")
+		},
+	}
+	pc.SetReadDeadline(time.Now())
+	pc.Close()
+	if len(calls) != 2 || calls[0] != "SetReadDeadline" || calls[1] != "Close" {
+		t.Errorf("Unexpected calls %v", calls)
+	}
+}
+
+func TestPacketConnUDP(t *testing.T) {
+	base, err := net.ListenPacket("udp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Error creating a UDP socket: %v", err)
+	}
+	var written int
+	pc := &PacketConn{
+		Base: base,
+		AfterWriteTo: func(_ *PacketConn, _ []byte, _ net.Addr, n int, _ error) {
+			written += n
+		},
+	}
+	defer pc.Close()
+	if _, err := pc.WriteTo([]byte("hello"), pc.LocalAddr()); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	buf := make([]byte, 16)
+	n, _, err := pc.ReadFrom(buf)
+	if err != nil || string(buf[:n]) != "hello" || written != 5 {
+		t.Errorf("Unexpected results (%q, %v, %d), expected (\"hello\", nil, 5)", buf[:n], err, written)
+	}
+}
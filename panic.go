@@ -0,0 +1,39 @@
+package connxray
+
+import (
+	"errors"
+)
+
+var (
+	// ErrHookPanic is returned by Conn and Listener methods whose 'before'
+	// hook panicked, provided that the panic was recovered and reported to
+	// OnHookPanic.
+	ErrHookPanic = errors.New("hook panicked")
+)
+
+// guard runs fn, which invokes the named hook, recovering from a panic in it
+// if OnHookPanic is set. It reports whether fn panicked. If OnHookPanic is not
+// set the panic propagates as usual.
+func (l *Listener) guard(hook string, fn func()) (panicked bool) {
+	if l.OnHookPanic == nil {
+		fn()
+		return false
+	}
+	defer func() {
+		if r := recover(); r != nil {
+			l.OnHookPanic(l, hook, r)
+			panicked = true
+		}
+	}()
+	fn()
+	return false
+}
+
+// guardErr is like guard, but for hooks returning an error. A panic is turned
+// into ErrHookPanic.
+func (l *Listener) guardErr(hook string, fn func() error) (err error) {
+	if l.guard(hook, func() { err = fn() }) {
+		return ErrHookPanic
+	}
+	return err
+}
@@ -0,0 +1,134 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestPanickingAfterWriteIsRecovered(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return 2, expErr
+		},
+	}
+	var gotHook string
+	var gotValue interface{}
+	cc := &Conn{
+		Base: mc,
+		AfterWrite: func(*Conn, []byte, int, error) {
+			panic("boom")
+		},
+		OnHookPanic: func(_ *Conn, hook string, recovered interface{}) {
+			gotHook, gotValue = hook, recovered
+		},
+	}
+	n, err := cc.Write([]byte("foo"))
+	if n != 2 || err != expErr {
+		t.Errorf("Unexpected results (%d, %v), expected (2, %v)", n, err, expErr)
+	}
+	if gotHook != "AfterWrite" || gotValue != "boom" {
+		t.Errorf("Unexpected panic report (%q, %v), expected (\"AfterWrite\", boom)", gotHook, gotValue)
+	}
+}
+
+func TestPanickingBeforeReadIsRecovered(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	var gotHook string
+	cc := &Conn{
+		Base: mc,
+		OnHookPanic: func(_ *Conn, hook string, _ interface{}) {
+			gotHook = hook
+		},
+	}
+	cc.AppendBeforeRead(func(*Conn, []byte) error {
+		panic("boom")
+	})
+	if _, err := cc.Read(nil); !errors.Is(err, ErrHookPanic) {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrHookPanic)
+	}
+	if gotHook != "BeforeRead" {
+		t.Errorf("Unexpected hook %q, expected \"BeforeRead\"", gotHook)
+	}
+}
+
+func TestPanickingTransformKeepsResults(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return 3, nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		TransformRead: func(*Conn, []byte, int, error) (int, error) {
+			panic("boom")
+		},
+		OnHookPanic: func(*Conn, string, interface{}) {},
+	}
+	if n, err := cc.Read(nil); n != 3 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (3, nil)", n, err)
+	}
+}
+
+func TestPanickingHookWithoutHandlerPropagates(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		AfterWrite: func(*Conn, []byte, int, error) {
+			panic("boom")
+		},
+	}
+	defer func() {
+		if r := recover(); r != "boom" {
+			t.Errorf("Unexpected recovered value %v, expected boom", r)
+		}
+	}()
+	cc.Write([]byte("foo"))
+	t.Error("Panic not propagated")
+}
+
+func TestListenerPanickingHooksAreRecovered(t *testing.T) {
+	client, server := net.Pipe()
+	defer client.Close()
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return server, nil
+		},
+		closeHandler: func() error {
+			return nil
+		},
+	}
+	reported := []string{}
+	l := &Listener{
+		Base: ml,
+		AfterAccept: func(*Listener, *Conn, error) {
+			panic("boom")
+		},
+		BeforeClose: func(*Listener) error {
+			panic("boom")
+		},
+		OnHookPanic: func(_ *Listener, hook string, _ interface{}) {
+			reported = append(reported, hook)
+		},
+	}
+	conn, err := l.Accept()
+	if err != nil || conn.(*Conn).Base != server {
+		t.Errorf("Unexpected results (%v, %v), expected (%v, nil)", conn, err, server)
+	}
+	if err := l.Close(); !errors.Is(err, ErrHookPanic) {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrHookPanic)
+	}
+	if len(reported) != 2 || reported[0] != "AfterAccept" || reported[1] != "BeforeClose" {
+		t.Errorf("Unexpected panic reports %v", reported)
+	}
+}
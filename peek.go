@@ -0,0 +1,49 @@
+package connxray
+
+// Peek returns the next n bytes from the connection without consuming them,
+// so that they are returned by subsequent Reads. It blocks until n bytes are
+// available, and if fewer bytes can be read it returns them together with the
+// error which stopped it (eg. io.EOF). This is useful for protocol detection.
+// The returned slice is only valid until the next Read.
+//
+// Data is read from the underlying net.Conn through the usual Read path, so
+// Read hooks (as well as Stats, the event log etc.) see it when it's peeked,
+// not when it's subsequently returned by Read.
+func (c *Conn) Peek(n int) ([]byte, error) {
+	c.peekMu.Lock()
+	defer c.peekMu.Unlock()
+	if len(c.peeked) < n {
+		buf := make([]byte, n)
+		copy(buf, c.peeked)
+		filled := len(c.peeked)
+		for filled < n {
+			m, err := c.read(buf[filled:])
+			filled += m
+			if err != nil {
+				c.peeked = buf[:filled]
+				return c.peeked, err
+			}
+		}
+		c.peeked = buf
+	}
+	return c.peeked[:n], nil
+}
+
+// readPeeked moves data buffered by Peek to b and returns its length.
+func (c *Conn) readPeeked(b []byte) int {
+	c.peekMu.Lock()
+	defer c.peekMu.Unlock()
+	n := copy(b, c.peeked)
+	c.peeked = c.peeked[n:]
+	if len(c.peeked) == 0 {
+		c.peeked = nil
+	}
+	return n
+}
+
+// hasPeeked tells whether there is data buffered by Peek.
+func (c *Conn) hasPeeked() bool {
+	c.peekMu.Lock()
+	defer c.peekMu.Unlock()
+	return len(c.peeked) > 0
+}
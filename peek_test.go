@@ -0,0 +1,63 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"testing"
+)
+
+func TestPeekThenRead(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	go func() {
+		server.Write([]byte("GET "))
+		server.Write([]byte("/ HTTP/1.1"))
+		server.Close()
+	}()
+	hookReads := 0
+	cc := &Conn{
+		Base:       client,
+		TrackStats: true,
+		AfterRead: func(*Conn, []byte, int, error) {
+			hookReads++
+		},
+	}
+	peeked, err := cc.Peek(6)
+	if err != nil || string(peeked) != "GET / " {
+		t.Fatalf("Unexpected results (%q, %v), expected (\"GET / \", nil)", peeked, err)
+	}
+	if again, _ := cc.Peek(3); string(again) != "GET" {
+		t.Errorf("Unexpected repeated peek %q, expected \"GET\"", again)
+	}
+	readsAfterPeek := hookReads
+	data, err := io.ReadAll(cc)
+	if err != nil || string(data) != "GET / HTTP/1.1" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"GET / HTTP/1.1\", nil)", data, err)
+	}
+	if readsAfterPeek != 2 {
+		t.Errorf("Unexpected number of hooked reads during Peek %d, expected 2", readsAfterPeek)
+	}
+	if stats := cc.Stats(); stats.BytesRead != 14 {
+		t.Errorf("Unexpected number of bytes read %d, expected 14", stats.BytesRead)
+	}
+}
+
+func TestPeekPastAvailableData(t *testing.T) {
+	client, server := net.Pipe()
+	go func() {
+		server.Write([]byte("SSH"))
+		server.Close()
+	}()
+	cc := &Conn{Base: client}
+	peeked, err := cc.Peek(10)
+	if err != io.EOF || string(peeked) != "SSH" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"SSH\", %v)", peeked, err, io.EOF)
+	}
+	buf := make([]byte, 10)
+	if n, err := cc.Read(buf); n != 3 || err != nil || string(buf[:n]) != "SSH" {
+		t.Errorf("Unexpected results (%q, %v), expected (\"SSH\", nil)", buf[:n], err)
+	}
+	if _, err := cc.Read(buf); err != io.EOF {
+		t.Errorf("Unexpected error %v, expected %v", err, io.EOF)
+	}
+}
@@ -0,0 +1,86 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"sync"
+)
+
+var (
+	// ErrTooManyConnsPerIP is passed to Listener.OnInvalidConn with
+	// connections rejected due to Listener.MaxConnsPerIP.
+	ErrTooManyConnsPerIP = errors.New("connxray: too many connections from the remote IP")
+)
+
+// perIPConns counts open connections by remote IP address.
+type perIPConns struct {
+	mu     sync.Mutex
+	counts map[string]int
+}
+
+// acquire counts a new connection from ip, unless there are already max
+// connections open from it, in which case it returns false.
+func (p *perIPConns) acquire(ip string, max int) bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.counts[ip] >= max {
+		return false
+	}
+	if p.counts == nil {
+		p.counts = make(map[string]int)
+	}
+	p.counts[ip]++
+	return true
+}
+
+// release accounts for a connection from ip being closed.
+func (p *perIPConns) release(ip string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.counts[ip]--; p.counts[ip] <= 0 {
+		delete(p.counts, ip)
+	}
+}
+
+// ConnsPerIP returns the number of open connections accepted by the Listener
+// from each remote IP address. It is only tracked while MaxConnsPerIP is set.
+func (l *Listener) ConnsPerIP() map[string]int {
+	l.perIP.mu.Lock()
+	defer l.perIP.mu.Unlock()
+	counts := make(map[string]int, len(l.perIP.counts))
+	for ip, n := range l.perIP.counts {
+		counts[ip] = n
+	}
+	return counts
+}
+
+// acquireIPSlot counts netconn towards the MaxConnsPerIP limit of its remote
+// IP address, which it returns. It returns false if the limit is reached.
+// Connections whose remote IP address is unknown are not limited.
+func (l *Listener) acquireIPSlot(netconn net.Conn) (ip string, ok bool) {
+	if l.MaxConnsPerIP <= 0 {
+		return "", true
+	}
+	if ip = remoteIP(netconn.RemoteAddr()); ip == "" {
+		return "", true
+	}
+	return ip, l.perIP.acquire(ip, l.MaxConnsPerIP)
+}
+
+// remoteIP returns the IP address part of addr, or an empty string if it
+// can't be determined.
+func remoteIP(addr net.Addr) string {
+	switch addr := addr.(type) {
+	case nil:
+		return ""
+	case *net.TCPAddr:
+		return addr.IP.String()
+	case *net.UDPAddr:
+		return addr.IP.String()
+	}
+	host, _, err := net.SplitHostPort(addr.String())
+	if err != nil {
+		return ""
+	}
+	return host
+}
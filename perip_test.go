@@ -0,0 +1,79 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+)
+
+// addrListener returns a mockListener accepting mock connections from the
+// given remote addresses, in order.
+func addrListener(addrs ...string) *mockListener {
+	return &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			if len(addrs) == 0 {
+				return nil, net.ErrClosed
+			}
+			addr, _ := net.ResolveTCPAddr("tcp", addrs[0])
+			addrs = addrs[1:]
+			return &mockConn{
+				remoteAddrHandler: func() net.Addr { return addr },
+				closeHandler:      func() error { return nil },
+			}, nil
+		},
+	}
+}
+
+func TestMaxConnsPerIP(t *testing.T) {
+	ml := addrListener("10.0.0.1:1", "10.0.0.1:2", "10.0.0.1:3", "10.0.0.2:1", "10.0.0.1:4")
+	var rejected []*Conn
+	cl := NewListener(ml, WithMaxConnsPerIP(2))
+	cl.OnInvalidConn = func(_ *Listener, conn *Conn, err error) {
+		if err != ErrTooManyConnsPerIP {
+			t.Errorf("Unexpected error %v, expected %v", err, ErrTooManyConnsPerIP)
+		}
+		rejected = append(rejected, conn)
+	}
+	conns := acceptN(t, cl, 3)
+	if len(rejected) != 1 {
+		t.Fatalf("Unexpected number of rejected conns %d, expected 1", len(rejected))
+	}
+	if addr := conns[2].RemoteAddr().String(); addr != "10.0.0.2:1" {
+		t.Errorf("Unexpected remote address %s, expected 10.0.0.2:1", addr)
+	}
+	exp := map[string]int{"10.0.0.1": 2, "10.0.0.2": 1}
+	if got := cl.ConnsPerIP(); len(got) != 2 || got["10.0.0.1"] != 2 || got["10.0.0.2"] != 1 {
+		t.Errorf("Unexpected conns per IP %v, expected %v", got, exp)
+	}
+	conns[0].Close()
+	conn, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if addr := conn.RemoteAddr().String(); addr != "10.0.0.1:4" {
+		t.Errorf("Unexpected remote address %s, expected 10.0.0.1:4", addr)
+	}
+}
+
+func TestMaxConnsPerIPUnknownAddr(t *testing.T) {
+	cl := &Listener{Base: pipeListener(t), MaxConnsPerIP: 1}
+	acceptN(t, cl, 3)
+	if got := cl.ConnsPerIP(); len(got) != 0 {
+		t.Errorf("Unexpected conns per IP %v, expected none", got)
+	}
+}
+
+func TestRemoteIP(t *testing.T) {
+	for _, tc := range []struct {
+		addr net.Addr
+		exp  string
+	}{
+		{nil, ""},
+		{&net.TCPAddr{IP: net.ParseIP("::1"), Port: 80}, "::1"},
+		{&net.UDPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 53}, "10.0.0.1"},
+		{&net.UnixAddr{Name: "/tmp/sock", Net: "unix"}, ""},
+	} {
+		if got := remoteIP(tc.addr); got != tc.exp {
+			t.Errorf("Unexpected IP %q for %v, expected %q", got, tc.addr, tc.exp)
+		}
+	}
+}
@@ -0,0 +1,64 @@
+package connxray
+
+import (
+	"encoding/json"
+	"io"
+)
+
+// persistedStats is the format written by SaveStats and read by RestoreStats:
+// a JSON object holding the cumulative counters of a Listener, eg.
+//
+//	{"accepted":3,"accept_errors":1,"bytes_read":15,"bytes_written":24}
+//
+// The number of open connections is not persisted, since it doesn't survive a
+// restart.
+type persistedStats struct {
+	Accepted     int64 `json:"accepted"`
+	AcceptErrors int64 `json:"accept_errors"`
+	BytesRead    int64 `json:"bytes_read"`
+	BytesWritten int64 `json:"bytes_written"`
+}
+
+// SaveStats writes the cumulative counters of the Listener (see Stats) to w as
+// a single line of JSON, so that they can be restored with RestoreStats after
+// a restart. Calling it periodically, eg. on a time.Ticker, keeps counters
+// used for billing or metering from being lost.
+func (l *Listener) SaveStats(w io.Writer) error {
+	snap := l.Stats()
+	return json.NewEncoder(w).Encode(persistedStats{
+		Accepted:     snap.Accepted,
+		AcceptErrors: snap.AcceptErrors,
+		BytesRead:    snap.BytesRead,
+		BytesWritten: snap.BytesWritten,
+	})
+}
+
+// RestoreStats reads counters written by SaveStats from r and adds them to the
+// counters of the Listener, so that Stats resumes from where the saving
+// Listener stopped. It's meant to be called on startup, before Accept. If r
+// holds several snapshots, eg. appended periodically to a file, the last one
+// is used.
+func (l *Listener) RestoreStats(r io.Reader) error {
+	var saved persistedStats
+	dec := json.NewDecoder(r)
+	found := false
+	for {
+		var next persistedStats
+		if err := dec.Decode(&next); err == io.EOF {
+			break
+		} else if err != nil {
+			return err
+		}
+		saved, found = next, true
+	}
+	if !found {
+		return io.ErrUnexpectedEOF
+	}
+	l.stats.accepted.Add(saved.Accepted)
+	l.stats.acceptErrors.Add(saved.AcceptErrors)
+	l.conns.mu.Lock()
+	defer l.conns.mu.Unlock()
+	l.conns.closedRead += saved.BytesRead
+	l.conns.closedWritten += saved.BytesWritten
+	return nil
+}
@@ -0,0 +1,74 @@
+package connxray
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestSaveRestoreStats(t *testing.T) {
+	l := baconListener()
+	buf := make([]byte, 8)
+	for i := 0; i < 2; i++ {
+		conn, _ := l.Accept()
+		conn.Read(buf)
+		conn.Write([]byte("chunky"))
+		if i == 0 {
+			conn.Close()
+		}
+	}
+	var saved bytes.Buffer
+	if err := l.SaveStats(&saved); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if exp := `{"accepted":2,"accept_errors":0,"bytes_read":10,"bytes_written":12}` + "\n"; saved.String() != exp {
+		t.Errorf("Unexpected snapshot %q, expected %q", saved.String(), exp)
+	}
+
+	restarted := baconListener()
+	if err := restarted.RestoreStats(&saved); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	exp := ListenerStatsSnapshot{Accepted: 2, BytesRead: 10, BytesWritten: 12}
+	if got := restarted.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	conn, _ := restarted.Accept()
+	conn.Read(buf)
+	exp = ListenerStatsSnapshot{Accepted: 3, Open: 1, BytesRead: 15, BytesWritten: 12}
+	if got := restarted.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	conn.Close()
+	exp.Open = 0
+	if got := restarted.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
+
+func TestRestoreStatsLastSnapshot(t *testing.T) {
+	l := baconListener()
+	var saved bytes.Buffer
+	l.SaveStats(&saved)
+	l.Accept()
+	l.SaveStats(&saved)
+	restarted := baconListener()
+	if err := restarted.RestoreStats(&saved); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if got := restarted.Stats(); got.Accepted != 1 {
+		t.Errorf("Unexpected number of accepted connections %d, expected 1", got.Accepted)
+	}
+}
+
+func TestRestoreStatsInvalid(t *testing.T) {
+	for _, input := range []string{"", "bacon", `{"acc`, `{"accepted":"chunky"}`} {
+		l := baconListener()
+		if err := l.RestoreStats(strings.NewReader(input)); err == nil {
+			t.Errorf("%q: unexpected nil error, expected a decoding error", input)
+		}
+		if got := l.Stats(); got != (ListenerStatsSnapshot{}) {
+			t.Errorf("%q: unexpected stats %+v, expected zero", input, got)
+		}
+	}
+}
@@ -0,0 +1,135 @@
+package connxray
+
+import (
+	"bytes"
+	"errors"
+	"net"
+	"strconv"
+	"strings"
+)
+
+// ErrBadProxyHeader is returned by Read on connections with ProxyProtocolV1 set
+// when the PROXY protocol header sent by the peer is malformed.
+var ErrBadProxyHeader = errors.New("malformed PROXY protocol header")
+
+const (
+	// proxyV1MaxLen is the maximum length of a PROXY protocol v1 header,
+	// including the trailing CRLF.
+	proxyV1MaxLen = 107
+	proxyV1Prefix = "PROXY "
+)
+
+// SetRemoteAddr makes RemoteAddr report addr instead of the remote address of
+// the underlying net.Conn, eg. when the real address of the peer is known from
+// a proxy. A nil addr removes the override. It is safe to call at any time.
+func (c *Conn) SetRemoteAddr(addr net.Addr) {
+	if addr == nil {
+		c.remoteAddr.Store(nil)
+		return
+	}
+	c.remoteAddr.Store(&addr)
+}
+
+// readProxied serves b from the PROXY protocol v1 header handling (see
+// Conn.ProxyProtocolV1). It reads and strips the header on the first call and
+// then returns the data read past it, telling whether it handled the read.
+// Once that data is exhausted reads go to the underlying net.Conn as usual.
+func (c *Conn) readProxied(b []byte) (bool, int, error) {
+	c.proxyMu.Lock()
+	defer c.proxyMu.Unlock()
+	if !c.proxyDone {
+		err := c.readProxyHeader()
+		if err != nil && err != ErrBadProxyHeader {
+			return true, 0, err
+		}
+		c.proxyDone, c.proxyErr = true, err
+	}
+	if c.proxyErr != nil {
+		return true, 0, c.proxyErr
+	}
+	if len(c.proxyBuf) > 0 {
+		n := copy(b, c.proxyBuf)
+		c.proxyBuf = c.proxyBuf[n:]
+		return true, n, nil
+	}
+	return false, 0, nil
+}
+
+// readProxyHeader reads the PROXY protocol v1 header, if any, into proxyBuf
+// and handles it, leaving only data which follows it in proxyBuf. Errors of
+// the underlying net.Conn are returned as they are, with the data read so far
+// kept for the next attempt. It must be called with proxyMu held.
+func (c *Conn) readProxyHeader() error {
+	if c.proxyBuf == nil {
+		c.proxyBuf = make([]byte, 0, proxyV1MaxLen)
+	}
+	for {
+		if done, err := c.scanProxyHeader(); done {
+			return err
+		}
+		buf := c.proxyBuf
+		c.syscalls.reads.Add(1)
+		n, err := c.Base.Read(buf[len(buf):cap(buf)])
+		c.proxyBuf = buf[:len(buf)+n]
+		if err != nil {
+			if done, perr := c.scanProxyHeader(); done {
+				return perr
+			}
+			return err
+		}
+	}
+}
+
+// scanProxyHeader looks for the PROXY protocol v1 header in proxyBuf and tells
+// whether it's complete, or if there is none. It must be called with proxyMu
+// held.
+func (c *Conn) scanProxyHeader() (done bool, err error) {
+	buf := c.proxyBuf
+	prefixLen := min(len(buf), len(proxyV1Prefix))
+	if string(buf[:prefixLen]) != proxyV1Prefix[:prefixLen] {
+		return true, nil
+	}
+	if end := bytes.Index(buf, []byte("\r\n")); end >= 0 {
+		c.proxyBuf = buf[end+2:]
+		addr, err := parseProxyV1(string(buf[:end]))
+		if err == nil && addr != nil {
+			c.SetRemoteAddr(addr)
+		}
+		return true, err
+	}
+	if len(buf) == cap(buf) {
+		return true, ErrBadProxyHeader
+	}
+	return false, nil
+}
+
+// parseProxyV1 returns the source address from a PROXY protocol v1 header
+// without the trailing CRLF. It returns a nil address for the UNKNOWN
+// protocol, which means that the address of the connection should be used.
+func parseProxyV1(header string) (net.Addr, error) {
+	fields := strings.Split(header, " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 {
+		return nil, ErrBadProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	if ip == nil || net.ParseIP(fields[3]) == nil {
+		return nil, ErrBadProxyHeader
+	}
+	switch {
+	case fields[1] == "TCP4" && ip.To4() != nil:
+	case fields[1] == "TCP6" && ip.To4() == nil:
+	default:
+		return nil, ErrBadProxyHeader
+	}
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if err != nil {
+		return nil, ErrBadProxyHeader
+	}
+	if _, err := strconv.ParseUint(fields[5], 10, 16); err != nil {
+		return nil, ErrBadProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
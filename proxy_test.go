@@ -0,0 +1,222 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+// proxiedConn returns a mockConn which serves chunks one per Read, followed
+// by io.EOF.
+func proxiedConn(chunks ...string) *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			if len(chunks) == 0 {
+				return 0, io.EOF
+			}
+			n := copy(b, chunks[0])
+			if chunks[0] = chunks[0][n:]; chunks[0] == "" {
+				chunks = chunks[1:]
+			}
+			return n, nil
+		},
+		remoteAddrHandler: func() net.Addr {
+			return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+		},
+	}
+}
+
+func TestProxyProtocolV1(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PROXY TCP4 192.168.0.1 ", "192.168.0.11 56324 443\r\nGET", " /"),
+		ProxyProtocolV1: true,
+	}
+	if addr := cc.RemoteAddr().String(); addr != "10.0.0.1:1234" {
+		t.Errorf("Unexpected address %q, expected \"10.0.0.1:1234\"", addr)
+	}
+	data, err := io.ReadAll(cc)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if string(data) != "GET /" {
+		t.Errorf("Unexpected data %q, expected \"GET /\"", data)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "192.168.0.1:56324" {
+		t.Errorf("Unexpected address %q, expected \"192.168.0.1:56324\"", addr)
+	}
+}
+
+func TestProxyProtocolV1SmallReads(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PROXY TCP6 ::1 ::2 80 443\r\nbacon"),
+		ProxyProtocolV1: true,
+	}
+	var data []byte
+	buf := make([]byte, 2)
+	for {
+		n, err := cc.Read(buf)
+		data = append(data, buf[:n]...)
+		if err != nil {
+			break
+		}
+	}
+	if string(data) != "bacon" {
+		t.Errorf("Unexpected data %q, expected \"bacon\"", data)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "[::1]:80" {
+		t.Errorf("Unexpected address %q, expected \"[::1]:80\"", addr)
+	}
+}
+
+func TestProxyProtocolV1NoHeader(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PRO", "TOCOL"),
+		ProxyProtocolV1: true,
+	}
+	data, _ := io.ReadAll(cc)
+	if string(data) != "PROTOCOL" {
+		t.Errorf("Unexpected data %q, expected \"PROTOCOL\"", data)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "10.0.0.1:1234" {
+		t.Errorf("Unexpected address %q, expected \"10.0.0.1:1234\"", addr)
+	}
+}
+
+func TestProxyProtocolV1Unknown(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PROXY UNKNOWN\r\nbacon"),
+		ProxyProtocolV1: true,
+	}
+	data, _ := io.ReadAll(cc)
+	if string(data) != "bacon" {
+		t.Errorf("Unexpected data %q, expected \"bacon\"", data)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "10.0.0.1:1234" {
+		t.Errorf("Unexpected address %q, expected \"10.0.0.1:1234\"", addr)
+	}
+}
+
+func TestProxyProtocolV1HooksDisabled(t *testing.T) {
+	var hookData []byte
+	cc := &Conn{
+		Base:            proxiedConn("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nGET", " /"),
+		ProxyProtocolV1: true,
+		AfterRead: func(_ *Conn, b []byte, n int, _ error) {
+			hookData = append(hookData, b[:n]...)
+		},
+	}
+	cc.SetHooksEnabled(false)
+	buf := make([]byte, 2)
+	n, err := cc.Read(buf)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if string(buf[:n]) != "GE" {
+		t.Errorf("Unexpected data %q, expected \"GE\"", buf[:n])
+	}
+	// Data read past the header is not lost when hooks come back on.
+	cc.SetHooksEnabled(true)
+	data, err := io.ReadAll(cc)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if string(data) != "T /" {
+		t.Errorf("Unexpected data %q, expected \"T /\"", data)
+	}
+	if string(hookData) != "T /" {
+		t.Errorf("Unexpected data seen by AfterRead %q, expected \"T /\"", hookData)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "192.168.0.1:56324" {
+		t.Errorf("Unexpected address %q, expected \"192.168.0.1:56324\"", addr)
+	}
+}
+
+func TestProxyProtocolV1Malformed(t *testing.T) {
+	for _, header := range []string{
+		"PROXY TCP4 192.168.0.1 192.168.0.11 56324\r\n",
+		"PROXY TCP4 ::1 ::2 80 443\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 99999 443\r\n",
+		"PROXY TCP4 192.168.0.1 192.168.0.11 56324 443 and then some more bytes which make the header way too long to be valid\r\n",
+	} {
+		cc := &Conn{Base: proxiedConn(header), ProxyProtocolV1: true}
+		for i := 0; i < 2; i++ {
+			if _, err := cc.Read(make([]byte, 16)); err != ErrBadProxyHeader {
+				t.Errorf("Unexpected error %v for %q, expected %v", err, header, ErrBadProxyHeader)
+			}
+		}
+	}
+}
+
+func TestSetRemoteAddr(t *testing.T) {
+	var hookAddr net.Addr
+	cc := &Conn{
+		Base: proxiedConn(),
+		AfterRemoteAddr: func(_ *Conn, addr net.Addr) {
+			hookAddr = addr
+		},
+	}
+	override := &net.TCPAddr{IP: net.IPv4(1, 2, 3, 4), Port: 5}
+	cc.SetRemoteAddr(override)
+	if addr := cc.RemoteAddr(); addr != override || hookAddr != override {
+		t.Errorf("Unexpected addresses (%v, %v), expected %v", addr, hookAddr, override)
+	}
+	cc.SetRemoteAddr(nil)
+	if addr := cc.RemoteAddr().String(); addr != "10.0.0.1:1234" {
+		t.Errorf("Unexpected address %q, expected \"10.0.0.1:1234\"", addr)
+	}
+}
+
+func TestProxyProtocolV1Peek(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\n", "GET /"),
+		ProxyProtocolV1: true,
+	}
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		peeked, err := cc.Peek(3)
+		if err != nil {
+			t.Errorf("Unexpected error %v, expected nil", err)
+		}
+		if string(peeked) != "GET" {
+			t.Errorf("Unexpected peeked data %q, expected \"GET\"", peeked)
+		}
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("Peek deadlocked")
+	}
+	data, _ := io.ReadAll(cc)
+	if string(data) != "GET /" {
+		t.Errorf("Unexpected data %q, expected \"GET /\"", data)
+	}
+}
+
+func TestProxyProtocolV1RetriesAfterTimeout(t *testing.T) {
+	mc := proxiedConn("PROXY TCP4 192.168.0.1 ", "192.168.0.11 56324 443\r\nbacon")
+	reads := 0
+	read := mc.readHandler
+	mc.readHandler = func(b []byte) (int, error) {
+		if reads++; reads == 2 {
+			return 0, os.ErrDeadlineExceeded
+		}
+		return read(b)
+	}
+	cc := &Conn{Base: mc, ProxyProtocolV1: true}
+	if _, err := cc.Read(make([]byte, 16)); err != os.ErrDeadlineExceeded {
+		t.Errorf("Unexpected error %v, expected %v", err, os.ErrDeadlineExceeded)
+	}
+	data, err := io.ReadAll(cc)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if string(data) != "bacon" {
+		t.Errorf("Unexpected data %q, expected \"bacon\"", data)
+	}
+	if addr := cc.RemoteAddr().String(); addr != "192.168.0.1:56324" {
+		t.Errorf("Unexpected address %q, expected \"192.168.0.1:56324\"", addr)
+	}
+}
@@ -0,0 +1,192 @@
+package connxray
+
+import (
+	"net"
+	"os"
+	"sync"
+	"time"
+)
+
+// Limiter is a token bucket limiting the number of bytes transferred per
+// second, or the number of connections accepted per second when used as
+// Listener.AcceptLimiter. Its hooks (see Template) can be set on a single Conn
+// to throttle it alone, or shared by many connections (eg. through
+// Listener.ConnTemplate) to enforce a global cap. It is safe for concurrent
+// use.
+type Limiter struct {
+	rate  float64
+	burst int
+
+	mu     sync.Mutex
+	tokens float64
+	last   time.Time
+}
+
+// NewLimiter returns a Limiter allowing perSec tokens (bytes or accepts) per
+// second on average and bursts of up to burst tokens. It starts full. A
+// perSec which is not positive is raised to 1, since a Limiter which never
+// refills would block forever once its burst is used up.
+func NewLimiter(perSec, burst int) *Limiter {
+	perSec = max(perSec, 1)
+	return &Limiter{
+		rate:   float64(perSec),
+		burst:  burst,
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// RateLimited returns a template Conn (see Listener.ConnTemplate and
+// WithTemplate) with hooks of a new Limiter allowing bytesPerSec bytes per
+// second and bursts of up to burst bytes, shared by all connections the
+// template is applied to. As with NewLimiter, a bytesPerSec which is not
+// positive is raised to 1.
+func RateLimited(bytesPerSec, burst int) *Conn {
+	return NewLimiter(bytesPerSec, burst).Template()
+}
+
+// Template returns a template Conn whose 'before' hooks of Read, Write,
+// ReadFrom, WriteTo, WriteBuffers, ReadMsgUDP and WriteMsgUDP block until the
+// Limiter has enough tokens for the buffers passed to the method (or burst
+// tokens, for larger buffers) and whose 'after' hooks take tokens for the
+// bytes actually transferred. Waiting is cut short by the read or write
+// deadline of the Conn (see Deadlines), in which case the method fails with a
+// timeout wrapping os.ErrDeadlineExceeded, and by the Conn's Context, whose
+// error is then wrapped instead (see HookError).
+//
+// Only the data is charged, not out-of-band data of ReadMsgUDP and
+// WriteMsgUDP. The copy methods of StreamConn are not throttled when they take
+// the fast path of the underlying net.Conn, since no Read or Write hooks fire
+// then; don't wrap throttled connections in a StreamConn (see
+// Listener.StreamConns) if that matters.
+func (l *Limiter) Template() *Conn {
+	beforeRead := func(c *Conn, n int) error {
+		deadline, _ := c.Deadlines()
+		return l.wait(c, n, deadline)
+	}
+	beforeWrite := func(c *Conn, n int) error {
+		_, deadline := c.Deadlines()
+		return l.wait(c, n, deadline)
+	}
+	return &Conn{
+		BeforeRead: func(c *Conn, b []byte) error {
+			return beforeRead(c, len(b))
+		},
+		AfterRead: func(_ *Conn, _ []byte, n int, _ error) {
+			l.take(n)
+		},
+		BeforeReadFrom: func(c *Conn, b []byte) error {
+			return beforeRead(c, len(b))
+		},
+		AfterReadFrom: func(_ *Conn, _ []byte, n int, _ net.Addr, _ error) {
+			l.take(n)
+		},
+		BeforeReadMsgUDP: func(c *Conn, b, _ []byte) error {
+			return beforeRead(c, len(b))
+		},
+		AfterReadMsgUDP: func(_ *Conn, _, _ []byte, n, _, _ int, _ *net.UDPAddr, _ error) {
+			l.take(n)
+		},
+		BeforeWrite: func(c *Conn, b []byte) error {
+			return beforeWrite(c, len(b))
+		},
+		AfterWrite: func(_ *Conn, _ []byte, n int, _ error) {
+			l.take(n)
+		},
+		BeforeWriteTo: func(c *Conn, b []byte, _ net.Addr) error {
+			return beforeWrite(c, len(b))
+		},
+		AfterWriteTo: func(_ *Conn, _ []byte, _ net.Addr, n int, _ error) {
+			l.take(n)
+		},
+		BeforeWriteBuffers: func(c *Conn, bufs *net.Buffers) error {
+			n := 0
+			for _, b := range *bufs {
+				n += len(b)
+			}
+			return beforeWrite(c, n)
+		},
+		AfterWriteBuffers: func(_ *Conn, n int64, _ error) {
+			l.take(int(n))
+		},
+		BeforeWriteMsgUDP: func(c *Conn, b, _ []byte, _ *net.UDPAddr) error {
+			return beforeWrite(c, len(b))
+		},
+		AfterWriteMsgUDP: func(_ *Conn, _, _ []byte, _ *net.UDPAddr, n, _ int, _ error) {
+			l.take(n)
+		},
+	}
+}
+
+// wait blocks until there are n tokens available (at most burst), the
+// deadline passes or the Conn's Context is done.
+func (l *Limiter) wait(c *Conn, n int, deadline time.Time) error {
+	ctx := c.context()
+	for {
+		delay := l.delay(n)
+		if delay <= 0 {
+			return nil
+		}
+		expired := false
+		if !deadline.IsZero() {
+			if untilDeadline := time.Until(deadline); untilDeadline < delay {
+				delay, expired = untilDeadline, true
+			}
+		}
+		timer := time.NewTimer(delay)
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		case <-timer.C:
+		}
+		if expired {
+			return os.ErrDeadlineExceeded
+		}
+	}
+}
+
+// delay returns how long it will take for n tokens (at most burst) to become
+// available.
+func (l *Limiter) delay(n int) time.Duration {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	need := float64(min(n, l.burst))
+	if l.tokens >= need {
+		return 0
+	}
+	return time.Duration((need - l.tokens) / l.rate * float64(time.Second))
+}
+
+// take removes n tokens from the bucket, possibly going into debt.
+func (l *Limiter) take(n int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	l.tokens -= float64(n)
+}
+
+// tryTake removes a single token from the bucket if one is available, and
+// tells whether it did.
+func (l *Limiter) tryTake() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.refill()
+	if l.tokens < 1 {
+		return false
+	}
+	l.tokens--
+	return true
+}
+
+// refill adds tokens accrued since the last refill. It must be called with mu
+// held.
+func (l *Limiter) refill() {
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > float64(l.burst) {
+		l.tokens = float64(l.burst)
+	}
+	l.last = now
+}
@@ -0,0 +1,117 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func rateLimitedTestConn() *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		setWriteDeadlineHandler: func(time.Time) error {
+			return nil
+		},
+	}
+}
+
+func TestRateLimitedSteadyState(t *testing.T) {
+	cc := NewConn(rateLimitedTestConn(), WithTemplate(RateLimited(10000, 1000)))
+	start := time.Now()
+	buf := make([]byte, 500)
+	for written := 0; written < 5000; written += len(buf) {
+		if _, err := cc.Write(buf); err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+	}
+	// The first 1000 bytes are a burst, the remaining 4000 take 400ms.
+	if elapsed := time.Since(start); elapsed < 300*time.Millisecond || elapsed > 800*time.Millisecond {
+		t.Errorf("Unexpected elapsed time %v, expected about 400ms", elapsed)
+	}
+}
+
+func TestRateLimitedSharedLimiter(t *testing.T) {
+	template := RateLimited(10000, 1000)
+	first := NewConn(rateLimitedTestConn(), WithTemplate(template))
+	second := NewConn(rateLimitedTestConn(), WithTemplate(template))
+	start := time.Now()
+	first.Read(make([]byte, 1000))
+	second.Read(make([]byte, 1000))
+	if elapsed := time.Since(start); elapsed < 50*time.Millisecond {
+		t.Errorf("Unexpected elapsed time %v, expected about 100ms", elapsed)
+	}
+}
+
+func TestRateLimitedOtherMethods(t *testing.T) {
+	mc := &mockUDPConn{
+		mockConn: *rateLimitedTestConn(),
+		readMsgUDPHandler: func(b, _ []byte) (int, int, int, *net.UDPAddr, error) {
+			return len(b), 0, 0, nil, nil
+		},
+		writeMsgUDPHandler: func(b, _ []byte, _ *net.UDPAddr) (int, int, error) {
+			return len(b), 0, nil
+		},
+	}
+	mc.readFromHandler = func(b []byte) (int, net.Addr, error) {
+		return len(b), nil, nil
+	}
+	mc.writeToHandler = func(b []byte, _ net.Addr) (int, error) {
+		return len(b), nil
+	}
+	cc := NewConn(mc, WithTemplate(RateLimited(10000, 500)))
+	start := time.Now()
+	buf := make([]byte, 500)
+	bufs := net.Buffers{buf[:250], buf[250:]}
+	cc.WriteBuffers(&bufs)
+	cc.WriteTo(buf, nil)
+	cc.ReadFrom(buf)
+	cc.WriteMsgUDP(buf, nil, nil)
+	cc.ReadMsgUDP(buf, nil)
+	// The first 500 bytes are a burst, the remaining 2000 take 200ms.
+	if elapsed := time.Since(start); elapsed < 150*time.Millisecond || elapsed > 600*time.Millisecond {
+		t.Errorf("Unexpected elapsed time %v, expected about 200ms", elapsed)
+	}
+}
+
+func TestRateLimitedDeadline(t *testing.T) {
+	cc := NewConn(rateLimitedTestConn(), WithTemplate(RateLimited(100, 100)))
+	cc.Write(make([]byte, 100))
+	cc.SetWriteDeadline(time.Now().Add(20 * time.Millisecond))
+	start := time.Now()
+	_, err := cc.Write(make([]byte, 100))
+	if !errors.Is(err, os.ErrDeadlineExceeded) {
+		t.Errorf("Unexpected error %v, expected %v", err, os.ErrDeadlineExceeded)
+	}
+	if ne, ok := err.(net.Error); !ok || !ne.Timeout() {
+		t.Errorf("Unexpected error %v, expected a timeout", err)
+	}
+	if elapsed := time.Since(start); elapsed > 500*time.Millisecond {
+		t.Errorf("Unexpected elapsed time %v, expected about 20ms", elapsed)
+	}
+}
+
+func TestRateLimitedContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	cc := NewConn(rateLimitedTestConn(), WithContext(ctx), WithTemplate(RateLimited(100, 100)))
+	cc.Read(make([]byte, 100))
+	if _, err := cc.Read(make([]byte, 100)); !errors.Is(err, context.Canceled) {
+		t.Errorf("Unexpected error %v, expected %v", err, context.Canceled)
+	}
+}
+
+func TestNewLimiterNonPositiveRate(t *testing.T) {
+	for _, rate := range []int{0, -1} {
+		if l := NewLimiter(rate, 1); l.rate != 1 {
+			t.Errorf("Unexpected rate %v of NewLimiter(%d, 1), expected 1", l.rate, rate)
+		}
+	}
+}
@@ -0,0 +1,38 @@
+package connxray
+
+import (
+	"math/rand"
+	"sync/atomic"
+)
+
+// SampleEvery returns a function suitable for Listener.AfterAccept which
+// copies hooks from template (see Listener.ConnTemplate) onto one out of every
+// n accepted connections, starting with the first one, and leaves the others
+// uninstrumented. Values of n below 2 instrument every connection. It is safe
+// to use with Accept called from multiple goroutines.
+func SampleEvery(n int, template *Conn) func(*Listener, *Conn, error) {
+	var accepted atomic.Uint64
+	return func(_ *Listener, conn *Conn, err error) {
+		if err != nil || conn == nil {
+			return
+		}
+		if n > 1 && (accepted.Add(1)-1)%uint64(n) != 0 {
+			return
+		}
+		conn.copyHooks(template)
+	}
+}
+
+// SampleFraction returns a function suitable for Listener.AfterAccept which
+// copies hooks from template onto accepted connections with probability p,
+// and leaves the others uninstrumented.
+func SampleFraction(p float64, template *Conn) func(*Listener, *Conn, error) {
+	return func(_ *Listener, conn *Conn, err error) {
+		if err != nil || conn == nil {
+			return
+		}
+		if rand.Float64() < p {
+			conn.copyHooks(template)
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package connxray
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestSampleEvery(t *testing.T) {
+	template := &Conn{AfterClose: func(*Conn, error) {}}
+	sample := SampleEvery(3, template)
+	sampled := []int{}
+	for i := 0; i < 9; i++ {
+		conn := &Conn{}
+		sample(nil, conn, nil)
+		if conn.AfterCloseHook() != nil {
+			sampled = append(sampled, i)
+		} else if conn.BeforeReadHook() != nil || conn.AfterWriteHook() != nil {
+			t.Errorf("Unexpected hooks on connection %d", i)
+		}
+	}
+	if len(sampled) != 3 || sampled[0] != 0 || sampled[1] != 3 || sampled[2] != 6 {
+		t.Errorf("Unexpected sampled connections %v, expected [0 3 6]", sampled)
+	}
+}
+
+func TestSampleEveryIgnoresFailedAccepts(t *testing.T) {
+	template := &Conn{AfterClose: func(*Conn, error) {}}
+	sample := SampleEvery(2, template)
+	sample(nil, nil, errors.New("chunky bacon"))
+	conn := &Conn{}
+	sample(nil, conn, nil)
+	if conn.AfterCloseHook() == nil {
+		t.Error("First successfully accepted connection not sampled")
+	}
+}
+
+func TestSampleFraction(t *testing.T) {
+	template := &Conn{AfterClose: func(*Conn, error) {}}
+	for _, tc := range []struct {
+		p       float64
+		sampled bool
+	}{{0, false}, {1, true}} {
+		conn := &Conn{}
+		SampleFraction(tc.p, template)(nil, conn, nil)
+		if got := conn.AfterCloseHook() != nil; got != tc.sampled {
+			t.Errorf("Unexpected sampling %v with p=%v, expected %v", got, tc.p, tc.sampled)
+		}
+	}
+}
@@ -0,0 +1,160 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"sync"
+)
+
+var (
+	// ErrListenerClosed is returned by Accept once Shutdown has been called.
+	ErrListenerClosed = errors.New("connxray: listener shut down")
+)
+
+// connRegistry counts connections accepted by a Listener until they are
+// closed, and keeps track of the connections themselves if TrackConns is set.
+type connRegistry struct {
+	mu       sync.Mutex
+	conns    map[*Conn]struct{}
+	open     int
+	shutdown bool
+	drained  chan struct{}
+
+	// closedRead and closedWritten accumulate the traffic counters of
+	// connections which were removed. See Listener.Stats.
+	closedRead, closedWritten int64
+}
+
+// add registers c, unless the Listener is shutting down, in which case it
+// returns false. The Conn itself is only kept if track is set.
+func (r *connRegistry) add(c *Conn, track bool) bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.shutdown {
+		return false
+	}
+	r.open++
+	if !track {
+		return true
+	}
+	if r.conns == nil {
+		r.conns = make(map[*Conn]struct{})
+	}
+	r.conns[c] = struct{}{}
+	return true
+}
+
+// remove deregisters c, signalling Shutdown once the last connection is gone.
+func (r *connRegistry) remove(c *Conn) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.open--
+	delete(r.conns, c)
+	r.closedRead += c.stats.BytesRead.Load()
+	r.closedWritten += c.stats.BytesWritten.Load()
+	if r.shutdown && r.open == 0 {
+		r.closeDrained()
+	}
+}
+
+// isShutdown tells whether Shutdown has been called.
+func (r *connRegistry) isShutdown() bool {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.shutdown
+}
+
+// startShutdown stops new registrations and returns a channel closed once all
+// registered connections are closed.
+func (r *connRegistry) startShutdown() <-chan struct{} {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.shutdown = true
+	if r.drained == nil {
+		r.drained = make(chan struct{})
+		if r.open == 0 {
+			r.closeDrained()
+		}
+	}
+	return r.drained
+}
+
+// closeDrained closes the drained channel unless that already happened. It
+// must be called with mu held.
+func (r *connRegistry) closeDrained() {
+	select {
+	case <-r.drained:
+	default:
+		close(r.drained)
+	}
+}
+
+// len returns the number of open connections.
+func (r *connRegistry) len() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.open
+}
+
+// snapshot returns the currently tracked connections.
+func (r *connRegistry) snapshot() []*Conn {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	conns := make([]*Conn, 0, len(r.conns))
+	for c := range r.conns {
+		conns = append(conns, c)
+	}
+	return conns
+}
+
+// register makes the Listener count (and, with TrackConns, track) conn until it
+// is closed. It returns false if the Listener is shutting down.
+func (l *Listener) register(conn *Conn) bool {
+	if !l.conns.add(conn, l.TrackConns) {
+		return false
+	}
+	conn.onClose(l.conns.remove)
+	return true
+}
+
+// ActiveConns returns the connections accepted by the Listener which are not
+// closed yet, in no particular order, eg. to inspect or close some of them. It
+// returns an empty slice unless TrackConns is set. See also Len.
+func (l *Listener) ActiveConns() []*Conn {
+	return l.conns.snapshot()
+}
+
+// Len returns the number of connections accepted by the Listener which are not
+// closed yet, whether or not TrackConns is set.
+func (l *Listener) Len() int {
+	return l.conns.len()
+}
+
+// Shutdown gracefully shuts down the Listener, similarly to http.Server's
+// Shutdown: it closes the Listener, so that Accept returns ErrListenerClosed
+// from then on, and waits for all connections accepted by it to be closed. If
+// OnGoingAway is set it is invoked with every open connection before waiting.
+// If ctx is done first the remaining connections are closed forcibly and
+// ctx.Err() is returned. Otherwise the error returned by Close is returned.
+//
+// Invoking OnGoingAway and closing connections forcibly both need TrackConns;
+// without it Shutdown still waits for connections to be closed, but leaves
+// those still open when ctx is done alone.
+func (l *Listener) Shutdown(ctx context.Context) error {
+	drained := l.conns.startShutdown()
+	err := l.Close()
+	if hook := l.OnGoingAway; hook != nil {
+		for _, conn := range l.conns.snapshot() {
+			l.guard("OnGoingAway", func() { hook(l, conn) })
+		}
+	}
+	select {
+	case <-drained:
+		return err
+	case <-ctx.Done():
+	}
+	for _, conn := range l.conns.snapshot() {
+		conn.Close()
+	}
+	return ctx.Err()
+}
@@ -0,0 +1,187 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"net"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// acceptN accepts n connections from l.
+func acceptN(t *testing.T, l *Listener, n int) []net.Conn {
+	t.Helper()
+	conns := []net.Conn{}
+	for i := 0; i < n; i++ {
+		conn, err := l.Accept()
+		if err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+		conns = append(conns, conn)
+	}
+	return conns
+}
+
+func TestShutdownDrains(t *testing.T) {
+	l := &Listener{Base: pipeListener(t)}
+	conns := acceptN(t, l, 3)
+	conns[0].Close()
+	done := make(chan error)
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+		defer cancel()
+		done <- l.Shutdown(ctx)
+	}()
+	time.Sleep(10 * time.Millisecond)
+	conns[1].Close()
+	select {
+	case <-done:
+		t.Fatal("Shutdown returned with a connection still open")
+	case <-time.After(20 * time.Millisecond):
+	}
+	conns[2].Close()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Errorf("Unexpected error %v, expected nil", err)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Shutdown did not return after all connections were closed")
+	}
+	if _, err := l.Accept(); err != ErrListenerClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrListenerClosed)
+	}
+}
+
+func TestShutdownForceCloses(t *testing.T) {
+	var closes atomic.Int32
+	l := &Listener{
+		Base:       pipeListener(t),
+		TrackConns: true,
+		ConnTemplate: &Conn{
+			AfterClose: func(*Conn, error) {
+				closes.Add(1)
+			},
+		},
+	}
+	acceptN(t, l, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error %v, expected %v", err, context.DeadlineExceeded)
+	}
+	if n := closes.Load(); n != 2 {
+		t.Errorf("Unexpected number of closed connections %d, expected 2", n)
+	}
+	if n := len(l.conns.snapshot()); n != 0 {
+		t.Errorf("Unexpected number of tracked connections %d, expected 0", n)
+	}
+}
+
+func TestShutdownUntrackedConns(t *testing.T) {
+	l := &Listener{Base: pipeListener(t)}
+	conns := acceptN(t, l, 2)
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if err := l.Shutdown(ctx); !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Unexpected error %v, expected %v", err, context.DeadlineExceeded)
+	}
+	for _, conn := range conns {
+		if conn.(*Conn).IsClosed() {
+			t.Error("Untracked connection unexpectedly closed")
+		}
+	}
+}
+
+func TestShutdownWithoutConns(t *testing.T) {
+	l := &Listener{Base: pipeListener(t)}
+	if err := l.Shutdown(context.Background()); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+}
+
+func TestActiveConns(t *testing.T) {
+	l := &Listener{Base: pipeListener(t), TrackConns: true}
+	if active := l.ActiveConns(); len(active) != 0 {
+		t.Errorf("Unexpected number of active conns %d, expected 0", len(active))
+	}
+	conns := acceptN(t, l, 3)
+	conns[1].Close()
+	active := l.ActiveConns()
+	if len(active) != 2 {
+		t.Fatalf("Unexpected number of active conns %d, expected 2", len(active))
+	}
+	for _, conn := range active {
+		if conn == conns[1] {
+			t.Error("Closed conn unexpectedly reported as active")
+		}
+	}
+	if n := l.Len(); n != 2 {
+		t.Errorf("Unexpected number of open conns %d, expected 2", n)
+	}
+}
+
+func TestActiveConnsUntracked(t *testing.T) {
+	l := &Listener{Base: pipeListener(t)}
+	conns := acceptN(t, l, 3)
+	conns[1].Close()
+	if active := l.ActiveConns(); len(active) != 0 {
+		t.Errorf("Unexpected number of active conns %d, expected 0", len(active))
+	}
+	if n := len(l.conns.conns); n != 0 {
+		t.Errorf("Unexpected number of tracked connections %d, expected 0", n)
+	}
+	if n := l.Len(); n != 2 {
+		t.Errorf("Unexpected number of open conns %d, expected 2", n)
+	}
+	if n := l.Stats().Open; n != 2 {
+		t.Errorf("Unexpected number of open conns in stats %d, expected 2", n)
+	}
+}
+
+func TestShutdownGoingAway(t *testing.T) {
+	var closed sync.Map
+	l := &Listener{
+		Base:       pipeListener(t),
+		TrackConns: true,
+		ConnTemplate: &Conn{
+			AfterClose: func(c *Conn, _ error) {
+				closed.Store(c, true)
+			},
+		},
+	}
+	var mu sync.Mutex
+	notified := map[*Conn]bool{}
+	l.OnGoingAway = func(gl *Listener, c *Conn) {
+		if gl != l {
+			t.Errorf("Unexpected listener %p, expected %p", gl, l)
+		}
+		if _, ok := closed.Load(c); ok {
+			t.Error("Unexpected going-away notification for a closed connection")
+		}
+		mu.Lock()
+		notified[c] = true
+		mu.Unlock()
+		// Behave like a well-mannered peer and go away.
+		go c.Close()
+	}
+	conns := acceptN(t, l, 3)
+	conns[0].Close()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	if err := l.Shutdown(ctx); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	mu.Lock()
+	defer mu.Unlock()
+	if len(notified) != 2 {
+		t.Errorf("Unexpected number of notified connections %d, expected 2", len(notified))
+	}
+	for _, conn := range conns[1:] {
+		if !notified[conn.(*Conn)] {
+			t.Errorf("Connection %p was not notified", conn)
+		}
+	}
+}
@@ -0,0 +1,126 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+)
+
+// Attribute keys used by SlogHooks and SlogListenerHooks.
+const (
+	slogKeyRemoteAddr = "remote_addr"
+	slogKeyBytes      = "bytes"
+	slogKeyErr        = "err"
+)
+
+// SlogHooks returns a template Conn (see Listener.ConnTemplate and
+// WithTemplate) whose hooks log to logger. Reads and writes are logged at
+// Debug level with the number of bytes transferred, failed reads and writes
+// at Warn level (except for io.EOF, which is a normal end of a stream) and
+// Close at Info level. All records carry the remote address of the underlying
+// net.Conn under the "remote_addr" key and errors under the "err" key.
+//
+// Nothing is allocated on the Read and Write paths unless logger is enabled
+// for the level in question, so it is fine to leave these hooks in place and
+// control verbosity through the logger's handler.
+func SlogHooks(logger *slog.Logger) *Conn {
+	return &Conn{
+		AfterRead: func(c *Conn, _ []byte, n int, err error) {
+			logTransfer(logger, c, "read", n, err)
+		},
+		AfterWrite: func(c *Conn, _ []byte, n int, err error) {
+			logTransfer(logger, c, "write", n, err)
+		},
+		AfterClose: func(c *Conn, err error) {
+			level := slog.LevelInfo
+			if err != nil {
+				level = slog.LevelWarn
+			}
+			logConn(logger, level, c, "close", err)
+		},
+	}
+}
+
+// SlogListenerHooks returns functions suitable for Listener.AfterAccept and
+// Listener.AfterClose which log to logger, using the same attribute keys as
+// SlogHooks. Accepted connections and closing the Listener are logged at Info
+// level, failures at Warn level.
+func SlogListenerHooks(logger *slog.Logger) (afterAccept func(*Listener, *Conn, error), afterClose func(*Listener, error)) {
+	afterAccept = func(_ *Listener, c *Conn, err error) {
+		if err != nil {
+			logger.LogAttrs(context.Background(), slog.LevelWarn, "accept", slog.Any(slogKeyErr, err))
+			return
+		}
+		logConn(logger, slog.LevelInfo, c, "accept", nil)
+	}
+	afterClose = func(l *Listener, err error) {
+		level := slog.LevelInfo
+		if err != nil {
+			level = slog.LevelWarn
+		}
+		if !logger.Enabled(context.Background(), level) {
+			return
+		}
+		attrs := []slog.Attr{slog.String("addr", addrString(l.Base.Addr()))}
+		if err != nil {
+			attrs = append(attrs, slog.Any(slogKeyErr, err))
+		}
+		logger.LogAttrs(context.Background(), level, "listener close", attrs...)
+	}
+	return afterAccept, afterClose
+}
+
+// logTransfer logs the outcome of a Read or Write.
+func logTransfer(logger *slog.Logger, c *Conn, msg string, n int, err error) {
+	level := slog.LevelDebug
+	if err != nil && !errors.Is(err, io.EOF) {
+		level = slog.LevelWarn
+	}
+	ctx := c.context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	if err == nil {
+		logger.LogAttrs(ctx, level, msg,
+			slog.String(slogKeyRemoteAddr, remoteAddrString(c)),
+			slog.Int(slogKeyBytes, n))
+		return
+	}
+	logger.LogAttrs(ctx, level, msg,
+		slog.String(slogKeyRemoteAddr, remoteAddrString(c)),
+		slog.Int(slogKeyBytes, n),
+		slog.Any(slogKeyErr, err))
+}
+
+// logConn logs an event concerning the whole connection.
+func logConn(logger *slog.Logger, level slog.Level, c *Conn, msg string, err error) {
+	ctx := c.context()
+	if !logger.Enabled(ctx, level) {
+		return
+	}
+	if err == nil {
+		logger.LogAttrs(ctx, level, msg, slog.String(slogKeyRemoteAddr, remoteAddrString(c)))
+		return
+	}
+	logger.LogAttrs(ctx, level, msg,
+		slog.String(slogKeyRemoteAddr, remoteAddrString(c)),
+		slog.Any(slogKeyErr, err))
+}
+
+// remoteAddrString returns the remote address of the Conn, bypassing the
+// RemoteAddr hooks so that logging does not trigger them.
+func remoteAddrString(c *Conn) string {
+	if override := c.remoteAddr.Load(); override != nil {
+		return addrString(*override)
+	}
+	return addrString(c.Base.RemoteAddr())
+}
+
+func addrString(addr net.Addr) string {
+	if addr == nil {
+		return ""
+	}
+	return addr.String()
+}
@@ -0,0 +1,148 @@
+package connxray
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"sync"
+	"testing"
+)
+
+// recordingHandler is a slog.Handler which keeps all records it handles.
+type recordingHandler struct {
+	level   slog.Level
+	mu      sync.Mutex
+	records []slog.Record
+}
+
+func (h *recordingHandler) Enabled(_ context.Context, level slog.Level) bool {
+	return level >= h.level
+}
+
+func (h *recordingHandler) Handle(_ context.Context, r slog.Record) error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.records = append(h.records, r)
+	return nil
+}
+
+func (h *recordingHandler) WithAttrs([]slog.Attr) slog.Handler { return h }
+
+func (h *recordingHandler) WithGroup(string) slog.Handler { return h }
+
+// attrs returns the attributes of the i-th record as strings.
+func (h *recordingHandler) attrs(i int) map[string]string {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	ret := make(map[string]string)
+	h.records[i].Attrs(func(a slog.Attr) bool {
+		ret[a.Key] = a.Value.String()
+		return true
+	})
+	return ret
+}
+
+func slogTestConn() *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "bacon"), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return 0, errors.New("broken pipe")
+		},
+		closeHandler: func() error {
+			return nil
+		},
+		remoteAddrHandler: func() net.Addr {
+			return &net.TCPAddr{IP: net.IPv4(10, 0, 0, 1), Port: 1234}
+		},
+	}
+}
+
+func TestSlogHooks(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelDebug}
+	cc := NewConn(slogTestConn(), WithTemplate(SlogHooks(slog.New(handler))))
+	cc.Read(make([]byte, 16))
+	cc.Write([]byte("bacon"))
+	cc.Close()
+	if len(handler.records) != 3 {
+		t.Fatalf("Unexpected number of records %d, expected 3", len(handler.records))
+	}
+	expected := []struct {
+		msg   string
+		level slog.Level
+		attrs map[string]string
+	}{
+		{"read", slog.LevelDebug, map[string]string{"remote_addr": "10.0.0.1:1234", "bytes": "5"}},
+		{"write", slog.LevelWarn, map[string]string{"remote_addr": "10.0.0.1:1234", "bytes": "0", "err": "broken pipe"}},
+		{"close", slog.LevelInfo, map[string]string{"remote_addr": "10.0.0.1:1234"}},
+	}
+	for i, e := range expected {
+		r := handler.records[i]
+		if r.Message != e.msg || r.Level != e.level {
+			t.Errorf("Unexpected record (%q, %v), expected (%q, %v)", r.Message, r.Level, e.msg, e.level)
+		}
+		attrs := handler.attrs(i)
+		if len(attrs) != len(e.attrs) {
+			t.Errorf("Unexpected attributes %v, expected %v", attrs, e.attrs)
+		}
+		for k, v := range e.attrs {
+			if attrs[k] != v {
+				t.Errorf("Unexpected %q attribute %q, expected %q", k, attrs[k], v)
+			}
+		}
+	}
+}
+
+func TestSlogHooksLevelGating(t *testing.T) {
+	mc := slogTestConn()
+	mc.readHandler = func([]byte) (int, error) {
+		return 0, io.EOF
+	}
+	handler := &recordingHandler{level: slog.LevelInfo}
+	cc := NewConn(mc, WithTemplate(SlogHooks(slog.New(handler))))
+	cc.Read(make([]byte, 16))
+	if len(handler.records) != 0 {
+		t.Errorf("Unexpected number of records %d, expected 0", len(handler.records))
+	}
+}
+
+func TestSlogListenerHooks(t *testing.T) {
+	handler := &recordingHandler{level: slog.LevelDebug}
+	expectedErr := errors.New("bacon")
+	accepted := 0
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			accepted++
+			if accepted > 1 {
+				return nil, expectedErr
+			}
+			return slogTestConn(), nil
+		},
+		closeHandler: func() error {
+			return nil
+		},
+		addrHandler: func() net.Addr {
+			return &net.TCPAddr{IP: net.IPv4(127, 0, 0, 1), Port: 80}
+		},
+	}
+	afterAccept, afterClose := SlogListenerHooks(slog.New(handler))
+	ll := &Listener{Base: ml, AfterAccept: afterAccept, AfterClose: afterClose}
+	ll.Accept()
+	ll.Accept()
+	ll.Close()
+	if len(handler.records) != 3 {
+		t.Fatalf("Unexpected number of records %d, expected 3", len(handler.records))
+	}
+	if addr := handler.attrs(0)["remote_addr"]; addr != "10.0.0.1:1234" {
+		t.Errorf("Unexpected remote_addr %q, expected \"10.0.0.1:1234\"", addr)
+	}
+	if err := handler.attrs(1)["err"]; err != "bacon" {
+		t.Errorf("Unexpected err %q, expected \"bacon\"", err)
+	}
+	if r := handler.records[2]; r.Message != "listener close" || r.Level != slog.LevelInfo {
+		t.Errorf("Unexpected record (%q, %v), expected (\"listener close\", INFO)", r.Message, r.Level)
+	}
+}
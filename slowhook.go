@@ -0,0 +1,14 @@
+package connxray
+
+import (
+	"time"
+)
+
+// reportSlowHook reports a hook which started at start to onSlow if it took
+// longer than threshold. It is meant to be deferred by hooks timed due to
+// MeasureHookLatency.
+func reportSlowHook(onSlow func(*Conn, string, time.Duration), threshold time.Duration, conn *Conn, hook string, start time.Time) {
+	if d := time.Since(start); d > threshold {
+		onSlow(conn, hook, d)
+	}
+}
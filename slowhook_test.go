@@ -0,0 +1,78 @@
+package connxray
+
+import (
+	"testing"
+	"time"
+)
+
+func TestOnSlowHook(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	var hooks []string
+	var durations []time.Duration
+	cc := &Conn{
+		Base:               mc,
+		MeasureHookLatency: true,
+		SlowHookThreshold:  5 * time.Millisecond,
+		OnSlowHook: func(_ *Conn, hook string, d time.Duration) {
+			hooks = append(hooks, hook)
+			durations = append(durations, d)
+		},
+		BeforeWrite: func(*Conn, []byte) error {
+			return nil
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			time.Sleep(10 * time.Millisecond)
+		},
+	}
+	cc.Write([]byte("bacon"))
+	if len(hooks) != 1 || hooks[0] != "AfterWrite" {
+		t.Fatalf("Unexpected slow hooks %v, expected [AfterWrite]", hooks)
+	}
+	if durations[0] < 10*time.Millisecond {
+		t.Errorf("Unexpected duration %v, expected at least 10ms", durations[0])
+	}
+}
+
+func TestOnSlowHookDisabled(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		OnSlowHook: func(*Conn, string, time.Duration) {
+			t.Error("OnSlowHook invoked without MeasureHookLatency")
+		},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			time.Sleep(time.Millisecond)
+		},
+	}
+	cc.Write([]byte("bacon"))
+}
+
+func TestOnSlowHookWithPanic(t *testing.T) {
+	var slow, panicked string
+	cc := &Conn{
+		Base:               &mockConn{},
+		MeasureHookLatency: true,
+		OnSlowHook: func(_ *Conn, hook string, _ time.Duration) {
+			slow = hook
+		},
+		OnHookPanic: func(_ *Conn, hook string, _ interface{}) {
+			panicked = hook
+		},
+		BeforeRead: func(*Conn, []byte) error {
+			time.Sleep(time.Millisecond)
+			panic("boom")
+		},
+	}
+	cc.Read(nil)
+	if slow != "BeforeRead" || panicked != "BeforeRead" {
+		t.Errorf("Unexpected hooks (%q, %q), expected (\"BeforeRead\", \"BeforeRead\")", slow, panicked)
+	}
+}
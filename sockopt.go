@@ -0,0 +1,81 @@
+package connxray
+
+import (
+	"errors"
+	"time"
+)
+
+var (
+	// ErrUnsupportedByBase signifies that the underlying net.Conn does not
+	// support the socket option being set.
+	ErrUnsupportedByBase = errors.New("this net.Conn does not support the option")
+)
+
+// setSocketOption sets a socket option on the underlying net.Conn, if it
+// implements T, and invokes the AfterSetSocketOption hook with the name of
+// the method.
+func setSocketOption[T any](c *Conn, name string, set func(T) error) error {
+	defer c.spentInMethod(c.now())
+	err := ErrUnsupportedByBase
+	if base, implements := c.Base.(T); implements {
+		start := c.now()
+		err = set(base)
+		c.spentInBase(start)
+	}
+	if hook := c.AfterSetSocketOptionHook(); hook != nil {
+		hook(c, name, err)
+	}
+	return err
+}
+
+// SetReadBuffer sets the size of the operating system's receive buffer of the
+// underlying net.Conn (see net.TCPConn.SetReadBuffer), or returns
+// ErrUnsupportedByBase.
+func (c *Conn) SetReadBuffer(bytes int) error {
+	return setSocketOption(c, "SetReadBuffer", func(base interface{ SetReadBuffer(int) error }) error {
+		return base.SetReadBuffer(bytes)
+	})
+}
+
+// SetWriteBuffer sets the size of the operating system's transmit buffer of
+// the underlying net.Conn (see net.TCPConn.SetWriteBuffer), or returns
+// ErrUnsupportedByBase.
+func (c *Conn) SetWriteBuffer(bytes int) error {
+	return setSocketOption(c, "SetWriteBuffer", func(base interface{ SetWriteBuffer(int) error }) error {
+		return base.SetWriteBuffer(bytes)
+	})
+}
+
+// SetNoDelay controls Nagle's algorithm on the underlying net.Conn (see
+// net.TCPConn.SetNoDelay), or returns ErrUnsupportedByBase.
+func (c *Conn) SetNoDelay(noDelay bool) error {
+	return setSocketOption(c, "SetNoDelay", func(base interface{ SetNoDelay(bool) error }) error {
+		return base.SetNoDelay(noDelay)
+	})
+}
+
+// SetKeepAlive enables or disables keep-alive messages on the underlying
+// net.Conn (see net.TCPConn.SetKeepAlive), or returns ErrUnsupportedByBase.
+func (c *Conn) SetKeepAlive(keepalive bool) error {
+	return setSocketOption(c, "SetKeepAlive", func(base interface{ SetKeepAlive(bool) error }) error {
+		return base.SetKeepAlive(keepalive)
+	})
+}
+
+// SetKeepAlivePeriod sets the period between keep-alive messages on the
+// underlying net.Conn (see net.TCPConn.SetKeepAlivePeriod), or returns
+// ErrUnsupportedByBase.
+func (c *Conn) SetKeepAlivePeriod(d time.Duration) error {
+	return setSocketOption(c, "SetKeepAlivePeriod", func(base interface{ SetKeepAlivePeriod(time.Duration) error }) error {
+		return base.SetKeepAlivePeriod(d)
+	})
+}
+
+// SetLinger sets the behavior of Close on the underlying net.Conn when data
+// is still waiting to be sent (see net.TCPConn.SetLinger), or returns
+// ErrUnsupportedByBase.
+func (c *Conn) SetLinger(sec int) error {
+	return setSocketOption(c, "SetLinger", func(base interface{ SetLinger(int) error }) error {
+		return base.SetLinger(sec)
+	})
+}
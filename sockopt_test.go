@@ -0,0 +1,68 @@
+package connxray
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestSocketOptions(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	var gotBytes int
+	mc := &mockSocketOptionConn{
+		setReadBufferHandler: func(bytes int) error {
+			gotBytes = bytes
+			return nil
+		},
+		setNoDelayHandler: func(bool) error {
+			return expErr
+		},
+	}
+	type call struct {
+		name string
+		err  error
+	}
+	calls := []call{}
+	cc := &Conn{
+		Base: &Conn{Base: mc},
+		AfterSetSocketOption: func(_ *Conn, name string, err error) {
+			calls = append(calls, call{name, err})
+		},
+	}
+	if err := cc.SetReadBuffer(1 << 16); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if gotBytes != 1<<16 {
+		t.Errorf("Unexpected buffer size %d, expected %d", gotBytes, 1<<16)
+	}
+	if err := cc.SetNoDelay(true); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if err := cc.SetLinger(0); err != ErrUnsupportedByBase {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrUnsupportedByBase)
+	}
+	exp := []call{
+		{"SetReadBuffer", nil},
+		{"SetNoDelay", expErr},
+		{"SetLinger", ErrUnsupportedByBase},
+	}
+	if !reflect.DeepEqual(calls, exp) {
+		t.Errorf("Unexpected calls %v, expected %v", calls, exp)
+	}
+}
+
+func TestSocketOptionsTCP(t *testing.T) {
+	client, _ := tcpConnPair(t)
+	cc := &Conn{Base: client}
+	for name, set := range map[string]func() error{
+		"SetReadBuffer":  func() error { return cc.SetReadBuffer(1 << 16) },
+		"SetWriteBuffer": func() error { return cc.SetWriteBuffer(1 << 16) },
+		"SetNoDelay":     func() error { return cc.SetNoDelay(false) },
+		"SetKeepAlive":   func() error { return cc.SetKeepAlive(true) },
+		"SetLinger":      func() error { return cc.SetLinger(0) },
+	} {
+		if err := set(); err != nil {
+			t.Errorf("Unexpected error %v from %s, expected nil", err, name)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"sync/atomic"
+	"time"
+)
+
+// Stats holds traffic counters of a Conn, maintained while Conn.TrackStats is
+// set. Reads and ReadErrors cover both Read and ReadFrom, Writes and
+// WriteErrors cover both Write and WriteTo. Any non-nil error returned by the
+// underlying net.Conn (including io.EOF) counts as an error. EOFs counts reads
+// which returned io.EOF, whether or not they also returned data, while
+// ReadTimeouts and WriteTimeouts count errors which are timeouts.
+type Stats struct {
+	BytesRead     atomic.Int64
+	BytesWritten  atomic.Int64
+	Reads         atomic.Int64
+	Writes        atomic.Int64
+	ReadErrors    atomic.Int64
+	WriteErrors   atomic.Int64
+	EOFs          atomic.Int64
+	ReadTimeouts  atomic.Int64
+	WriteTimeouts atomic.Int64
+}
+
+// StatsSnapshot is a point-in-time copy of Stats. It also carries the creation
+// time of the Conn and its Age at the time of the snapshot, which are zero if
+// the creation time is unknown.
+type StatsSnapshot struct {
+	BytesRead     int64
+	BytesWritten  int64
+	Reads         int64
+	Writes        int64
+	ReadErrors    int64
+	WriteErrors   int64
+	EOFs          int64
+	ReadTimeouts  int64
+	WriteTimeouts int64
+	Created       time.Time
+	Age           time.Duration
+}
+
+// recordRead accounts for a read of n bytes which returned err.
+func (s *Stats) recordRead(n int, err error) {
+	s.Reads.Add(1)
+	s.BytesRead.Add(int64(n))
+	if err != nil {
+		s.ReadErrors.Add(1)
+	}
+	if errors.Is(err, io.EOF) {
+		s.EOFs.Add(1)
+	}
+	if isTimeout(err) {
+		s.ReadTimeouts.Add(1)
+	}
+}
+
+// recordWrite accounts for a write of n bytes which returned err.
+func (s *Stats) recordWrite(n int, err error) {
+	s.Writes.Add(1)
+	s.BytesWritten.Add(int64(n))
+	if err != nil {
+		s.WriteErrors.Add(1)
+	}
+	if isTimeout(err) {
+		s.WriteTimeouts.Add(1)
+	}
+}
+
+// snapshot loads all counters. Counters are loaded one by one so the
+// snapshot may be slightly skewed if taken while I/O is in progress.
+func (s *Stats) snapshot() StatsSnapshot {
+	return StatsSnapshot{
+		BytesRead:     s.BytesRead.Load(),
+		BytesWritten:  s.BytesWritten.Load(),
+		Reads:         s.Reads.Load(),
+		Writes:        s.Writes.Load(),
+		ReadErrors:    s.ReadErrors.Load(),
+		WriteErrors:   s.WriteErrors.Load(),
+		EOFs:          s.EOFs.Load(),
+		ReadTimeouts:  s.ReadTimeouts.Load(),
+		WriteTimeouts: s.WriteTimeouts.Load(),
+	}
+}
+
+// Stats returns a copy of traffic counters of the Conn. It is safe to call
+// concurrently with I/O. All counters stay at zero unless TrackStats is set.
+func (c *Conn) Stats() StatsSnapshot {
+	snapshot := c.stats.snapshot()
+	snapshot.Created = c.created
+	snapshot.Age = c.Age()
+	return snapshot
+}
+
+// StatsDiff returns the difference between the current traffic counters of
+// the Conn and prev, an earlier result of Stats, ie. the traffic in between.
+// Created is that of the Conn and Age is the time elapsed between the two
+// snapshots if the creation time is known, so that callers can compute
+// per-interval rates.
+func (c *Conn) StatsDiff(prev StatsSnapshot) StatsSnapshot {
+	return c.Stats().sub(prev)
+}
+
+// sub returns the counters of s minus those of prev.
+func (s StatsSnapshot) sub(prev StatsSnapshot) StatsSnapshot {
+	return StatsSnapshot{
+		BytesRead:     s.BytesRead - prev.BytesRead,
+		BytesWritten:  s.BytesWritten - prev.BytesWritten,
+		Reads:         s.Reads - prev.Reads,
+		Writes:        s.Writes - prev.Writes,
+		ReadErrors:    s.ReadErrors - prev.ReadErrors,
+		WriteErrors:   s.WriteErrors - prev.WriteErrors,
+		EOFs:          s.EOFs - prev.EOFs,
+		ReadTimeouts:  s.ReadTimeouts - prev.ReadTimeouts,
+		WriteTimeouts: s.WriteTimeouts - prev.WriteTimeouts,
+		Created:       s.Created,
+		Age:           s.Age - prev.Age,
+	}
+}
+
+// trackRead updates traffic counters after a read from the underlying
+// net.Conn, if TrackStats is set, and the warmup throughput.
+func (c *Conn) trackRead(n int, err error) {
+	c.trackWarmup(n)
+	if c.TrackStats {
+		c.stats.recordRead(n, err)
+	}
+}
+
+// trackWrite updates traffic counters after a write to the underlying
+// net.Conn, if TrackStats is set, and the warmup throughput.
+func (c *Conn) trackWrite(n int, err error) {
+	c.trackWarmup(n)
+	if c.TrackStats {
+		c.stats.recordWrite(n, err)
+	}
+}
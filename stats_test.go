@@ -0,0 +1,149 @@
+package connxray
+
+import (
+	"errors"
+	"io"
+	"net"
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStatsPartialReadsAndWrites(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	reads := []struct {
+		n   int
+		err error
+	}{{3, nil}, {2, io.EOF}}
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			r := reads[0]
+			reads = reads[1:]
+			return r.n, r.err
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b) - 1, expErr
+		},
+	}
+	cc := &Conn{Base: mc, TrackStats: true}
+	buf := make([]byte, 10)
+	cc.Read(buf)
+	cc.Read(buf)
+	cc.Write(buf)
+	exp := StatsSnapshot{
+		BytesRead:    5,
+		BytesWritten: 9,
+		Reads:        2,
+		Writes:       1,
+		ReadErrors:   1,
+		WriteErrors:  1,
+		EOFs:         1,
+	}
+	if got := cc.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
+
+func TestStatsPacketConn(t *testing.T) {
+	mc := &mockConn{
+		readFromHandler: func(b []byte) (int, net.Addr, error) {
+			return 4, nil, nil
+		},
+		writeToHandler: func(b []byte, _ net.Addr) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, TrackStats: true}
+	cc.ReadFrom(make([]byte, 8))
+	cc.WriteTo(make([]byte, 6), nil)
+	exp := StatsSnapshot{BytesRead: 4, BytesWritten: 6, Reads: 1, Writes: 1}
+	if got := cc.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
+
+func TestStatsDisabled(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	cc.Read(make([]byte, 10))
+	if got := cc.Stats(); got != (StatsSnapshot{}) {
+		t.Errorf("Unexpected stats %+v, expected zero value", got)
+	}
+}
+
+func TestStatsConcurrentAccess(t *testing.T) {
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc, TrackStats: true}
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cc.Write([]byte("ab"))
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				cc.Stats()
+			}
+		}()
+	}
+	wg.Wait()
+	if got := cc.Stats(); got.Writes != 400 || got.BytesWritten != 800 {
+		t.Errorf("Unexpected stats %+v, expected 400 writes of 800 bytes", got)
+	}
+}
+
+func TestStatsAge(t *testing.T) {
+	cc := NewConn(&mockConn{})
+	time.Sleep(10 * time.Millisecond)
+	got := cc.Stats()
+	if got.Created != cc.created {
+		t.Errorf("Unexpected creation time %v, expected %v", got.Created, cc.created)
+	}
+	if got.Age < 10*time.Millisecond {
+		t.Errorf("Unexpected age %v, expected at least 10ms", got.Age)
+	}
+}
+
+func TestStatsDiff(t *testing.T) {
+	cc := NewConn(&mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "bacon"), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}, WithStats())
+	buf := make([]byte, 8)
+	cc.Read(buf)
+	cc.Write([]byte("chunky"))
+	prev := cc.Stats()
+	time.Sleep(10 * time.Millisecond)
+	for i := 0; i < 3; i++ {
+		cc.Read(buf)
+	}
+	cc.Write([]byte("chunky bacon"))
+	diff := cc.StatsDiff(prev)
+	if diff.Age < 10*time.Millisecond || diff.Age > cc.Age() {
+		t.Errorf("Unexpected interval %v, expected between 10ms and %v", diff.Age, cc.Age())
+	}
+	if diff.Created != cc.created {
+		t.Errorf("Unexpected creation time %v, expected %v", diff.Created, cc.created)
+	}
+	diff.Created, diff.Age = time.Time{}, 0
+	exp := StatsSnapshot{BytesRead: 15, BytesWritten: 12, Reads: 3, Writes: 1}
+	if diff != exp {
+		t.Errorf("Unexpected diff %+v, expected %+v", diff, exp)
+	}
+}
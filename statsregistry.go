@@ -0,0 +1,49 @@
+package connxray
+
+import (
+	"sync"
+)
+
+// StatsRegistry aggregates the counters of many Listeners, so that a process
+// running several of them can report consolidated figures. The zero value is
+// an empty registry ready to use.
+type StatsRegistry struct {
+	mu        sync.Mutex
+	listeners map[*Listener]struct{}
+}
+
+// Register adds l to the registry. Registering a Listener more than once has
+// no further effect.
+func (r *StatsRegistry) Register(l *Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.listeners == nil {
+		r.listeners = make(map[*Listener]struct{})
+	}
+	r.listeners[l] = struct{}{}
+}
+
+// Unregister removes l from the registry, so that its counters no longer
+// contribute to Snapshot.
+func (r *StatsRegistry) Unregister(l *Listener) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	delete(r.listeners, l)
+}
+
+// Snapshot returns the sums of the counters (see Listener.Stats) of all
+// registered Listeners.
+func (r *StatsRegistry) Snapshot() ListenerStatsSnapshot {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var total ListenerStatsSnapshot
+	for l := range r.listeners {
+		snap := l.Stats()
+		total.Accepted += snap.Accepted
+		total.Open += snap.Open
+		total.AcceptErrors += snap.AcceptErrors
+		total.BytesRead += snap.BytesRead
+		total.BytesWritten += snap.BytesWritten
+	}
+	return total
+}
@@ -0,0 +1,55 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+)
+
+// baconListener returns a Listener tracking stats and connections, whose connections read
+// "bacon" and accept all writes.
+func baconListener() *Listener {
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return &mockConn{
+				readHandler: func(b []byte) (int, error) {
+					return copy(b, "bacon"), nil
+				},
+				writeHandler: func(b []byte) (int, error) {
+					return len(b), nil
+				},
+				closeHandler: func() error {
+					return nil
+				},
+			}, nil
+		},
+	}
+	return &Listener{Base: ml, TrackConnStats: true, TrackConns: true}
+}
+
+func TestStatsRegistry(t *testing.T) {
+	var registry StatsRegistry
+	if got := registry.Snapshot(); got != (ListenerStatsSnapshot{}) {
+		t.Errorf("Unexpected stats %+v, expected zero", got)
+	}
+	l1, l2 := baconListener(), baconListener()
+	registry.Register(l1)
+	registry.Register(l2)
+	registry.Register(l2)
+	buf := make([]byte, 8)
+	c1, _ := l1.Accept()
+	c1.Read(buf)
+	c1.Close()
+	for i := 0; i < 2; i++ {
+		c2, _ := l2.Accept()
+		c2.Write([]byte("chunky"))
+	}
+	exp := ListenerStatsSnapshot{Accepted: 3, Open: 2, BytesRead: 5, BytesWritten: 12}
+	if got := registry.Snapshot(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+	registry.Unregister(l1)
+	exp = l2.Stats()
+	if got := registry.Snapshot(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
@@ -0,0 +1,115 @@
+package connxray
+
+import (
+	"io"
+)
+
+// StreamConn is a view of a Conn for stream-oriented connections (eg. TCP)
+// which, unlike Conn itself, implements io.ReaderFrom and io.WriterTo. Conn
+// can't do that since its ReadFrom and WriteTo methods implement
+// net.PacketConn instead. Code such as io.Copy and net/http looks for these
+// interfaces to use zero-copy fast paths like sendfile and splice, so wrapping
+// a TCP connection in a StreamConn (see Conn.Stream and Listener.StreamConns)
+// keeps these optimizations working.
+//
+// All other methods are those of the wrapped Conn, hooks included.
+type StreamConn struct {
+	*Conn
+}
+
+// Stream returns a StreamConn view of this Conn.
+func (c *Conn) Stream() *StreamConn {
+	return &StreamConn{Conn: c}
+}
+
+// ReadFrom reads data from r until EOF and writes it to the connection. If
+// the underlying net.Conn implements io.ReaderFrom the call is delegated to it
+// so that fast paths like sendfile are taken; in that case only the CopyFrom
+// hooks fire, while IdleTimeout and RejectAfterClose still apply and the
+// transfer counts as a single Write for the purpose of Stats, ErrorCounts and
+// the event log. Otherwise data is copied through Conn.Write, so Write hooks
+// fire for every chunk in addition to the CopyFrom hooks.
+func (s *StreamConn) ReadFrom(r io.Reader) (int64, error) {
+	c := s.Conn
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeCopyFromHook(); hook != nil {
+		if err := hook(c, r); err != nil {
+			return 0, beforeHookError("CopyFrom", err)
+		}
+	}
+	var n int64
+	var err error
+	rf, implements := c.Base.(io.ReaderFrom)
+	switch {
+	case !implements:
+		n, err = io.Copy(writerOnly{c}, r)
+	case c.rejectsIO():
+		err = ErrConnClosed
+	default:
+		c.beginBusy()
+		start := c.now()
+		c.syscalls.writes.Add(1)
+		n, err = rf.ReadFrom(r)
+		c.spentInBase(start)
+		c.trackWrite(int(n), err)
+		c.errorCounts.record(err)
+		c.recordEvent(Event{Kind: EventWrite, N: int(n), Err: err})
+		c.endBusy(err)
+	}
+	if hook := c.AfterCopyFromHook(); hook != nil {
+		hook(c, r, n, err)
+	}
+	return n, err
+}
+
+// WriteTo reads data from the connection until EOF and writes it to w. If the
+// underlying net.Conn implements io.WriterTo the call is delegated to it so
+// that fast paths like splice are taken; in that case only the CopyTo hooks
+// fire and the transfer counts as a single Read, as with ReadFrom. Otherwise
+// data is copied through Conn.Read, so Read hooks fire for every chunk in
+// addition to the CopyTo hooks. The latter also happens if there is data
+// buffered by Peek or ProxyProtocolV1 is set.
+func (s *StreamConn) WriteTo(w io.Writer) (int64, error) {
+	c := s.Conn
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeCopyToHook(); hook != nil {
+		if err := hook(c, w); err != nil {
+			return 0, beforeHookError("CopyTo", err)
+		}
+	}
+	var n int64
+	var err error
+	wt, implements := c.Base.(io.WriterTo)
+	switch {
+	case !implements || c.hasPeeked() || c.ProxyProtocolV1:
+		n, err = io.Copy(w, readerOnly{c})
+	case c.rejectsIO():
+		err = ErrConnClosed
+	default:
+		c.beginBusy()
+		start := c.now()
+		c.syscalls.reads.Add(1)
+		n, err = wt.WriteTo(w)
+		c.spentInBase(start)
+		c.trackRead(int(n), err)
+		c.errorCounts.record(err)
+		c.recordEvent(Event{Kind: EventRead, N: int(n), Err: err})
+		c.endBusy(err)
+	}
+	if hook := c.AfterCopyToHook(); hook != nil {
+		hook(c, w, n, err)
+	}
+	return n, err
+}
+
+// writerOnly hides all methods of an io.Writer other than Write, so that
+// io.Copy does not take any shortcuts around it.
+type writerOnly struct {
+	io.Writer
+}
+
+// readerOnly hides all methods of an io.Reader other than Read, so that
+// io.Copy does not take any shortcuts around it.
+type readerOnly struct {
+	io.Reader
+}
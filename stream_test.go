@@ -0,0 +1,279 @@
+package connxray
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+var (
+	_ io.ReaderFrom = &StreamConn{}
+	_ io.WriterTo   = &StreamConn{}
+	_ net.Conn      = &StreamConn{}
+)
+
+func TestStreamReadFromFastPath(t *testing.T) {
+	src := readerOnly{bytes.NewReader([]byte("hello"))}
+	beforeCalled, afterCalled := false, false
+	mc := &mockStreamConn{
+		readFromReaderHandler: func(r io.Reader) (int64, error) {
+			if r != src {
+				t.Errorf("Unexpected reader %v, expected %v", r, src)
+			}
+			return 5, nil
+		},
+	}
+	mc.writeHandler = func(_ []byte) (int, error) {
+		t.Error("Slow path taken")
+		return 0, nil
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeCopyFrom: func(_ *Conn, _ io.Reader) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterCopyFrom: func(_ *Conn, _ io.Reader, n int64, err error) {
+			if n != 5 || err != nil {
+				t.Errorf("Unexpected results (%d, %v)", n, err)
+			}
+			afterCalled = true
+		},
+	}
+	n, err := io.Copy(cc.Stream(), src)
+	if n != 5 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (5, nil)", n, err)
+	}
+	if !beforeCalled {
+		t.Error("Before callback not invoked")
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestStreamReadFromSlowPath(t *testing.T) {
+	var dst bytes.Buffer
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return dst.Write(b)
+		},
+	}
+	writes := 0
+	cc := &Conn{
+		Base: mc,
+		AfterWrite: func(_ *Conn, _ []byte, _ int, _ error) {
+			writes++
+		},
+	}
+	n, err := cc.Stream().ReadFrom(bytes.NewReader([]byte("hello")))
+	if n != 5 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (5, nil)", n, err)
+	}
+	if dst.String() != "hello" {
+		t.Errorf("Unexpected data %q, expected %q", dst.String(), "hello")
+	}
+	if writes == 0 {
+		t.Error("Write hooks not invoked")
+	}
+}
+
+func TestStreamWriteTo(t *testing.T) {
+	var dst bytes.Buffer
+	fast := &mockStreamConn{
+		writeToWriterHandler: func(w io.Writer) (int64, error) {
+			n, err := w.Write([]byte("fast"))
+			return int64(n), err
+		},
+	}
+	if _, err := io.Copy(&dst, (&Conn{Base: fast}).Stream()); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	src := bytes.NewReader([]byte("slow"))
+	slow := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return src.Read(b)
+		},
+	}
+	reads := 0
+	cc := &Conn{
+		Base: slow,
+		AfterRead: func(_ *Conn, _ []byte, _ int, _ error) {
+			reads++
+		},
+		AfterCopyTo: func(_ *Conn, _ io.Writer, n int64, err error) {
+			if n != 4 || err != nil {
+				t.Errorf("Unexpected results (%d, %v)", n, err)
+			}
+		},
+	}
+	if _, err := cc.Stream().WriteTo(&dst); err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if dst.String() != "fastslow" {
+		t.Errorf("Unexpected data %q, expected %q", dst.String(), "fastslow")
+	}
+	if reads == 0 {
+		t.Error("Read hooks not invoked")
+	}
+}
+
+func TestAcceptStreamConns(t *testing.T) {
+	mc := &mockConn{}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return mc, nil
+		},
+	}
+	var accepted *Conn
+	cl := &Listener{
+		Base:        ml,
+		StreamConns: true,
+		AfterAccept: func(_ *Listener, c *Conn, _ error) {
+			accepted = c
+		},
+	}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	sc, ok := nc.(*StreamConn)
+	if !ok {
+		t.Fatalf("Unexpected conn type %T, expected *StreamConn", nc)
+	}
+	if sc.Conn != accepted {
+		t.Errorf("Unexpected conn %v, expected %v", sc.Conn, accepted)
+	}
+}
+
+// benchmarkStreamReadFrom copies a file into a TCP connection through a
+// StreamConn, reporting the number of chunked writes per copy (zero when the
+// sendfile fast path is taken).
+func benchmarkStreamReadFrom(b *testing.B, hideReaderFrom bool) {
+	path := filepath.Join(b.TempDir(), "data")
+	if err := os.WriteFile(path, make([]byte, 1<<20), 0o600); err != nil {
+		b.Fatal(err)
+	}
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer l.Close()
+	go func() {
+		if sc, err := l.Accept(); err == nil {
+			io.Copy(io.Discard, sc)
+		}
+	}()
+	client, err := net.Dial("tcp", l.Addr().String())
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer client.Close()
+	base := client
+	if hideReaderFrom {
+		base = struct{ net.Conn }{client}
+	}
+	writes := 0
+	cc := &Conn{
+		Base: base,
+		AfterWrite: func(_ *Conn, _ []byte, _ int, _ error) {
+			writes++
+		},
+	}
+	sc := cc.Stream()
+	b.SetBytes(1 << 20)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, err := os.Open(path)
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := sc.ReadFrom(f); err != nil {
+			b.Fatal(err)
+		}
+		f.Close()
+	}
+	b.ReportMetric(float64(writes)/float64(b.N), "writes/op")
+}
+
+func BenchmarkStreamReadFromFastPath(b *testing.B) {
+	benchmarkStreamReadFrom(b, false)
+}
+
+func BenchmarkStreamReadFromSlowPath(b *testing.B) {
+	benchmarkStreamReadFrom(b, true)
+}
+
+func TestStreamReadFromNotIdle(t *testing.T) {
+	var closes atomic.Int32
+	mc := &mockStreamConn{
+		mockConn: *idleMockConn(&closes),
+		readFromReaderHandler: func(io.Reader) (int64, error) {
+			time.Sleep(100 * time.Millisecond)
+			return 5, nil
+		},
+	}
+	cc := &Conn{Base: mc, IdleTimeout: 20 * time.Millisecond}
+	if _, err := cc.Stream().ReadFrom(bytes.NewReader(nil)); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if closes.Load() != 0 {
+		t.Error("Conn closed as idle during a transfer")
+	}
+	time.Sleep(100 * time.Millisecond)
+	if closes.Load() != 1 {
+		t.Errorf("Unexpected number of closes %d, expected 1", closes.Load())
+	}
+}
+
+func TestStreamRejectAfterClose(t *testing.T) {
+	mc := &mockStreamConn{
+		readFromReaderHandler: func(io.Reader) (int64, error) {
+			t.Error("Base ReadFrom called after Close")
+			return 0, nil
+		},
+		writeToWriterHandler: func(io.Writer) (int64, error) {
+			t.Error("Base WriteTo called after Close")
+			return 0, nil
+		},
+	}
+	mc.closeHandler = func() error { return nil }
+	cc := &Conn{Base: mc, RejectAfterClose: true}
+	cc.Close()
+	sc := cc.Stream()
+	if _, err := sc.ReadFrom(bytes.NewReader(nil)); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+	if _, err := sc.WriteTo(io.Discard); err != ErrConnClosed {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrConnClosed)
+	}
+}
+
+func TestStreamFastPathStats(t *testing.T) {
+	mc := &mockStreamConn{
+		readFromReaderHandler: func(r io.Reader) (int64, error) {
+			return io.Copy(io.Discard, r)
+		},
+		writeToWriterHandler: func(w io.Writer) (int64, error) {
+			n, err := w.Write([]byte("bacon"))
+			return int64(n), err
+		},
+	}
+	cc := &Conn{Base: mc, TrackStats: true}
+	tc := cc.Transparent()
+	if _, err := io.Copy(tc, io.LimitReader(bytes.NewReader([]byte("chunky bacon")), 12)); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if _, err := io.Copy(io.Discard, tc); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	exp := StatsSnapshot{BytesRead: 5, BytesWritten: 12, Reads: 1, Writes: 1}
+	if got := cc.Stats(); got != exp {
+		t.Errorf("Unexpected stats %+v, expected %+v", got, exp)
+	}
+}
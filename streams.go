@@ -0,0 +1,93 @@
+package connxray
+
+import (
+	"errors"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+var (
+	// ErrStreamRateExceeded is returned by StreamOpened when opening another
+	// logical stream would exceed the connection's stream rate limit.
+	ErrStreamRateExceeded = errors.New("stream rate limit exceeded")
+)
+
+// streamCounters track logical streams reported via StreamOpened and
+// StreamClosed.
+type streamCounters struct {
+	open  atomic.Int64
+	total atomic.Uint64
+
+	// limiter enforces StreamRate. It's created on first use.
+	limiterOnce sync.Once
+	limiter     *Limiter
+}
+
+// allow takes a token from the limiter enforcing StreamRate, creating it if
+// needed, and tells whether one was available.
+func (sc *streamCounters) allow(rate float64, burst int) bool {
+	sc.limiterOnce.Do(func() {
+		sc.limiter = &Limiter{
+			rate:   rate,
+			burst:  burst,
+			tokens: float64(burst),
+			last:   time.Now(),
+		}
+	})
+	return sc.limiter.tryTake()
+}
+
+// StreamOpened reports that a new logical stream (eg. an HTTP/2 stream) was
+// opened on this connection. If StreamRate is set and the rate of new streams
+// exceeds it, the stream is not counted and ErrStreamRateExceeded is returned,
+// in which case the caller is expected to refuse the stream. The
+// AfterStreamOpened hook is invoked either way.
+func (c *Conn) StreamOpened() error {
+	var err error
+	if c.StreamRate > 0 {
+		burst := c.StreamBurst
+		if burst < 1 {
+			burst = 1
+		}
+		if !c.streams.allow(c.StreamRate, burst) {
+			err = ErrStreamRateExceeded
+		}
+	}
+	if err == nil {
+		c.streams.open.Add(1)
+		c.streams.total.Add(1)
+	}
+	if hook := c.AfterStreamOpenedHook(); hook != nil {
+		hook(c, err)
+	}
+	return err
+}
+
+// StreamClosed reports that a logical stream previously reported with
+// StreamOpened was closed, and invokes the AfterStreamClosed hook. Calls
+// outnumbering successful StreamOpened ones (eg. racing closes of the same
+// stream) don't make OpenStreams go below zero.
+func (c *Conn) StreamClosed() {
+	for {
+		open := c.streams.open.Load()
+		if open <= 0 || c.streams.open.CompareAndSwap(open, open-1) {
+			break
+		}
+	}
+	if hook := c.AfterStreamClosedHook(); hook != nil {
+		hook(c)
+	}
+}
+
+// OpenStreams returns the number of logical streams currently open on this
+// connection.
+func (c *Conn) OpenStreams() int64 {
+	return c.streams.open.Load()
+}
+
+// TotalStreams returns the number of logical streams ever opened on this
+// connection, excluding ones rejected by the rate limiter.
+func (c *Conn) TotalStreams() uint64 {
+	return c.streams.total.Load()
+}
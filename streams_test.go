@@ -0,0 +1,113 @@
+package connxray
+
+import (
+	"sync"
+	"testing"
+	"time"
+)
+
+func TestStreamCounts(t *testing.T) {
+	opened, closed := 0, 0
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterStreamOpened: func(_ *Conn, err error) {
+			if err != nil {
+				t.Errorf("Unexpected error %v", err)
+			}
+			opened++
+		},
+		AfterStreamClosed: func(_ *Conn) {
+			closed++
+		},
+	}
+	for i := 0; i < 3; i++ {
+		if err := cc.StreamOpened(); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	cc.StreamClosed()
+	if n := cc.OpenStreams(); n != 2 {
+		t.Errorf("Unexpected open streams %d, expected 2", n)
+	}
+	if n := cc.TotalStreams(); n != 3 {
+		t.Errorf("Unexpected total streams %d, expected 3", n)
+	}
+	if opened != 3 || closed != 1 {
+		t.Errorf("Unexpected hook calls: %d opened, %d closed", opened, closed)
+	}
+}
+
+func TestStreamRateLimit(t *testing.T) {
+	var lastErr error
+	cc := &Conn{
+		Base:        &mockConn{},
+		StreamRate:  20,
+		StreamBurst: 2,
+		AfterStreamOpened: func(_ *Conn, err error) {
+			lastErr = err
+		},
+	}
+	for i := 0; i < 2; i++ {
+		if err := cc.StreamOpened(); err != nil {
+			t.Fatalf("Unexpected error %v", err)
+		}
+	}
+	if err := cc.StreamOpened(); err != ErrStreamRateExceeded {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrStreamRateExceeded)
+	}
+	if lastErr != ErrStreamRateExceeded {
+		t.Errorf("Unexpected hook error %v, expected %v", lastErr, ErrStreamRateExceeded)
+	}
+	if n := cc.TotalStreams(); n != 2 {
+		t.Errorf("Unexpected total streams %d, expected 2", n)
+	}
+	time.Sleep(60 * time.Millisecond)
+	if err := cc.StreamOpened(); err != nil {
+		t.Errorf("Unexpected error %v after refill", err)
+	}
+	if n := cc.OpenStreams(); n != 3 {
+		t.Errorf("Unexpected open streams %d, expected 3", n)
+	}
+}
+
+func TestStreamClosedUnmatched(t *testing.T) {
+	closed := 0
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterStreamClosed: func(_ *Conn) {
+			closed++
+		},
+	}
+	cc.StreamOpened()
+	cc.StreamClosed()
+	cc.StreamClosed()
+	if n := cc.OpenStreams(); n != 0 {
+		t.Errorf("Unexpected open streams %d, expected 0", n)
+	}
+	if closed != 2 {
+		t.Errorf("Unexpected number of AfterStreamClosed calls %d, expected 2", closed)
+	}
+	cc.StreamOpened()
+	if n := cc.OpenStreams(); n != 1 {
+		t.Errorf("Unexpected open streams %d, expected 1", n)
+	}
+}
+
+func TestStreamClosedConcurrent(t *testing.T) {
+	cc := &Conn{Base: &mockConn{}}
+	for i := 0; i < 10; i++ {
+		cc.StreamOpened()
+	}
+	var wg sync.WaitGroup
+	for i := 0; i < 50; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			cc.StreamClosed()
+		}()
+	}
+	wg.Wait()
+	if n := cc.OpenStreams(); n != 0 {
+		t.Errorf("Unexpected open streams %d, expected 0", n)
+	}
+}
@@ -0,0 +1,100 @@
+package connxray
+
+import (
+	"errors"
+	"syscall"
+	"testing"
+)
+
+// mockRawConn is a do-nothing implementation of syscall.RawConn.
+type mockRawConn struct{}
+
+func (mockRawConn) Control(func(uintptr)) error    { return nil }
+func (mockRawConn) Read(func(uintptr) bool) error  { return nil }
+func (mockRawConn) Write(func(uintptr) bool) error { return nil }
+
+func TestSyscallConn(t *testing.T) {
+	expRaw := mockRawConn{}
+	beforeCalled, afterCalled := false, false
+	mc := &mockSyscallConn{
+		syscallConnHandler: func() (syscall.RawConn, error) {
+			if !beforeCalled {
+				t.Error("Before callback not invoked")
+			}
+			return expRaw, nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeSyscallConn: func(_ *Conn) error {
+			beforeCalled = true
+			return nil
+		},
+		AfterSyscallConn: func(_ *Conn, raw syscall.RawConn, err error) {
+			if raw != expRaw || err != nil {
+				t.Errorf("Unexpected results (%v, %v)", raw, err)
+			}
+			afterCalled = true
+		},
+	}
+	raw, err := cc.SyscallConn()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if raw != expRaw {
+		t.Errorf("Unexpected raw conn %v, expected %v", raw, expRaw)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestSyscallConnNotImplemented(t *testing.T) {
+	afterCalled := false
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterSyscallConn: func(_ *Conn, raw syscall.RawConn, err error) {
+			if raw != nil || err != ErrNotSyscallConn {
+				t.Errorf("Unexpected results (%v, %v)", raw, err)
+			}
+			afterCalled = true
+		},
+	}
+	if _, err := cc.SyscallConn(); err != ErrNotSyscallConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotSyscallConn)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestSyscallConnWithFailingBeforeCallback(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockSyscallConn{
+		syscallConnHandler: func() (syscall.RawConn, error) {
+			t.Error("Base method invoked")
+			return nil, nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		BeforeSyscallConn: func(_ *Conn) error {
+			return expErr
+		},
+	}
+	if _, err := cc.SyscallConn(); !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestSyscallConnTCP(t *testing.T) {
+	tcp, _ := tcpConnPair(t)
+	var sc syscall.Conn = &Conn{Base: tcp}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if err := raw.Control(func(fd uintptr) {}); err != nil {
+		t.Errorf("Unexpected error %v", err)
+	}
+}
@@ -0,0 +1,29 @@
+package connxray
+
+import (
+	"sync/atomic"
+)
+
+// syscallCounts counts invocations of the I/O methods of the underlying
+// net.Conn.
+type syscallCounts struct {
+	reads, writes atomic.Int64
+}
+
+// SyscallReads returns the number of times data was read from the underlying
+// net.Conn (by Read, ReadFrom, ReadMsgUDP, StreamConn.WriteTo and while
+// looking for a PROXY header). Unlike Stats.Reads it is always maintained and
+// it counts reads of the PROXY header, while Reads served from data buffered
+// by Peek or ProxyProtocolV1 count towards neither, so it can be compared with
+// the number of logical Reads to see how effective buffering is. Without
+// buffering every Read reaching the underlying net.Conn is one of these.
+func (c *Conn) SyscallReads() int64 {
+	return c.syscalls.reads.Load()
+}
+
+// SyscallWrites returns the number of times data was written to the underlying
+// net.Conn (by Write, WriteTo, WriteBuffers, WriteMsgUDP and
+// StreamConn.ReadFrom). Like SyscallReads it is always maintained.
+func (c *Conn) SyscallWrites() int64 {
+	return c.syscalls.writes.Load()
+}
@@ -0,0 +1,81 @@
+package connxray
+
+import (
+	"io"
+	"testing"
+)
+
+// chunkConn returns a mockConn which returns chunk from every Read and
+// accepts all writes.
+func chunkConn(chunk string) *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, chunk), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+}
+
+func TestSyscallCountsUnbuffered(t *testing.T) {
+	cc := &Conn{Base: chunkConn("bacon"), TrackStats: true}
+	buf := make([]byte, 8)
+	for i := 0; i < 3; i++ {
+		cc.Read(buf)
+		cc.Write([]byte("chunky"))
+	}
+	stats := cc.Stats()
+	if n := cc.SyscallReads(); n != 3 || n != stats.Reads {
+		t.Errorf("Unexpected syscall reads %d, expected 3 like Stats.Reads (%d)", n, stats.Reads)
+	}
+	if n := cc.SyscallWrites(); n != 3 || n != stats.Writes {
+		t.Errorf("Unexpected syscall writes %d, expected 3 like Stats.Writes (%d)", n, stats.Writes)
+	}
+}
+
+func TestSyscallCountsPeek(t *testing.T) {
+	cc := &Conn{Base: chunkConn("bacon")}
+	if _, err := cc.Peek(10); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if n := cc.SyscallReads(); n != 2 {
+		t.Errorf("Unexpected syscall reads %d after Peek, expected 2", n)
+	}
+	// Five logical reads, all served from the Peek buffer.
+	buf := make([]byte, 2)
+	for i := 0; i < 5; i++ {
+		if _, err := cc.Read(buf); err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+	}
+	if n := cc.SyscallReads(); n != 2 {
+		t.Errorf("Unexpected syscall reads %d after buffered reads, expected 2", n)
+	}
+	cc.Read(buf)
+	if n := cc.SyscallReads(); n != 3 {
+		t.Errorf("Unexpected syscall reads %d, expected 3", n)
+	}
+}
+
+func TestSyscallCountsProxyHeader(t *testing.T) {
+	cc := &Conn{
+		Base:            proxiedConn("PROXY TCP4 192.168.0.1 192.168.0.11 56324 443\r\nGET / HTTP/1.1"),
+		ProxyProtocolV1: true,
+	}
+	buf := make([]byte, 4)
+	for i := 0; i < 3; i++ {
+		if _, err := cc.Read(buf); err != nil {
+			t.Fatalf("Unexpected error %v, expected nil", err)
+		}
+	}
+	if n := cc.SyscallReads(); n != 1 {
+		t.Errorf("Unexpected syscall reads %d, expected 1", n)
+	}
+	if _, err := io.ReadAll(cc); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if n := cc.SyscallReads(); n != 2 {
+		t.Errorf("Unexpected syscall reads %d, expected 2", n)
+	}
+}
@@ -0,0 +1,150 @@
+package connxray
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/hex"
+	"io"
+	"sync"
+	"sync/atomic"
+)
+
+// Tap copies all data read from and written to the connection to readW and
+// writeW respectively, which is handy for protocol debugging. Either writer
+// may be nil, in which case that direction is not tapped. See TapReads and
+// TapWrites.
+func (c *Conn) Tap(readW, writeW io.Writer) {
+	if readW != nil {
+		c.TapReads(readW)
+	}
+	if writeW != nil {
+		c.TapWrites(writeW)
+	}
+}
+
+// TapReads appends an AfterRead hook copying the bytes returned by every
+// Read to w. Errors returned by w are ignored. The tap is written to
+// synchronously, so a slow w slows down every Read; use NewBufferedTap to
+// decouple the two.
+func (c *Conn) TapReads(w io.Writer) {
+	c.AppendAfterRead(func(_ *Conn, b []byte, n int, _ error) {
+		tapWrite(w, b, n)
+	})
+}
+
+// TapWrites appends an AfterWrite hook copying the bytes written by every
+// Write to w. Like with TapReads, a slow w slows down every Write.
+func (c *Conn) TapWrites(w io.Writer) {
+	c.AppendAfterWrite(func(_ *Conn, b []byte, n int, _ error) {
+		tapWrite(w, b, n)
+	})
+}
+
+// tapWrite writes the first n bytes of b to the tap w.
+func tapWrite(w io.Writer, b []byte, n int) {
+	if n = min(n, len(b)); n > 0 {
+		w.Write(b[:n])
+	}
+}
+
+// NewHexDumpTap returns a pair of writers suitable for Tap, which write a hex
+// dump (as produced by hex.Dump) of every chunk of data to w, with lines
+// prefixed with "< " for reads and "> " for writes. Dumps of concurrent reads
+// and writes are not interleaved.
+func NewHexDumpTap(w io.Writer) (reads, writes io.Writer) {
+	mu := new(sync.Mutex)
+	return &hexDumpTap{mu: mu, w: w, prefix: "< "}, &hexDumpTap{mu: mu, w: w, prefix: "> "}
+}
+
+// hexDumpTap is one direction of NewHexDumpTap.
+type hexDumpTap struct {
+	mu     *sync.Mutex
+	w      io.Writer
+	prefix string
+}
+
+func (t *hexDumpTap) Write(b []byte) (int, error) {
+	var buf bytes.Buffer
+	scanner := bufio.NewScanner(bytes.NewReader([]byte(hex.Dump(b))))
+	for scanner.Scan() {
+		buf.WriteString(t.prefix)
+		buf.Write(scanner.Bytes())
+		buf.WriteByte('\n')
+	}
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if _, err := t.w.Write(buf.Bytes()); err != nil {
+		return 0, err
+	}
+	return len(b), nil
+}
+
+// BufferedTap is an io.Writer which hands data over to another io.Writer
+// asynchronously, so that it can be used with Tap without slowing down the
+// connection. Chunks of data are copied and queued, and dropped if the queue
+// is full (see Dropped). It must be closed to release its goroutine.
+type BufferedTap struct {
+	w       io.Writer
+	queue   chan []byte
+	done    chan struct{}
+	dropped atomic.Uint64
+
+	// mu guards closed, making sure nothing is queued after Close.
+	mu     sync.RWMutex
+	closed bool
+}
+
+// NewBufferedTap returns a BufferedTap writing to w, which queues up to size
+// chunks of data.
+func NewBufferedTap(w io.Writer, size int) *BufferedTap {
+	t := &BufferedTap{
+		w:     w,
+		queue: make(chan []byte, size),
+		done:  make(chan struct{}),
+	}
+	go t.run()
+	return t
+}
+
+func (t *BufferedTap) run() {
+	defer close(t.done)
+	for b := range t.queue {
+		t.w.Write(b)
+	}
+}
+
+// Write queues a copy of b to be written to the underlying io.Writer. It
+// never blocks and always succeeds, unless the BufferedTap is closed, in
+// which case it fails with io.ErrClosedPipe.
+func (t *BufferedTap) Write(b []byte) (int, error) {
+	t.mu.RLock()
+	defer t.mu.RUnlock()
+	if t.closed {
+		return 0, io.ErrClosedPipe
+	}
+	select {
+	case t.queue <- bytes.Clone(b):
+	default:
+		t.dropped.Add(1)
+	}
+	return len(b), nil
+}
+
+// Dropped returns the number of chunks of data dropped because the queue
+// was full.
+func (t *BufferedTap) Dropped() uint64 {
+	return t.dropped.Load()
+}
+
+// Close stops accepting data and waits until everything queued has been
+// written to the underlying io.Writer. Subsequent calls do nothing.
+func (t *BufferedTap) Close() error {
+	t.mu.Lock()
+	if !t.closed {
+		t.closed = true
+		close(t.queue)
+	}
+	t.mu.Unlock()
+	<-t.done
+	return nil
+}
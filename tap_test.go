@@ -0,0 +1,114 @@
+package connxray
+
+import (
+	"bytes"
+	"io"
+	"strings"
+	"testing"
+)
+
+func TestTap(t *testing.T) {
+	reads := []string{"chunky", " bacon"}
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			if len(reads) == 0 {
+				return 0, io.EOF
+			}
+			n := copy(b, reads[0])
+			reads = reads[1:]
+			return n, nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b) - 1, nil
+		},
+	}
+	var readTap, writeTap bytes.Buffer
+	cc := &Conn{Base: mc}
+	cc.Tap(&readTap, &writeTap)
+	got, err := io.ReadAll(cc)
+	if err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if readTap.String() != string(got) {
+		t.Errorf("Unexpected tapped reads %q, expected %q", readTap.String(), got)
+	}
+	cc.Write([]byte("bacon"))
+	if writeTap.String() != "baco" {
+		t.Errorf("Unexpected tapped writes %q, expected %q", writeTap.String(), "baco")
+	}
+}
+
+func TestTapKeepsHooks(t *testing.T) {
+	called := false
+	cc := &Conn{
+		Base: &mockConn{writeHandler: func(b []byte) (int, error) { return len(b), nil }},
+		AfterWrite: func(*Conn, []byte, int, error) {
+			called = true
+		},
+	}
+	cc.Tap(nil, io.Discard)
+	cc.Write([]byte("bacon"))
+	if !called {
+		t.Error("AfterWrite hook not invoked after Tap")
+	}
+}
+
+func TestHexDumpTap(t *testing.T) {
+	var out bytes.Buffer
+	reads, writes := NewHexDumpTap(&out)
+	reads.Write([]byte("chunky"))
+	writes.Write([]byte("bacon"))
+	lines := strings.Split(strings.TrimSpace(out.String()), "\n")
+	if len(lines) != 2 {
+		t.Fatalf("Unexpected dump %q, expected 2 lines", out.String())
+	}
+	if !strings.HasPrefix(lines[0], "< 00000000  63 68 75 6e 6b 79") || !strings.HasSuffix(lines[0], "|chunky|") {
+		t.Errorf("Unexpected line %q, expected a dump of reads", lines[0])
+	}
+	if !strings.HasPrefix(lines[1], "> 00000000  62 61 63 6f 6e") || !strings.HasSuffix(lines[1], "|bacon|") {
+		t.Errorf("Unexpected line %q, expected a dump of writes", lines[1])
+	}
+}
+
+func TestBufferedTap(t *testing.T) {
+	var out bytes.Buffer
+	tap := NewBufferedTap(&out, 16)
+	b := []byte("chunky")
+	tap.Write(b)
+	copy(b, "bacon!")
+	tap.Write(b)
+	tap.Close()
+	if out.String() != "chunkybacon!" {
+		t.Errorf("Unexpected output %q, expected %q", out.String(), "chunkybacon!")
+	}
+	if _, err := tap.Write(b); err != io.ErrClosedPipe {
+		t.Errorf("Unexpected error %v, expected %v", err, io.ErrClosedPipe)
+	}
+	tap.Close()
+}
+
+// blockingWriter blocks every Write until unblock is closed.
+type blockingWriter struct {
+	unblock chan struct{}
+}
+
+func (w *blockingWriter) Write(b []byte) (int, error) {
+	<-w.unblock
+	return len(b), nil
+}
+
+func TestBufferedTapDrops(t *testing.T) {
+	w := &blockingWriter{unblock: make(chan struct{})}
+	tap := NewBufferedTap(w, 1)
+	for i := 0; i < 5; i++ {
+		if _, err := tap.Write([]byte("bacon")); err != nil {
+			t.Errorf("Unexpected error %v, expected nil", err)
+		}
+	}
+	close(w.unblock)
+	tap.Close()
+	// The writer holds at most one chunk and the queue another one.
+	if dropped := tap.Dropped(); dropped < 3 {
+		t.Errorf("Unexpected number of dropped chunks %d, expected at least 3", dropped)
+	}
+}
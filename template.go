@@ -0,0 +1,77 @@
+package connxray
+
+// copyHooks copies all hook function fields, as well as hook chains, from the
+// template t onto c. Other fields, notably Base, are left alone.
+func (c *Conn) copyHooks(t *Conn) {
+	t.hooksMu.RLock()
+	defer t.hooksMu.RUnlock()
+	c.hooksMu.Lock()
+	defer c.hooksMu.Unlock()
+	c.BeforeRead = t.BeforeRead
+	c.AfterRead = t.AfterRead
+	c.BeforeReadCtx = t.BeforeReadCtx
+	c.AfterReadCtx = t.AfterReadCtx
+	c.AfterReadTimed = t.AfterReadTimed
+	c.TransformRead = t.TransformRead
+	c.ReadInterceptor = t.ReadInterceptor
+	c.AfterEOF = t.AfterEOF
+	c.AfterReadTimeout = t.AfterReadTimeout
+	c.AfterFirstRead = t.AfterFirstRead
+	c.AfterReadFull = t.AfterReadFull
+	c.BeforeReadFrom = t.BeforeReadFrom
+	c.AfterReadFrom = t.AfterReadFrom
+	c.BeforeReadMsgUDP = t.BeforeReadMsgUDP
+	c.AfterReadMsgUDP = t.AfterReadMsgUDP
+	c.BeforeWrite = t.BeforeWrite
+	c.AfterWrite = t.AfterWrite
+	c.BeforeWriteCtx = t.BeforeWriteCtx
+	c.AfterWriteCtx = t.AfterWriteCtx
+	c.AfterWriteTimed = t.AfterWriteTimed
+	c.TransformWrite = t.TransformWrite
+	c.WriteInterceptor = t.WriteInterceptor
+	c.AfterWriteTimeout = t.AfterWriteTimeout
+	c.AfterFirstWrite = t.AfterFirstWrite
+	c.AfterWriteAll = t.AfterWriteAll
+	c.BeforeWriteBuffers = t.BeforeWriteBuffers
+	c.AfterWriteBuffers = t.AfterWriteBuffers
+	c.BeforeWriteTo = t.BeforeWriteTo
+	c.AfterWriteTo = t.AfterWriteTo
+	c.BeforeWriteMsgUDP = t.BeforeWriteMsgUDP
+	c.AfterWriteMsgUDP = t.AfterWriteMsgUDP
+	c.BeforeClose = t.BeforeClose
+	c.AfterClose = t.AfterClose
+	c.BeforeCloseWrite = t.BeforeCloseWrite
+	c.AfterCloseWrite = t.AfterCloseWrite
+	c.BeforeCloseRead = t.BeforeCloseRead
+	c.AfterCloseRead = t.AfterCloseRead
+	c.BeforeCloseCtx = t.BeforeCloseCtx
+	c.AfterCloseCtx = t.AfterCloseCtx
+	c.AfterLocalAddr = t.AfterLocalAddr
+	c.AfterRemoteAddr = t.AfterRemoteAddr
+	c.BeforeSetDeadline = t.BeforeSetDeadline
+	c.AfterSetDeadline = t.AfterSetDeadline
+	c.BeforeSetReadDeadline = t.BeforeSetReadDeadline
+	c.AfterSetReadDeadline = t.AfterSetReadDeadline
+	c.BeforeSetWriteDeadline = t.BeforeSetWriteDeadline
+	c.AfterSetWriteDeadline = t.AfterSetWriteDeadline
+	c.AfterSetSocketOption = t.AfterSetSocketOption
+	c.BeforeCopyFrom = t.BeforeCopyFrom
+	c.AfterCopyFrom = t.AfterCopyFrom
+	c.BeforeCopyTo = t.BeforeCopyTo
+	c.AfterCopyTo = t.AfterCopyTo
+	c.BeforeSyscallConn = t.BeforeSyscallConn
+	c.AfterSyscallConn = t.AfterSyscallConn
+	c.BeforeHandshake = t.BeforeHandshake
+	c.AfterHandshake = t.AfterHandshake
+	c.AfterIdleTimeout = t.AfterIdleTimeout
+	c.AfterStreamOpened = t.AfterStreamOpened
+	c.AfterStreamClosed = t.AfterStreamClosed
+	c.Observer = t.Observer
+	c.OnHookPanic = t.OnHookPanic
+	c.OnCloseVetoed = t.OnCloseVetoed
+	c.OnKeyRotation = t.OnKeyRotation
+	c.MeasureHookLatency = t.MeasureHookLatency
+	c.SlowHookThreshold = t.SlowHookThreshold
+	c.OnSlowHook = t.OnSlowHook
+	c.chains = t.chains
+}
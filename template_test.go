@@ -0,0 +1,110 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"testing"
+)
+
+func TestAcceptAppliesConnTemplate(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return mc, nil
+		},
+	}
+	calls := []string{}
+	template := &Conn{
+		Base: &mockConn{},
+		BeforeRead: func(_ *Conn, _ []byte) error {
+			calls = append(calls, "template BeforeRead")
+			return nil
+		},
+		AfterRead: func(_ *Conn, _ []byte, _ int, _ error) {
+			calls = append(calls, "template AfterRead")
+		},
+		AfterWrite: func(_ *Conn, _ []byte, _ int, _ error) {
+			calls = append(calls, "template AfterWrite")
+		},
+	}
+	cl := &Listener{
+		Base:         ml,
+		ConnTemplate: template,
+		AfterAccept: func(_ *Listener, c *Conn, _ error) {
+			if c.AfterRead == nil {
+				t.Error("Template not applied before AfterAccept")
+			}
+			c.AfterWrite = func(_ *Conn, _ []byte, _ int, _ error) {
+				calls = append(calls, "overridden AfterWrite")
+			}
+		},
+	}
+	nc, err := cl.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	if base := nc.(*Conn).Base; base != mc {
+		t.Errorf("Unexpected base %v, expected %v", base, mc)
+	}
+	nc.Read(make([]byte, 1))
+	nc.Write(make([]byte, 1))
+	exp := []string{
+		"template BeforeRead",
+		"template AfterRead",
+		"overridden AfterWrite",
+	}
+	if len(calls) != len(exp) {
+		t.Fatalf("Unexpected calls %v, expected %v", calls, exp)
+	}
+	for i := range exp {
+		if calls[i] != exp[i] {
+			t.Errorf("Unexpected call %q, expected %q", calls[i], exp[i])
+		}
+	}
+}
+
+func TestConnTemplateWithFailingBeforeAccept(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	baseCalled, templateUsed := false, false
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			baseCalled = true
+			return &mockConn{}, nil
+		},
+	}
+	cl := &Listener{
+		Base: ml,
+		BeforeAccept: func(_ *Listener) error {
+			return expErr
+		},
+		ConnTemplate: &Conn{
+			BeforeRead: func(_ *Conn, _ []byte) error {
+				templateUsed = true
+				return nil
+			},
+		},
+		AfterAccept: func(_ *Listener, _ *Conn, _ error) {
+			t.Error("After callback invoked")
+		},
+	}
+	nc, err := cl.Accept()
+	if !errors.Is(err, expErr) {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if nc != nil {
+		t.Errorf("Unexpected conn %v, expected nil", nc)
+	}
+	if baseCalled {
+		t.Error("Base method invoked")
+	}
+	if templateUsed {
+		t.Error("Template hook invoked")
+	}
+}
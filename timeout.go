@@ -0,0 +1,38 @@
+package connxray
+
+import (
+	"time"
+)
+
+// ReadWithTimeout reads from the connection like Read, but fails with a
+// timeout error (a net.Error whose Timeout method returns true) if the read
+// does not complete within d. The read deadline in effect before the call
+// (see Deadlines) is restored afterwards. If the deadline can't be set the
+// error is returned without attempting to read.
+func (c *Conn) ReadWithTimeout(b []byte, d time.Duration) (int, error) {
+	prev, _ := c.Deadlines()
+	if err := c.SetReadDeadline(time.Now().Add(d)); err != nil {
+		return 0, err
+	}
+	n, err := c.Read(b)
+	if rerr := c.SetReadDeadline(prev); err == nil {
+		err = rerr
+	}
+	return n, err
+}
+
+// WriteWithTimeout writes to the connection like Write, but fails with a
+// timeout error if the write does not complete within d. The write deadline
+// in effect before the call is restored afterwards. If the deadline can't be
+// set the error is returned without attempting to write.
+func (c *Conn) WriteWithTimeout(b []byte, d time.Duration) (int, error) {
+	_, prev := c.Deadlines()
+	if err := c.SetWriteDeadline(time.Now().Add(d)); err != nil {
+		return 0, err
+	}
+	n, err := c.Write(b)
+	if rerr := c.SetWriteDeadline(prev); err == nil {
+		err = rerr
+	}
+	return n, err
+}
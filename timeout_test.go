@@ -0,0 +1,152 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+	"os"
+	"testing"
+	"time"
+)
+
+func TestReadWithTimeout(t *testing.T) {
+	deadlines := []time.Time{}
+	mc := &mockConn{
+		setReadDeadlineHandler: func(d time.Time) error {
+			deadlines = append(deadlines, d)
+			return nil
+		},
+		readHandler: func(b []byte) (int, error) {
+			return 0, os.ErrDeadlineExceeded
+		},
+	}
+	readHooked := false
+	cc := &Conn{
+		Base: mc,
+		AfterRead: func(*Conn, []byte, int, error) {
+			readHooked = true
+		},
+	}
+	prev := time.Now().Add(time.Hour)
+	cc.SetReadDeadline(prev)
+	_, err := cc.ReadWithTimeout(make([]byte, 1), time.Second)
+	var ne net.Error
+	if !errors.As(err, &ne) || !ne.Timeout() {
+		t.Errorf("Unexpected error %v, expected a timeout", err)
+	}
+	if !readHooked {
+		t.Error("Read hook not invoked")
+	}
+	if len(deadlines) != 3 || !deadlines[2].Equal(prev) {
+		t.Errorf("Unexpected deadlines %v, expected the last one to be %v", deadlines, prev)
+	}
+	if read, _ := cc.Deadlines(); !read.Equal(prev) {
+		t.Errorf("Unexpected read deadline %v, expected %v", read, prev)
+	}
+}
+
+func TestWriteWithTimeoutFailingDeadline(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		setWriteDeadlineHandler: func(time.Time) error {
+			return expErr
+		},
+		writeHandler: func(b []byte) (int, error) {
+			t.Error("Base method invoked")
+			return 0, nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	if _, err := cc.WriteWithTimeout([]byte("foo"), time.Second); err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestWriteWithTimeoutClearsDeadline(t *testing.T) {
+	var last time.Time
+	mc := &mockConn{
+		setWriteDeadlineHandler: func(d time.Time) error {
+			last = d
+			return nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	if n, err := cc.WriteWithTimeout([]byte("foo"), time.Second); n != 3 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (3, nil)", n, err)
+	}
+	if !last.IsZero() {
+		t.Errorf("Unexpected write deadline %v left behind, expected none", last)
+	}
+}
+
+func TestAfterTimeoutHooks(t *testing.T) {
+	testCases := []struct {
+		desc       string
+		err        error
+		expTimeout bool
+	}{
+		{"timeout", os.ErrDeadlineExceeded, true},
+		{"generic error", errors.New("chunky bacon"), false},
+		{"no error", nil, false},
+	}
+	for _, tc := range testCases {
+		mc := &mockConn{
+			readHandler: func(b []byte) (int, error) {
+				return 0, tc.err
+			},
+			writeHandler: func(b []byte) (int, error) {
+				return 0, tc.err
+			},
+		}
+		var readTimeouts, writeTimeouts, afterReads int
+		cc := &Conn{
+			Base:       mc,
+			TrackStats: true,
+			AfterRead: func(*Conn, []byte, int, error) {
+				afterReads++
+			},
+			AfterReadTimeout: func(*Conn) {
+				readTimeouts++
+			},
+			AfterWriteTimeout: func(*Conn) {
+				writeTimeouts++
+			},
+		}
+		cc.Read(nil)
+		cc.Write(nil)
+		exp := 0
+		if tc.expTimeout {
+			exp = 1
+		}
+		if readTimeouts != exp || writeTimeouts != exp {
+			t.Errorf("%s: Unexpected timeout hook calls (%d, %d), expected (%d, %d)", tc.desc, readTimeouts, writeTimeouts, exp, exp)
+		}
+		if afterReads != 1 {
+			t.Errorf("%s: Unexpected AfterRead calls %d, expected 1", tc.desc, afterReads)
+		}
+		if stats := cc.Stats(); stats.ReadTimeouts != int64(exp) || stats.WriteTimeouts != int64(exp) {
+			t.Errorf("%s: Unexpected timeout counts (%d, %d), expected (%d, %d)", tc.desc, stats.ReadTimeouts, stats.WriteTimeouts, exp, exp)
+		}
+	}
+}
+
+func TestAfterReadTimeoutWithRealDeadline(t *testing.T) {
+	client, server := net.Pipe()
+	defer server.Close()
+	fired := false
+	cc := &Conn{
+		Base: client,
+		AfterReadTimeout: func(*Conn) {
+			fired = true
+		},
+	}
+	defer cc.Close()
+	if _, err := cc.ReadWithTimeout(make([]byte, 1), time.Millisecond); err == nil {
+		t.Fatal("Unexpected nil error, expected a timeout")
+	}
+	if !fired {
+		t.Error("AfterReadTimeout not invoked")
+	}
+}
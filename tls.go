@@ -0,0 +1,83 @@
+package connxray
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+)
+
+var (
+	// ErrNotTLSConn signifies that the underlying net.Conn does not support
+	// a TLS handshake (ie. it is not a *tls.Conn or similar).
+	ErrNotTLSConn = errors.New("this net.Conn is not a TLS connection")
+)
+
+// ConnectionState returns the TLS connection state of the underlying net.Conn
+// if it has one (eg. *tls.Conn), looking through nested Conns. The second
+// return value is false if the underlying net.Conn is not a TLS connection.
+func (c *Conn) ConnectionState() (tls.ConnectionState, bool) {
+	switch base := c.Base.(type) {
+	case *Conn:
+		return base.ConnectionState()
+	case interface{ ConnectionState() tls.ConnectionState }:
+		return base.ConnectionState(), true
+	}
+	return tls.ConnectionState{}, false
+}
+
+// Handshake runs the TLS handshake on the underlying net.Conn (see
+// tls.Conn.HandshakeContext) and invokes relevant hooks ('before' and 'after')
+// that were set up. If the underlying net.Conn is not a TLS connection
+// ErrNotTLSConn is returned (and passed to the 'after' hook).
+func (c *Conn) Handshake(ctx context.Context) error {
+	defer c.spentInMethod(c.now())
+	release, err := c.handshakeLimit.acquire(ctx, c)
+	if err != nil {
+		return err
+	}
+	defer release()
+	if hook := c.BeforeHandshakeHook(); hook != nil {
+		if err := hook(c); err != nil {
+			return beforeHookError("Handshake", err)
+		}
+	}
+	err = ErrNotTLSConn
+	switch base := c.Base.(type) {
+	case *Conn:
+		start := c.now()
+		err = base.Handshake(ctx)
+		c.spentInBase(start)
+	case interface{ HandshakeContext(context.Context) error }:
+		start := c.now()
+		err = base.HandshakeContext(ctx)
+		c.spentInBase(start)
+	}
+	state, _ := c.ConnectionState()
+	if err == nil {
+		c.handshakeReported.Store(true)
+	}
+	if hook := c.AfterHandshakeHook(); hook != nil {
+		hook(c, state, err)
+	}
+	return err
+}
+
+// reportHandshake invokes the AfterHandshake hook once a handshake triggered
+// implicitly by Read or Write (which is how TLS connections normally perform
+// it) has completed, unless it was already reported.
+func (c *Conn) reportHandshake() {
+	if c.handshakeReported.Load() {
+		return
+	}
+	hook := c.AfterHandshakeHook()
+	if hook == nil {
+		return
+	}
+	state, isTLS := c.ConnectionState()
+	if !isTLS || !state.HandshakeComplete {
+		return
+	}
+	if c.handshakeReported.CompareAndSwap(false, true) {
+		hook(c, state, nil)
+	}
+}
@@ -0,0 +1,129 @@
+package connxray
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+)
+
+// tlsPair returns both ends of a TLS connection over net.Pipe, with the
+// handshake not yet performed. The client uses "example.com" as SNI.
+func tlsPair(t *testing.T) (client, server *tls.Conn) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		DNSNames:     []string{"example.com"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	der, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		t.Fatalf("Unexpected error %v", err)
+	}
+	cert := tls.Certificate{Certificate: [][]byte{der}, PrivateKey: key}
+	cp, sp := net.Pipe()
+	t.Cleanup(func() {
+		cp.Close()
+		sp.Close()
+	})
+	server = tls.Server(sp, &tls.Config{Certificates: []tls.Certificate{cert}})
+	client = tls.Client(cp, &tls.Config{
+		ServerName:         "example.com",
+		InsecureSkipVerify: true,
+	})
+	return client, server
+}
+
+func TestConnectionStateNotTLS(t *testing.T) {
+	cc := &Conn{Base: &mockConn{}}
+	if _, ok := cc.ConnectionState(); ok {
+		t.Error("Unexpected TLS connection state for a plain conn")
+	}
+}
+
+func TestHandshake(t *testing.T) {
+	client, server := tlsPair(t)
+	go server.Handshake()
+	var gotState tls.ConnectionState
+	afterCalls := 0
+	cc := &Conn{
+		Base: &Conn{Base: client},
+		AfterHandshake: func(_ *Conn, state tls.ConnectionState, err error) {
+			afterCalls++
+			gotState = state
+			if err != nil {
+				t.Errorf("Unexpected error %v, expected nil", err)
+			}
+		},
+	}
+	if err := cc.Handshake(context.Background()); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if !gotState.HandshakeComplete || gotState.ServerName != "example.com" {
+		t.Errorf("Unexpected state %+v", gotState)
+	}
+	state, ok := cc.ConnectionState()
+	if !ok || state.Version != gotState.Version || state.CipherSuite == 0 {
+		t.Errorf("Unexpected connection state (%+v, %v)", state, ok)
+	}
+	go server.Write([]byte("x"))
+	cc.Read(make([]byte, 1))
+	if afterCalls != 1 {
+		t.Errorf("Unexpected number of after callback calls %d, expected 1", afterCalls)
+	}
+}
+
+func TestHandshakeNotTLS(t *testing.T) {
+	var gotErr error
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterHandshake: func(_ *Conn, _ tls.ConnectionState, err error) {
+			gotErr = err
+		},
+	}
+	if err := cc.Handshake(context.Background()); err != ErrNotTLSConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotTLSConn)
+	}
+	if gotErr != ErrNotTLSConn {
+		t.Errorf("Unexpected error %v, expected %v", gotErr, ErrNotTLSConn)
+	}
+}
+
+func TestHandshakeReportedAfterImplicitHandshake(t *testing.T) {
+	client, server := tlsPair(t)
+	go func() {
+		buf := make([]byte, 5)
+		server.Read(buf)
+		server.Write(buf)
+	}()
+	afterCalls := 0
+	cc := &Conn{
+		Base: client,
+		AfterHandshake: func(_ *Conn, state tls.ConnectionState, err error) {
+			afterCalls++
+			if !state.HandshakeComplete || err != nil {
+				t.Errorf("Unexpected results (%+v, %v)", state, err)
+			}
+		},
+	}
+	if _, err := cc.Write([]byte("hello")); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if _, err := cc.Read(make([]byte, 5)); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if afterCalls != 1 {
+		t.Errorf("Unexpected number of after callback calls %d, expected 1", afterCalls)
+	}
+}
@@ -0,0 +1,78 @@
+package connxray
+
+import (
+	"errors"
+	"testing"
+)
+
+func TestTransformRead(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "hello"), nil
+		},
+	}
+	afterCalled := false
+	cc := &Conn{
+		Base: mc,
+		TransformRead: func(_ *Conn, b []byte, n int, err error) (int, error) {
+			if afterCalled {
+				t.Error("After callback invoked before transform")
+			}
+			if n != 5 || err != nil {
+				t.Errorf("Unexpected base results (%d, %v)", n, err)
+			}
+			return 2, expErr
+		},
+		AfterRead: func(_ *Conn, _ []byte, n int, err error) {
+			if n != 2 || err != expErr {
+				t.Errorf("Unexpected transformed results (%d, %v)", n, err)
+			}
+			afterCalled = true
+		},
+	}
+	n, err := cc.Read(make([]byte, 8))
+	if n != 2 {
+		t.Errorf("Unexpected n %d, expected 2", n)
+	}
+	if err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+	if !afterCalled {
+		t.Error("After callback not invoked")
+	}
+}
+
+func TestTransformWrite(t *testing.T) {
+	expErr := errors.New("chunky bacon")
+	mc := &mockConn{
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+	cc := &Conn{
+		Base: mc,
+		TransformWrite: func(_ *Conn, _ []byte, n int, _ error) (int, error) {
+			return n - 1, expErr
+		},
+	}
+	n, err := cc.Write([]byte("hello"))
+	if n != 4 {
+		t.Errorf("Unexpected n %d, expected 4", n)
+	}
+	if err != expErr {
+		t.Errorf("Unexpected error %v, expected %v", err, expErr)
+	}
+}
+
+func TestWithoutTransform(t *testing.T) {
+	mc := &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return copy(b, "hello"), nil
+		},
+	}
+	cc := &Conn{Base: mc}
+	if n, err := cc.Read(make([]byte, 8)); n != 5 || err != nil {
+		t.Errorf("Unexpected results (%d, %v), expected (5, nil)", n, err)
+	}
+}
@@ -0,0 +1,94 @@
+package connxray
+
+import (
+	"io"
+	"net"
+	"syscall"
+)
+
+// closeWriter is implemented by connections supporting half-close, like
+// *net.TCPConn and *net.UnixConn.
+type closeWriter interface {
+	CloseWrite() error
+}
+
+// rawConner is syscall.Conn under a name which can be embedded alongside
+// net.Conn.
+type rawConner interface {
+	SyscallConn() (syscall.RawConn, error)
+}
+
+// Transparent returns a net.Conn view of this Conn whose method set mirrors
+// the optional interfaces implemented by the underlying net.Conn, for code
+// which probes connections with type assertions and caches the outcome (eg.
+// gRPC or net/http). Conn itself can't do that, since Go method sets are
+// static: it implements net.PacketConn rather than io.ReaderFrom and
+// io.WriterTo, and has CloseWrite and SyscallConn methods whether or not the
+// underlying net.Conn supports them.
+//
+// The returned value implements io.ReaderFrom and io.WriterTo (as StreamConn
+// does) if the underlying net.Conn implements either of them, and
+// CloseWrite and syscall.Conn only if the underlying net.Conn does. All calls
+// go through this Conn, so hooks fire as usual.
+//
+// Only these four interfaces are forwarded: any other method of the
+// underlying net.Conn, as well as the methods of Conn which are not part of
+// net.Conn (eg. Stats), are hidden. The interfaces are probed once, so the
+// view must be created again if Base changes.
+func (c *Conn) Transparent() net.Conn {
+	_, readerFrom := c.Base.(io.ReaderFrom)
+	_, writerTo := c.Base.(io.WriterTo)
+	_, halfClose := c.Base.(closeWriter)
+	_, syscallConn := c.Base.(syscall.Conn)
+	stream := c.Stream()
+	switch streams := readerFrom || writerTo; {
+	case streams && halfClose && syscallConn:
+		return struct {
+			net.Conn
+			io.ReaderFrom
+			io.WriterTo
+			closeWriter
+			rawConner
+		}{c, stream, stream, c, c}
+	case streams && halfClose:
+		return struct {
+			net.Conn
+			io.ReaderFrom
+			io.WriterTo
+			closeWriter
+		}{c, stream, stream, c}
+	case streams && syscallConn:
+		return struct {
+			net.Conn
+			io.ReaderFrom
+			io.WriterTo
+			rawConner
+		}{c, stream, stream, c}
+	case streams:
+		return struct {
+			net.Conn
+			io.ReaderFrom
+			io.WriterTo
+		}{c, stream, stream}
+	case halfClose && syscallConn:
+		return struct {
+			net.Conn
+			closeWriter
+			rawConner
+		}{c, c, c}
+	case halfClose:
+		return struct {
+			net.Conn
+			closeWriter
+		}{c, c}
+	case syscallConn:
+		return struct {
+			net.Conn
+			rawConner
+		}{c, c}
+	default:
+		return struct {
+			net.Conn
+		}{c}
+	}
+}
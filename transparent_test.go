@@ -0,0 +1,113 @@
+package connxray
+
+import (
+	"bytes"
+	"io"
+	"net"
+	"strings"
+	"syscall"
+	"testing"
+)
+
+func TestTransparentTCP(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	defer ln.Close()
+	go func() {
+		conn, err := ln.Accept()
+		if err == nil {
+			io.Copy(io.Discard, conn)
+			conn.Close()
+		}
+	}()
+	base, err := net.Dial("tcp", ln.Addr().String())
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	copyFrom := false
+	cc := &Conn{
+		Base: base,
+		AfterCopyFrom: func(*Conn, io.Reader, int64, error) {
+			copyFrom = true
+		},
+	}
+	defer cc.Close()
+	conn := cc.Transparent()
+	if _, ok := conn.(io.ReaderFrom); !ok {
+		t.Error("Transparent conn does not implement io.ReaderFrom")
+	}
+	if _, ok := conn.(io.WriterTo); !ok {
+		t.Error("Transparent conn does not implement io.WriterTo")
+	}
+	if _, ok := conn.(syscall.Conn); !ok {
+		t.Error("Transparent conn does not implement syscall.Conn")
+	}
+	cw, ok := conn.(interface{ CloseWrite() error })
+	if !ok {
+		t.Fatal("Transparent conn does not implement CloseWrite")
+	}
+	if _, err := io.Copy(conn, io.LimitReader(strings.NewReader("chunky bacon"), 64)); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+	if !copyFrom {
+		t.Error("AfterCopyFrom not invoked")
+	}
+	if err := cw.CloseWrite(); err != nil {
+		t.Errorf("Unexpected error %v, expected nil", err)
+	}
+}
+
+func TestTransparentPlainConn(t *testing.T) {
+	conn := (&Conn{Base: &mockConn{}}).Transparent()
+	if _, ok := conn.(io.ReaderFrom); ok {
+		t.Error("Transparent conn unexpectedly implements io.ReaderFrom")
+	}
+	if _, ok := conn.(syscall.Conn); ok {
+		t.Error("Transparent conn unexpectedly implements syscall.Conn")
+	}
+	if _, ok := conn.(interface{ CloseWrite() error }); ok {
+		t.Error("Transparent conn unexpectedly implements CloseWrite")
+	}
+}
+
+func TestTransparentPartial(t *testing.T) {
+	base := &mockHalfCloseConn{closeWriteHandler: func() error { return nil }}
+	conn := (&Conn{Base: base}).Transparent()
+	if _, ok := conn.(interface{ CloseWrite() error }); !ok {
+		t.Error("Transparent conn does not implement CloseWrite")
+	}
+	if _, ok := conn.(io.WriterTo); ok {
+		t.Error("Transparent conn unexpectedly implements io.WriterTo")
+	}
+}
+
+func TestTransparentConnsListener(t *testing.T) {
+	var written bytes.Buffer
+	base := &mockStreamConn{
+		mockConn: mockConn{
+			writeHandler: written.Write,
+		},
+		writeToWriterHandler: func(io.Writer) (int64, error) {
+			return 0, nil
+		},
+	}
+	ml := &mockListener{
+		acceptHandler: func() (net.Conn, error) {
+			return base, nil
+		},
+	}
+	ll := NewListener(ml, WithTransparentConns())
+	conn, err := ll.Accept()
+	if err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	if _, ok := conn.(io.WriterTo); !ok {
+		t.Error("Accepted conn does not implement io.WriterTo")
+	}
+	conn.Write([]byte("bacon"))
+	if written.String() != "bacon" {
+		t.Errorf("Unexpected data %q, expected \"bacon\"", written.String())
+	}
+}
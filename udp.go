@@ -0,0 +1,85 @@
+package connxray
+
+import (
+	"errors"
+	"net"
+)
+
+var (
+	// ErrNotUDPConn signifies that the underlying net.Conn does not support
+	// reading and writing messages with out-of-band data (ie. it is not a
+	// *net.UDPConn or similar).
+	ErrNotUDPConn = errors.New("this net.Conn is not a UDP connection")
+)
+
+// ReadMsgUDP reads a message and its out-of-band data from the underlying
+// net.Conn if it supports it (see net.UDPConn.ReadMsgUDP) and invokes relevant
+// hooks ('before' and 'after') that were set up. Otherwise ErrNotUDPConn is
+// returned (and passed to the 'after' hook).
+func (c *Conn) ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error) {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeReadMsgUDPHook(); hook != nil {
+		if err = beforeHookError("ReadMsgUDP", hook(c, b, oob)); err != nil {
+			return
+		}
+	}
+	type msgReader interface {
+		ReadMsgUDP(b, oob []byte) (n, oobn, flags int, addr *net.UDPAddr, err error)
+	}
+	uconn, implements := c.Base.(msgReader)
+	switch {
+	case !implements:
+		err = ErrNotUDPConn
+	case c.rejectsIO():
+		err = ErrConnClosed
+	default:
+		start := c.now()
+		c.syscalls.reads.Add(1)
+		n, oobn, flags, addr, err = uconn.ReadMsgUDP(b, oob)
+		c.spentInBase(start)
+		c.trackRead(n, err)
+		c.errorCounts.record(err)
+		c.recordEvent(Event{Kind: EventReadFrom, N: n, Addr: addr, Err: err})
+		c.touchIdle(err)
+	}
+	if hook := c.AfterReadMsgUDPHook(); hook != nil {
+		hook(c, b, oob, n, oobn, flags, addr, err)
+	}
+	return n, oobn, flags, addr, err
+}
+
+// WriteMsgUDP writes a message and its out-of-band data to the underlying
+// net.Conn if it supports it (see net.UDPConn.WriteMsgUDP) and invokes relevant
+// hooks ('before' and 'after') that were set up. Otherwise ErrNotUDPConn is
+// returned (and passed to the 'after' hook).
+func (c *Conn) WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error) {
+	defer c.spentInMethod(c.now())
+	if hook := c.BeforeWriteMsgUDPHook(); hook != nil {
+		if err = beforeHookError("WriteMsgUDP", hook(c, b, oob, addr)); err != nil {
+			return
+		}
+	}
+	type msgWriter interface {
+		WriteMsgUDP(b, oob []byte, addr *net.UDPAddr) (n, oobn int, err error)
+	}
+	uconn, implements := c.Base.(msgWriter)
+	switch {
+	case !implements:
+		err = ErrNotUDPConn
+	case c.rejectsIO():
+		err = ErrConnClosed
+	default:
+		start := c.now()
+		c.syscalls.writes.Add(1)
+		n, oobn, err = uconn.WriteMsgUDP(b, oob, addr)
+		c.spentInBase(start)
+		c.trackWrite(n, err)
+		c.errorCounts.record(err)
+		c.recordEvent(Event{Kind: EventWriteTo, N: n, Addr: addr, Err: err})
+		c.touchIdle(err)
+	}
+	if hook := c.AfterWriteMsgUDPHook(); hook != nil {
+		hook(c, b, oob, addr, n, oobn, err)
+	}
+	return n, oobn, err
+}
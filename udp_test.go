@@ -0,0 +1,69 @@
+package connxray
+
+import (
+	"net"
+	"testing"
+)
+
+func TestMsgUDP(t *testing.T) {
+	server, err := net.ListenUDP("udp", &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)})
+	if err != nil {
+		t.Fatalf("Error creating a UDP socket: %v", err)
+	}
+	defer server.Close()
+	client, err := net.DialUDP("udp", nil, server.LocalAddr().(*net.UDPAddr))
+	if err != nil {
+		t.Fatalf("Error dialing a UDP socket: %v", err)
+	}
+	defer client.Close()
+	var writeN, readN, readFlags int
+	var readAddr *net.UDPAddr
+	cc := &Conn{
+		Base: client,
+		AfterWriteMsgUDP: func(_ *Conn, _, _ []byte, _ *net.UDPAddr, n, _ int, _ error) {
+			writeN = n
+		},
+	}
+	sc := &Conn{
+		Base: &Conn{Base: server},
+		AfterReadMsgUDP: func(_ *Conn, _, _ []byte, n, _, flags int, addr *net.UDPAddr, _ error) {
+			readN, readFlags, readAddr = n, flags, addr
+		},
+	}
+	if _, _, err := cc.WriteMsgUDP([]byte("hello"), nil, nil); err != nil {
+		t.Fatalf("Unexpected error %v, expected nil", err)
+	}
+	buf := make([]byte, 16)
+	n, _, _, addr, err := sc.ReadMsgUDP(buf, make([]byte, 64))
+	if err != nil || string(buf[:n]) != "hello" {
+		t.Fatalf("Unexpected results (%q, %v), expected (\"hello\", nil)", buf[:n], err)
+	}
+	if addr.String() != client.LocalAddr().String() {
+		t.Errorf("Unexpected address %v, expected %v", addr, client.LocalAddr())
+	}
+	if writeN != 5 || readN != 5 || readFlags != 0 || readAddr.String() != addr.String() {
+		t.Errorf("Unexpected hook results (%d, %d, %d, %v)", writeN, readN, readFlags, readAddr)
+	}
+}
+
+func TestMsgUDPNotSupported(t *testing.T) {
+	var readErr, writeErr error
+	cc := &Conn{
+		Base: &mockConn{},
+		AfterReadMsgUDP: func(_ *Conn, _, _ []byte, _, _, _ int, _ *net.UDPAddr, err error) {
+			readErr = err
+		},
+		AfterWriteMsgUDP: func(_ *Conn, _, _ []byte, _ *net.UDPAddr, _, _ int, err error) {
+			writeErr = err
+		},
+	}
+	if _, _, _, _, err := cc.ReadMsgUDP(nil, nil); err != ErrNotUDPConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotUDPConn)
+	}
+	if _, _, err := cc.WriteMsgUDP(nil, nil, nil); err != ErrNotUDPConn {
+		t.Errorf("Unexpected error %v, expected %v", err, ErrNotUDPConn)
+	}
+	if readErr != ErrNotUDPConn || writeErr != ErrNotUDPConn {
+		t.Errorf("Unexpected hook errors (%v, %v), expected %v", readErr, writeErr, ErrNotUDPConn)
+	}
+}
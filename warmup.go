@@ -0,0 +1,77 @@
+package connxray
+
+import (
+	"sync"
+	"time"
+)
+
+// warmupStats splits the bytes transferred by a Conn into those moved during
+// its warmup window and those moved afterwards.
+type warmupStats struct {
+	mu          sync.Mutex
+	start       time.Time // beginning of the warmup window
+	end         time.Time // end of the warmup window, zero while warming up
+	last        time.Time // time of the most recent transfer
+	warmupBytes int64
+	steadyBytes int64
+}
+
+// trackWarmup accounts n bytes transferred in either direction, if WarmupBytes
+// or WarmupPeriod is set.
+func (c *Conn) trackWarmup(n int) {
+	if n <= 0 || (c.WarmupBytes <= 0 && c.WarmupPeriod <= 0) {
+		return
+	}
+	now := timeNow()
+	w := &c.warmup
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.start.IsZero() {
+		w.start = c.created
+		if w.start.IsZero() {
+			w.start = now
+		}
+	}
+	if w.end.IsZero() && c.WarmupPeriod > 0 && now.Sub(w.start) > c.WarmupPeriod {
+		w.end = w.start.Add(c.WarmupPeriod)
+	}
+	if w.end.IsZero() {
+		w.warmupBytes += int64(n)
+		if c.WarmupBytes > 0 && w.warmupBytes >= c.WarmupBytes {
+			w.end = now
+		}
+	} else {
+		w.steadyBytes += int64(n)
+	}
+	w.last = now
+}
+
+// WarmupThroughput returns the throughput, in bytes per second and counting
+// both directions, of the Conn during its warmup window and after it. The
+// warmup window starts when the Conn is created (or at its first transfer, if
+// the creation time is unknown) and ends once WarmupBytes have been
+// transferred or WarmupPeriod has elapsed, whichever comes first. The transfer
+// which crosses WarmupBytes still counts towards warmup.
+//
+// Each rate covers the time from the start of its window until the most
+// recent transfer, so idle time after the last transfer doesn't dilute it.
+// steady is zero while the Conn is still warming up, and both are zero unless
+// WarmupBytes or WarmupPeriod is set.
+func (c *Conn) WarmupThroughput() (warmup, steady float64) {
+	w := &c.warmup
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.end.IsZero() {
+		return bytesPerSecond(w.warmupBytes, w.last.Sub(w.start)), 0
+	}
+	return bytesPerSecond(w.warmupBytes, w.end.Sub(w.start)), bytesPerSecond(w.steadyBytes, w.last.Sub(w.end))
+}
+
+// bytesPerSecond returns n bytes over d in bytes per second, or zero if d is not
+// positive.
+func bytesPerSecond(n int64, d time.Duration) float64 {
+	if d <= 0 {
+		return 0
+	}
+	return float64(n) / d.Seconds()
+}
@@ -0,0 +1,101 @@
+package connxray
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+)
+
+// fakeClock replaces the clock for the duration of a test, starting at start.
+// The returned function advances the clock by d.
+func fakeClock(t *testing.T, start time.Time) func(d time.Duration) {
+	var elapsed atomic.Int64
+	now := func() time.Time {
+		return start.Add(time.Duration(elapsed.Load()))
+	}
+	clock.Store(&now)
+	t.Cleanup(func() { clock.Store(nil) })
+	return func(d time.Duration) {
+		elapsed.Add(int64(d))
+	}
+}
+
+// transfer is a step of a byte schedule: after advancing the clock by after,
+// n bytes are read or written.
+type transfer struct {
+	after time.Duration
+	n     int
+	write bool
+}
+
+func runSchedule(cc *Conn, advance func(time.Duration), schedule []transfer) {
+	for _, step := range schedule {
+		advance(step.after)
+		if step.write {
+			cc.Write(make([]byte, step.n))
+		} else {
+			cc.Read(make([]byte, step.n))
+		}
+	}
+}
+
+// fillConn returns a mockConn which fills every Read buffer and accepts all
+// writes.
+func fillConn() *mockConn {
+	return &mockConn{
+		readHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+		writeHandler: func(b []byte) (int, error) {
+			return len(b), nil
+		},
+	}
+}
+
+func TestWarmupThroughputPeriod(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	advance := fakeClock(t, start)
+	cc := &Conn{Base: fillConn(), created: start, WarmupPeriod: time.Second}
+	runSchedule(cc, advance, []transfer{
+		{after: 500 * time.Millisecond, n: 300},
+		{after: 500 * time.Millisecond, n: 700, write: true},
+	})
+	if warmup, steady := cc.WarmupThroughput(); warmup != 1000 || steady != 0 {
+		t.Errorf("Unexpected throughput (%v, %v), expected (1000, 0)", warmup, steady)
+	}
+	runSchedule(cc, advance, []transfer{
+		{after: 500 * time.Millisecond, n: 5000, write: true},
+		{after: 500 * time.Millisecond, n: 5000},
+	})
+	if warmup, steady := cc.WarmupThroughput(); warmup != 1000 || steady != 10000 {
+		t.Errorf("Unexpected throughput (%v, %v), expected (1000, 10000)", warmup, steady)
+	}
+	// Idle time after the last transfer doesn't change either rate.
+	advance(time.Hour)
+	if warmup, steady := cc.WarmupThroughput(); warmup != 1000 || steady != 10000 {
+		t.Errorf("Unexpected throughput (%v, %v), expected (1000, 10000)", warmup, steady)
+	}
+}
+
+func TestWarmupThroughputBytes(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	advance := fakeClock(t, start)
+	cc := &Conn{Base: fillConn(), created: start, WarmupBytes: 1000, WarmupPeriod: time.Minute}
+	runSchedule(cc, advance, []transfer{
+		{after: time.Second, n: 600},
+		{after: time.Second, n: 600, write: true},
+		{after: 500 * time.Millisecond, n: 3000},
+	})
+	if warmup, steady := cc.WarmupThroughput(); warmup != 600 || steady != 6000 {
+		t.Errorf("Unexpected throughput (%v, %v), expected (600, 6000)", warmup, steady)
+	}
+}
+
+func TestWarmupThroughputDisabled(t *testing.T) {
+	advance := fakeClock(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC))
+	cc := &Conn{Base: fillConn()}
+	runSchedule(cc, advance, []transfer{{after: time.Second, n: 100}})
+	if warmup, steady := cc.WarmupThroughput(); warmup != 0 || steady != 0 {
+		t.Errorf("Unexpected throughput (%v, %v), expected (0, 0)", warmup, steady)
+	}
+}